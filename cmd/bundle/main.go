@@ -5,36 +5,47 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
-	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/spf13/pflag"
 
 	"github.com/shipwright-io/build/pkg/bundle"
 	"github.com/shipwright-io/build/pkg/image"
+	"github.com/shipwright-io/build/pkg/prune"
 )
 
 type settings struct {
-	help                  bool
-	image                 string
-	prune                 bool
-	target                string
-	secretPath            string
-	resultFileImageDigest string
+	help                   bool
+	image                  string
+	url                    string
+	checksum               string
+	prune                  bool
+	target                 string
+	secretPath             string
+	caBundlePath           string
+	resultFileImageDigest  string
+	resultFileDirectory    string
+	resultFileCommitSha    string
+	resultFileIgnore       string
+	resultFileVerified     string
+	resultFilePruneWarning string
+	tokenCredentials       image.TokenCredentials
+	umask                  string
+	ownership              string
+	maxSize                int64
+	maxFiles               int
 }
 
 var flagValues settings
@@ -44,12 +55,31 @@ func init() {
 	pflag.BoolVar(&flagValues.help, "help", false, "Print the help")
 
 	// Main flags of the bundle step
-	pflag.StringVar(&flagValues.image, "image", "", "Location of the bundle image (mandatory)")
+	pflag.StringVar(&flagValues.image, "image", "", "Location of the bundle image, container image or OCI artifact (mandatory unless --url is set)")
+	pflag.StringVar(&flagValues.url, "url", "", "Location of a tar or gzip compressed tar file to download instead of an image (optional)")
+	pflag.StringVar(&flagValues.checksum, "checksum", "", "The checksum of the file of --url in the format sha256:<hex> (mandatory with --url)")
 	pflag.StringVar(&flagValues.target, "target", "/workspace/source", "The target directory to place the code")
-	pflag.StringVar(&flagValues.resultFileImageDigest, "result-file-image-digest", "", "A file to write the image digest")
+	pflag.StringVar(&flagValues.resultFileImageDigest, "result-file-image-digest", "", "A file to write the image digest, or the checksum of the downloaded file")
+	pflag.StringVar(&flagValues.resultFileDirectory, "result-file-directory", "", "A file to write the directory that was packed into the bundle image")
+	pflag.StringVar(&flagValues.resultFileCommitSha, "result-file-commit-sha", "", "A file to write the commit SHA of the Git worktree that contained the directory")
+	pflag.StringVar(&flagValues.resultFileIgnore, "result-file-ignore-patterns", "", "A file to write the .shpignore patterns as a JSON array")
+	pflag.StringVar(&flagValues.resultFileVerified, "result-file-verified-files", "", "A file to write the number of files that were verified against the digests in the bundle image")
 
 	pflag.StringVar(&flagValues.secretPath, "secret-path", "", "A directory that contains access credentials (optional)")
 	pflag.BoolVar(&flagValues.prune, "prune", false, "Delete bundle image from registry after it was pulled")
+	pflag.StringVar(&flagValues.resultFilePruneWarning, "result-file-prune-warning", "", "A file to write the reason why the image could not be deleted from the registry")
+	pflag.StringVar(&flagValues.caBundlePath, "ca-bundle-path", "", "A file with PEM-encoded certificates of certificate authorities to trust in addition to the system ones (optional)")
+
+	pflag.StringVar(&flagValues.tokenCredentials.TokenFile, "token-file", "", "A file that contains a token to authenticate with the registry, for example a projected ServiceAccount token (optional)")
+	pflag.StringVar(&flagValues.tokenCredentials.ExchangeEndpoint, "token-exchange-endpoint", "", "An OAuth 2.0 token exchange endpoint to exchange the token for a registry token (optional)")
+	pflag.StringVar(&flagValues.tokenCredentials.Audience, "token-exchange-audience", "", "The audience to request from the token exchange endpoint (optional)")
+	pflag.StringVar(&flagValues.tokenCredentials.Username, "token-username", "", "The username to use with the token as password, the token is used as bearer token if not set (optional)")
+
+	// Flags for the files that are unpacked from the bundle image
+	pflag.StringVar(&flagValues.umask, "umask", "022", "The octal permission bits to remove from the unpacked files and directories")
+	pflag.StringVar(&flagValues.ownership, "ownership", string(bundle.OwnershipCurrentUser), "The owner of the unpacked files: 'current' for the current user, 'preserve' for the owner stored in the bundle image, or a fixed 'uid:gid'")
+	pflag.Int64Var(&flagValues.maxSize, "max-size", 0, "The maximum total size of the unpacked files in bytes, 0 means no limit")
+	pflag.IntVar(&flagValues.maxFiles, "max-files", 0, "The maximum number of unpacked files, directories and symlinks, 0 means no limit")
 }
 
 func main() {
@@ -60,7 +90,10 @@ func main() {
 
 // Do is the main entry point of the bundle command
 func Do(ctx context.Context) error {
-	flagValues = settings{}
+	flagValues = settings{
+		umask:     "022",
+		ownership: string(bundle.OwnershipCurrentUser),
+	}
 	pflag.Parse()
 
 	if flagValues.help {
@@ -68,8 +101,21 @@ func Do(ctx context.Context) error {
 		return nil
 	}
 
-	if flagValues.image == "" {
-		return fmt.Errorf("mandatory flag --image is not set")
+	if flagValues.image == "" && flagValues.url == "" {
+		return fmt.Errorf("mandatory flag --image or --url is not set")
+	}
+
+	if flagValues.image != "" && flagValues.url != "" {
+		return fmt.Errorf("the flags --image and --url cannot be used together")
+	}
+
+	unpackOptions, err := getUnpackOptions()
+	if err != nil {
+		return err
+	}
+
+	if flagValues.url != "" {
+		return downloadAndUnpack(ctx, unpackOptions)
 	}
 
 	ref, err := name.ParseReference(flagValues.image)
@@ -77,15 +123,28 @@ func Do(ctx context.Context) error {
 		return err
 	}
 
-	options, auth, err := image.GetOptions(ctx, ref, true, flagValues.secretPath, "Shipwright Build")
+	options, auth, err := image.GetOptions(ctx, ref, true, flagValues.secretPath, flagValues.caBundlePath, "Shipwright Build")
 	if err != nil {
 		return err
 	}
 
+	if flagValues.tokenCredentials.IsConfigured() {
+		if flagValues.secretPath != "" {
+			return fmt.Errorf("the flags --secret-path and --token-file cannot be used together")
+		}
+
+		if auth, err = flagValues.tokenCredentials.AuthConfig(ctx); err != nil {
+			return err
+		}
+
+		options = append(options, remote.WithAuth(authn.FromConfig(*auth)))
+	}
+
 	log.Printf("Pulling image %q", ref)
-	img, err := bundle.PullAndUnpack(
+	img, err := bundle.PullAndUnpackWithOptions(
 		ref,
 		flagValues.target,
+		unpackOptions,
 		options...)
 	if err != nil {
 		return err
@@ -104,325 +163,176 @@ func Do(ctx context.Context) error {
 		}
 	}
 
-	if flagValues.prune {
-		// Some container registry implementations, i.e. library/registry:2 will fail to
-		// delete the image when there is no image digest given. Use image digest from the
-		// image pulling to construct an image name including tag and digest.
-		ref, err = name.NewDigest(fmt.Sprintf("%s@%s", ref.Name(), digest.String()))
-		if err != nil {
-			return err
-		}
-
-		log.Printf("Deleting image %q", ref)
-		if err := Prune(ref, options, *auth); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// Prune removes the image from the container registry
-//
-// Deleting a tag, or a whole repo is not as straightforward as initially
-// planned as DockerHub seems to restrict deleting a single tag for
-// standard users. This might be subject to change, but as of September
-// 2021 it is limited to the business tier. However, there is an API call
-// to delete the whole repository. In case there is only one tag used in
-// a repository, the effect is pretty much the same. For convenience, there
-// is a provider switch to deal with images on DockerHub differently.
-//
-// DockerHub images:
-//   - In case the repository only has one tag, the repository is deleted.
-//   - If there are multiple tags, the tag to be deleted is overwritten
-//     with an empty image (to remove the content, and save quota).
-//   - Edge case would be no tags in the repository, which is ignored.
-//
-// IBM Container Registry images:
-// Custom delete API call has to be used, since ICR does not support the
-// default registry API for deletions. The credentials need to have an
-// IBM API key, which is used to obtain an identity token that needs to
-// contains the respective authorization token for requests as well as
-// an account identifier to select the IBM account in which the registry
-// namespace and image is located.
-//
-// Other registries:
-// Use standard spec delete API request to delete the provided tag.
-func Prune(ref name.Reference, options []remote.Option, auth authn.AuthConfig) error {
-	switch {
-	case strings.Contains(ref.Context().RegistryStr(), "docker.io"):
-		list, err := remote.List(ref.Context(), options...)
-		if err != nil {
-			return err
-		}
-
-		switch len(list) {
-		case 0:
-			return nil
-
-		case 1:
-			var token string
-			token, err = dockerHubLogin(auth.Username, auth.Password)
-			if err != nil {
-				return err
-			}
-
-			return dockerHubRepoDelete(token, ref)
-
-		default:
-			log.Printf("Removing a specific image tag is not supported on %q, the respective image tag will be overwritten with an empty image.\n", ref.Context().RegistryStr())
-
-			// In case the input argument included a digest, the reference
-			// needs to be updated to exclude the digest for the empty image
-			// override to succeed.
-			switch ref.(type) {
-			case name.Digest:
-				ref, err = name.NewTag(ref.Context().Name())
-				if err != nil {
-					return err
-				}
-			}
-
-			return remote.Write(
-				ref,
-				empty.Image,
-				options...,
-			)
-		}
-
-	case strings.Contains(ref.Context().RegistryStr(), "icr.io"):
-		token, accountID, err := icrLogin(ref.Context().RegistryStr(), auth.Username, auth.Password)
-		if err != nil {
-			return err
-		}
-
-		return icrDelete(token, accountID, ref)
-
-	default:
-		return remote.Delete(
-			ref,
-			options...,
-		)
+	// bundle images without a file index, for example from older versions, are not verified
+	metadata, err := bundle.GetMetadata(img)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve metadata from bundle image: %w", err)
 	}
-}
 
-func httpClient() *http.Client {
-	return &http.Client{
-		Timeout: 30 * time.Second,
+	if err := metadata.VerifyFiles(flagValues.target); err != nil {
+		return err
 	}
-}
 
-func dockerHubLogin(username string, password string) (string, error) {
-	type LoginData struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+	if len(metadata.Files) > 0 {
+		log.Printf("Verified %d files against the digests in the bundle image\n", len(metadata.Files))
 	}
 
-	loginData, err := json.Marshal(LoginData{Username: username, Password: password})
-	if err != nil {
-		return "", err
+	if err := writeMetadataResults(metadata); err != nil {
+		return err
 	}
 
-	req, err := http.NewRequest("POST", "https://hub.docker.com/v2/users/login/", bytes.NewReader(loginData))
-	if err != nil {
-		return "", err
+	if flagValues.prune {
+		return pruneImage(ctx, ref, digest.String(), options, *auth)
 	}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
+	return nil
+}
 
-	resp, err := httpClient().Do(req)
+// pruneImage deletes the image from the registry. The image was already obtained,
+// therefore a failed deletion does not fail the command, but is reported as warning.
+func pruneImage(ctx context.Context, ref name.Reference, digest string, options []remote.Option, auth authn.AuthConfig) error {
+	client, err := httpClient(30 * time.Second)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	defer resp.Body.Close()
-
-	bodyData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	// Some container registry implementations, i.e. library/registry:2 will fail to
+	// delete the image when there is no image digest given. The digest from the image
+	// pulling is used, the tag is used by the providers that only delete tags.
+	target := prune.Image{Repository: ref.Context(), Digest: digest}
+	if tag, ok := ref.(name.Tag); ok {
+		target.Tag = tag.TagStr()
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		type LoginToken struct {
-			Token string `json:"token"`
-		}
-
-		var loginToken LoginToken
-		if err := json.Unmarshal(bodyData, &loginToken); err != nil {
-			return "", err
-		}
+	log.Printf("Deleting image %q", target)
+	provider, err := prune.Prune(ctx, prune.Config{Client: client, Auth: auth, Options: options}, target, prune.DefaultProviders()...)
+	if err == nil {
+		log.Printf("Deleted image %q with the %s API\n", target, provider)
+		return nil
+	}
 
-		return fmt.Sprintf("JWT %s", loginToken.Token), nil
+	warning := fmt.Sprintf("failed to delete image %q: %v", target, err)
+	log.Printf("Warning: %s\n", warning)
 
-	default:
-		return "", fmt.Errorf(string(bodyData))
+	if flagValues.resultFilePruneWarning != "" {
+		return os.WriteFile(flagValues.resultFilePruneWarning, []byte(warning), 0644)
 	}
+
+	return nil
 }
 
-func dockerHubRepoDelete(token string, ref name.Reference) error {
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("https://hub.docker.com/v2/repositories/%s/", ref.Context().RepositoryStr()), nil)
-	if err != nil {
-		return err
+// downloadAndUnpack downloads the file of the --url flag, and unpacks it after
+// it was verified against the checksum
+func downloadAndUnpack(ctx context.Context, unpackOptions bundle.UnpackOptions) error {
+	if flagValues.checksum == "" {
+		return fmt.Errorf("mandatory flag --checksum is not set")
 	}
 
-	req.Header.Set("Authorization", token)
+	if flagValues.prune {
+		return fmt.Errorf("the flag --prune can only be used with --image")
+	}
 
-	resp, err := httpClient().Do(req)
+	// downloads of large files take longer than the requests to registry APIs
+	client, err := httpClient(0)
 	if err != nil {
 		return err
 	}
 
-	defer resp.Body.Close()
-
-	respData, err := io.ReadAll(resp.Body)
+	log.Printf("Downloading %q", flagValues.url)
+	digest, err := bundle.DownloadAndUnpack(ctx, client, flagValues.url, flagValues.checksum, flagValues.target, unpackOptions, bundle.DownloadOptions{})
 	if err != nil {
 		return err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusAccepted:
-		return nil
+	log.Printf("File content was extracted to %s\n", flagValues.target)
 
-	default:
-		return fmt.Errorf("failed to delete image %q: %s (HTTP status code %d)",
-			ref.String(),
-			string(respData),
-			resp.StatusCode,
-		)
+	if flagValues.resultFileImageDigest != "" {
+		return os.WriteFile(flagValues.resultFileImageDigest, []byte(digest), 0644)
 	}
-}
 
-func icrLogin(registry, username, apikey string) (string, string, error) {
-	// IBM Container Registry API calls will only work in case an API key is available
-	if username != "iamapikey" {
-		return "", "", fmt.Errorf("provided access credentials for %q do not contain an IBM API key", registry)
-	}
+	return nil
+}
 
-	iamEndpoint := "https://iam.cloud.ibm.com/identity/token"
-	if strings.Contains(registry, "stg.icr.io") {
-		iamEndpoint = "https://iam.test.cloud.ibm.com/identity/token"
+func writeMetadataResults(metadata bundle.Metadata) error {
+	ignorePatterns, err := json.Marshal(metadata.IgnorePatterns)
+	if err != nil {
+		return err
 	}
 
-	data := fmt.Sprintf("grant_type=%s&apikey=%s",
-		url.QueryEscape("urn:ibm:params:oauth:grant-type:apikey"),
-		apikey,
-	)
+	for _, result := range []struct{ file, value string }{
+		{flagValues.resultFileDirectory, metadata.Directory},
+		{flagValues.resultFileCommitSha, metadata.CommitSha},
+		{flagValues.resultFileIgnore, string(ignorePatterns)},
+		{flagValues.resultFileVerified, strconv.Itoa(len(metadata.Files))},
+	} {
+		if result.file == "" {
+			continue
+		}
 
-	req, err := http.NewRequest("POST", iamEndpoint, strings.NewReader(data))
-	if err != nil {
-		return "", "", err
+		if err := os.WriteFile(result.file, []byte(result.value), 0644); err != nil {
+			return err
+		}
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/json")
+	return nil
+}
 
-	resp, err := httpClient().Do(req)
-	if err != nil {
-		return "", "", err
+func getUnpackOptions() (bundle.UnpackOptions, error) {
+	unpackOptions := bundle.DefaultUnpackOptions()
+
+	umask, err := strconv.ParseUint(flagValues.umask, 8, 32)
+	if err != nil || umask > 0777 {
+		return unpackOptions, fmt.Errorf("flag --umask must be an octal value between 000 and 777: %q", flagValues.umask)
 	}
 
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", err
-	}
-
-	switch resp.StatusCode {
-	case http.StatusOK:
-		type ibmCloudIdentityToken struct {
-			AccessToken  string `json:"access_token"`
-			RefreshToken string `json:"refresh_token"`
-			TokenType    string `json:"token_type"`
-			Scope        string `json:"scope"`
-			ExpiresIn    int64  `json:"expires_in"`
-			Expiration   int64  `json:"expiration"`
-		}
+	unpackOptions.Umask = os.FileMode(umask)
 
-		var identityToken ibmCloudIdentityToken
-		if err := json.Unmarshal(body, &identityToken); err != nil {
-			return "", "", err
-		}
+	switch ownership := bundle.Ownership(flagValues.ownership); ownership {
+	case bundle.OwnershipCurrentUser, bundle.OwnershipPreserve:
+		unpackOptions.Ownership = ownership
 
-		var token = fmt.Sprintf("%s %s", identityToken.TokenType, identityToken.AccessToken)
-
-		var accountID string
-		_, _ = jwt.Parse(identityToken.AccessToken, func(t *jwt.Token) (interface{}, error) {
-			switch obj := t.Claims.(type) {
-			case jwt.MapClaims:
-				if account, ok := obj["account"]; ok {
-					switch accountMap := account.(type) {
-					case map[string]interface{}:
-						switch tmp := accountMap["bss"].(type) {
-						case string:
-							accountID = tmp
-						}
-					}
-				}
-			}
-
-			return nil, nil
-		})
-
-		if accountID == "" {
-			return "", "", fmt.Errorf("failed to obtain account ID from identity token")
+	default:
+		uid, gid, found := strings.Cut(flagValues.ownership, ":")
+		if !found {
+			return unpackOptions, fmt.Errorf("flag --ownership must be 'current', 'preserve' or 'uid:gid': %q", flagValues.ownership)
 		}
 
-		return token, accountID, nil
-
-	default:
-		var responseMsg map[string]interface{}
-		if err := json.Unmarshal(body, &responseMsg); err != nil {
-			return "", "", err
+		if unpackOptions.UID, err = strconv.Atoi(uid); err != nil || unpackOptions.UID < 0 {
+			return unpackOptions, fmt.Errorf("flag --ownership contains an invalid user ID: %q", uid)
 		}
 
-		errorCode, errorCodeFound := responseMsg["errorCode"]
-		errorMessage, errorMessageFound := responseMsg["errorMessage"]
-		if errorCodeFound && errorMessageFound {
-			return "", "", fmt.Errorf("failed to obtain identity token from IAM: %v (%v)", errorMessage, errorCode)
+		if unpackOptions.GID, err = strconv.Atoi(gid); err != nil || unpackOptions.GID < 0 {
+			return unpackOptions, fmt.Errorf("flag --ownership contains an invalid group ID: %q", gid)
 		}
 
-		return "", "", fmt.Errorf("failed to obtain identity token from IAM: %s", string(body))
+		unpackOptions.Ownership = bundle.OwnershipFixed
 	}
-}
 
-func icrDelete(token string, accountID string, ref name.Reference) error {
-	deleteURL := fmt.Sprintf("https://%s/api/v1/images/%s",
-		ref.Context().RegistryStr(),
-		url.QueryEscape(ref.String()),
-	)
-
-	req, err := http.NewRequest("DELETE", deleteURL, nil)
-	if err != nil {
-		return err
+	if flagValues.maxSize < 0 || flagValues.maxFiles < 0 {
+		return unpackOptions, fmt.Errorf("flags --max-size and --max-files must not be negative")
 	}
 
-	req.Header.Set("Account", accountID)
-	req.Header.Set("Authorization", token)
-	req.Header.Set("Accept", "application/json")
+	unpackOptions.MaxSize = flagValues.maxSize
+	unpackOptions.MaxFiles = flagValues.maxFiles
 
-	resp, err := httpClient().Do(req)
-	if err != nil {
-		return err
-	}
+	return unpackOptions, nil
+}
 
-	defer resp.Body.Close()
+// httpClient returns a client that trusts the certificate authorities of the
+// optional CA bundle in addition to the system ones
+func httpClient(timeout time.Duration) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
 
-	respData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return nil
+	if flagValues.caBundlePath != "" {
+		pool, err := image.CertPool(flagValues.caBundlePath)
+		if err != nil {
+			return nil, err
+		}
 
-	default:
-		return fmt.Errorf("failed to delete image %q: %s (HTTP status code %d)",
-			ref.String(),
-			string(respData),
-			resp.StatusCode,
-		)
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		client.Transport = transport
 	}
+
+	return client, nil
 }
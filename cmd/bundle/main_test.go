@@ -5,20 +5,29 @@
 package main_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	. "github.com/shipwright-io/build/cmd/bundle"
+	"github.com/shipwright-io/build/pkg/bundle"
 	"github.com/shipwright-io/build/pkg/image"
+	"github.com/shipwright-io/build/pkg/prune"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
 	containerreg "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"k8s.io/apimachinery/pkg/util/rand"
@@ -108,6 +117,53 @@ var _ = Describe("Bundle Loader", func() {
 				)).To(MatchError("failed to find registry credentials for secret.typo.registry.com, available configurations: secret.private.registry.com"))
 			})
 		})
+
+		It("should fail in case both the image and the URL are specified", func() {
+			Expect(run(
+				"--image", exampleImage,
+				"--url", "https://example.com/source.tar.gz",
+			)).To(MatchError("the flags --image and --url cannot be used together"))
+		})
+
+		It("should fail in case the URL is specified without a checksum", func() {
+			Expect(run(
+				"--url", "https://example.com/source.tar.gz",
+			)).To(MatchError("mandatory flag --checksum is not set"))
+		})
+
+		It("should fail in case the URL is specified with the prune flag", func() {
+			Expect(run(
+				"--url", "https://example.com/source.tar.gz",
+				"--checksum", "sha256:"+strings.Repeat("0", 64),
+				"--prune",
+			)).To(MatchError("the flag --prune can only be used with --image"))
+		})
+
+		It("should fail in case the umask is not an octal value", func() {
+			Expect(run(
+				"--image", exampleImage,
+				"--umask", "0999",
+			)).To(MatchError(ContainSubstring("flag --umask must be an octal value")))
+		})
+
+		It("should fail in case the ownership is invalid", func() {
+			Expect(run(
+				"--image", exampleImage,
+				"--ownership", "root",
+			)).To(MatchError(ContainSubstring("flag --ownership must be")))
+
+			Expect(run(
+				"--image", exampleImage,
+				"--ownership", "1000:group",
+			)).To(MatchError(ContainSubstring("invalid group ID")))
+		})
+
+		It("should fail in case a limit is negative", func() {
+			Expect(run(
+				"--image", exampleImage,
+				"--max-files", "-1",
+			)).To(MatchError(ContainSubstring("must not be negative")))
+		})
 	})
 
 	Context("Pulling image anonymously", func() {
@@ -140,12 +196,168 @@ var _ = Describe("Bundle Loader", func() {
 		})
 	})
 
+	Context("Pulling a bundle image with metadata from a local registry", func() {
+		var bundleImage string
+
+		BeforeEach(func() {
+			server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			DeferCleanup(server.Close)
+
+			ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", strings.TrimPrefix(server.URL, "http://")))
+			Expect(err).ToNot(HaveOccurred())
+
+			digest, err := bundle.PackAndPush(ref, "../../test/bundle")
+			Expect(err).ToNot(HaveOccurred())
+
+			bundleImage = digest.String()
+		})
+
+		It("should verify the files and store the metadata into the result files", func() {
+			withTempDir(func(target string) {
+				resultDir := GinkgoT().TempDir()
+				Expect(run(
+					"--image", bundleImage,
+					"--target", target,
+					"--result-file-directory", filepath.Join(resultDir, "directory"),
+					"--result-file-commit-sha", filepath.Join(resultDir, "commit-sha"),
+					"--result-file-ignore-patterns", filepath.Join(resultDir, "ignore-patterns"),
+					"--result-file-verified-files", filepath.Join(resultDir, "verified-files"),
+				)).To(Succeed())
+
+				Expect(filecontent(filepath.Join(resultDir, "directory"))).To(Equal("bundle"))
+				Expect(filecontent(filepath.Join(resultDir, "commit-sha"))).To(MatchRegexp("^[0-9a-f]{40}$"))
+				Expect(filecontent(filepath.Join(resultDir, "ignore-patterns"))).To(Equal(`[".someToolDir"]`))
+				Expect(filecontent(filepath.Join(resultDir, "verified-files"))).To(Equal("4"))
+			})
+		})
+	})
+
+	Context("Pruning a bundle image from a local registry", func() {
+		var (
+			bundleImage  string
+			bundleDigest string
+			allowDelete  bool
+		)
+
+		BeforeEach(func() {
+			allowDelete = true
+			registryHandler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete && !allowDelete {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					_, _ = w.Write([]byte(`{"errors":[{"code":"UNSUPPORTED","message":"The operation is unsupported."}]}`))
+					return
+				}
+
+				registryHandler.ServeHTTP(w, r)
+			}))
+			DeferCleanup(server.Close)
+
+			ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", strings.TrimPrefix(server.URL, "http://")))
+			Expect(err).ToNot(HaveOccurred())
+
+			digest, err := bundle.PackAndPush(ref, "../../test/bundle")
+			Expect(err).ToNot(HaveOccurred())
+
+			bundleImage = ref.String()
+			bundleDigest = digest.String()
+		})
+
+		It("should delete the image after it was pulled", func() {
+			withTempDir(func(target string) {
+				warningFile := filepath.Join(GinkgoT().TempDir(), "prune-warning")
+				Expect(run(
+					"--image", bundleImage,
+					"--target", target,
+					"--prune",
+					"--result-file-prune-warning", warningFile,
+				)).To(Succeed())
+
+				Expect(warningFile).ToNot(BeAnExistingFile())
+
+				ref, err := name.ParseReference(bundleDigest)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = remote.Head(ref)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		It("should report a warning instead of failing if the registry does not support the deletion", func() {
+			allowDelete = false
+
+			withTempDir(func(target string) {
+				warningFile := filepath.Join(GinkgoT().TempDir(), "prune-warning")
+				Expect(run(
+					"--image", bundleImage,
+					"--target", target,
+					"--prune",
+					"--result-file-prune-warning", warningFile,
+				)).To(Succeed())
+
+				Expect(filecontent(warningFile)).To(ContainSubstring("deleting the image with the OCI distribution API is not supported"))
+			})
+		})
+	})
+
+	Context("Downloading a tarball", func() {
+		var (
+			tarballURL string
+			checksum   string
+		)
+
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			Expect(tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 5, Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte("hello"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tw.Close()).To(Succeed())
+
+			data := buf.Bytes()
+			checksum = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(data)
+			}))
+			DeferCleanup(server.Close)
+
+			tarballURL = server.URL + "/source.tar"
+		})
+
+		It("should download, verify and unpack the tarball and store its checksum", func() {
+			withTempDir(func(target string) {
+				withTempFile("digest", func(filename string) {
+					Expect(run(
+						"--url", tarballURL,
+						"--checksum", checksum,
+						"--target", target,
+						"--result-file-image-digest", filename,
+					)).To(Succeed())
+
+					Expect(filecontent(filepath.Join(target, "README.md"))).To(Equal("hello"))
+					Expect(filecontent(filename)).To(Equal(checksum))
+				})
+			})
+		})
+
+		It("should fail if the tarball does not match the checksum", func() {
+			withTempDir(func(target string) {
+				Expect(run(
+					"--url", tarballURL,
+					"--checksum", "sha256:"+strings.Repeat("0", 64),
+					"--target", target,
+				)).To(MatchError(ContainSubstring("does not match the checksum")))
+			})
+		})
+	})
+
 	Context("Pulling image from private location", func() {
 		var testImage string
 		var dockerConfigFile string
 
 		var copyImage = func(src, dst name.Reference) {
-			options, _, err := image.GetOptions(context.TODO(), src, true, dockerConfigFile, "test-agent")
+			options, _, err := image.GetOptions(context.TODO(), src, true, dockerConfigFile, "", "test-agent")
 			Expect(err).ToNot(HaveOccurred())
 
 			srcDesc, err := remote.Get(src, options...)
@@ -154,7 +366,7 @@ var _ = Describe("Bundle Loader", func() {
 			srcImage, err := srcDesc.Image()
 			Expect(err).ToNot(HaveOccurred())
 
-			options, _, err = image.GetOptions(context.TODO(), dst, true, dockerConfigFile, "test-agent")
+			options, _, err = image.GetOptions(context.TODO(), dst, true, dockerConfigFile, "", "test-agent")
 			Expect(err).ToNot(HaveOccurred())
 
 			err = remote.Write(dst, srcImage, options...)
@@ -191,11 +403,18 @@ var _ = Describe("Bundle Loader", func() {
 			ref, err := name.ParseReference(testImage)
 			Expect(err).ToNot(HaveOccurred())
 
-			options, auth, err := image.GetOptions(context.TODO(), ref, true, dockerConfigFile, "test-agent")
+			options, auth, err := image.GetOptions(context.TODO(), ref, true, dockerConfigFile, "", "test-agent")
+			Expect(err).ToNot(HaveOccurred())
+
+			digest, err := remote.Head(ref, options...)
 			Expect(err).ToNot(HaveOccurred())
 
 			// Delete test image (best effort)
-			_ = Prune(ref, options, *auth)
+			_, _ = prune.Prune(context.TODO(),
+				prune.Config{Client: http.DefaultClient, Auth: *auth, Options: options},
+				prune.Image{Repository: ref.Context(), Tag: ref.Identifier(), Digest: digest.Digest.String()},
+				prune.DefaultProviders()...,
+			)
 		})
 
 		It("should pull and unpack an image from a private registry", func() {
@@ -224,7 +443,7 @@ var _ = Describe("Bundle Loader", func() {
 				ref, err := name.ParseReference(testImage)
 				Expect(err).ToNot(HaveOccurred())
 
-				options, _, err := image.GetOptions(context.TODO(), ref, true, dockerConfigFile, "test-agent")
+				options, _, err := image.GetOptions(context.TODO(), ref, true, dockerConfigFile, "", "test-agent")
 				Expect(err).ToNot(HaveOccurred())
 
 				_, err = remote.Head(ref, options...)
@@ -0,0 +1,836 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	gogitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	gogithttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gogitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	shpgit "github.com/shipwright-io/build/pkg/git"
+)
+
+const (
+	backendGit   = "git"
+	backendGoGit = "go-git"
+)
+
+// fetchHead is the reference that revisions, which are not checked out as a branch, are fetched into
+const fetchHead = plumbing.ReferenceName("FETCH_HEAD")
+
+// goGitRemote contains the URL of the repository and the settings to connect to it
+type goGitRemote struct {
+	url            string
+	auth           transport.AuthMethod
+	caBundle       []byte
+	hasCredentials bool
+}
+
+// goGitRevision is the reference to fetch for the requested revision and how to check it out
+type goGitRevision struct {
+	refSpec gogitconfig.RefSpec
+	branch  plumbing.ReferenceName
+	hash    plumbing.Hash
+}
+
+// runGoGitClone clones the repository using the go-git library, so that no Git, SSH or Git LFS binaries
+// are required, and writes the same result files as the clone using the Git CLI
+func runGoGitClone(ctx context.Context) error {
+	if err := checkGoGitSupport(); err != nil {
+		return err
+	}
+
+	remote, err := goGitRemoteSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	advRefs, err := advertisedReferences(ctx, remote)
+	if err != nil {
+		return goGitError(err, remote.hasCredentials)
+	}
+
+	revision, err := resolveGoGitRevision(advRefs)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Cloning %s into %s using go-git\n", displayURL, flagValues.target)
+
+	repository, err := gogit.PlainInit(flagValues.target, false)
+	if err != nil {
+		return err
+	}
+
+	if _, err := repository.CreateRemote(&gogitconfig.RemoteConfig{Name: gogit.DefaultRemoteName, URLs: []string{remote.url}}); err != nil {
+		return err
+	}
+
+	var fetch = func(refSpec gogitconfig.RefSpec, depth int) error {
+		err := repository.FetchContext(ctx, &gogit.FetchOptions{
+			RemoteName: gogit.DefaultRemoteName,
+			RefSpecs:   []gogitconfig.RefSpec{refSpec},
+			Depth:      depth,
+			Tags:       gogit.NoTags,
+			Auth:       remote.auth,
+			CABundle:   remote.caBundle,
+		})
+		if errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+			return nil
+		}
+
+		return err
+	}
+
+	if revision.refSpec != "" {
+		err := fetch(revision.refSpec, int(flagValues.depth))
+		switch {
+		case errors.Is(err, gogit.ErrExactSHA1NotSupported):
+			revision.refSpec = ""
+
+		case err != nil:
+			return goGitError(err, remote.hasCredentials)
+		}
+	}
+
+	// abbreviated commit SHAs, and commit SHAs that the server does not
+	// allow to fetch directly, need to be looked up in the history
+	if revision.refSpec == "" {
+		log.Printf("Failed to fetch commit %s directly, fetching all branches instead\n", flagValues.revision)
+		if err := fetch("+refs/heads/*:refs/remotes/origin/*", 0); err != nil {
+			return goGitError(err, remote.hasCredentials)
+		}
+
+		hash, err := repository.ResolveRevision(plumbing.Revision(flagValues.revision))
+		if err != nil {
+			return revisionNotFoundError(flagValues.revision, err)
+		}
+
+		revision.hash = *hash
+	}
+
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if paths := sparseCheckoutPaths(); len(paths) > 0 {
+		if err := sparseCheckout(repository, revision, paths); err != nil {
+			return err
+		}
+	} else {
+		checkoutOptions := &gogit.CheckoutOptions{Branch: revision.branch, Hash: revision.hash}
+		if revision.branch != "" {
+			checkoutOptions.Hash = plumbing.ZeroHash
+		}
+
+		if err := worktree.Checkout(checkoutOptions); err != nil {
+			return err
+		}
+	}
+
+	var submodules []submodule
+	if !flagValues.skipSubmodules {
+		if submodules, err = updateGoGitSubmodules(ctx, worktree, remote, "", flagValues.submodulePaths); err != nil {
+			return err
+		}
+
+		// the same order as the output of git submodule status
+		sort.Slice(submodules, func(i, j int) bool { return submodules[i].Path < submodules[j].Path })
+	}
+
+	if !flagValues.skipLFS && usesLFS(flagValues.target) {
+		return &ExitError{
+			Code:    102,
+			Message: "the repository uses Git LFS, which is not supported by the go-git backend, skip Git LFS to keep the Git LFS pointers",
+			Reason:  shpgit.LFSFetchFailed,
+		}
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return err
+	}
+
+	if err := writeGoGitResults(repository, head, advRefs, submodules); err != nil {
+		return err
+	}
+
+	loadedRevision := flagValues.revision
+	if loadedRevision == "" {
+		loadedRevision = head.Name().Short()
+	}
+
+	log.Printf("Successfully loaded %s (%s) into %s\n",
+		displayURL,
+		loadedRevision,
+		flagValues.target,
+	)
+
+	return nil
+}
+
+// checkGoGitSupport rejects the options that the go-git backend does not implement
+func checkGoGitSupport() error {
+	var unsupported string
+	switch {
+	case flagValues.signatureKeysPath != "":
+		unsupported = "signature verification"
+
+	case len(flagValues.lfsIncludes) > 0:
+		unsupported = "Git LFS include patterns"
+	}
+
+	if unsupported != "" {
+		return &ExitError{
+			Code:    102,
+			Message: fmt.Sprintf("%s is not supported by the go-git backend, use the git backend instead", unsupported),
+		}
+	}
+
+	return nil
+}
+
+// goGitRemoteSettings determines the URL of the repository and the authentication
+// method of the secret, the credential checks are the same as for the Git CLI
+func goGitRemoteSettings(ctx context.Context) (*goGitRemote, error) {
+	var remote = &goGitRemote{url: flagValues.url}
+
+	if flagValues.caBundlePath != "" {
+		data, err := os.ReadFile(flagValues.caBundlePath)
+		if err != nil {
+			return nil, err
+		}
+
+		remote.caBundle = data
+	}
+
+	if flagValues.secretPath == "" {
+		return remote, nil
+	}
+
+	credType, err := checkCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	remote.hasCredentials = true
+
+	switch credType {
+	case typePrivateKey:
+		// go-git does not support url.insteadOf, therefore the HTTPS URL is rewritten directly
+		if flagValues.gitURLRewrite && strings.HasPrefix(flagValues.url, "http") {
+			repoURL, err := url.Parse(flagValues.url)
+			if err != nil {
+				return nil, err
+			}
+
+			remote.url = fmt.Sprintf("ssh://git@%s%s", repoURL.Host, repoURL.Path)
+		}
+
+		endpoint, err := transport.NewEndpoint(remote.url)
+		if err != nil {
+			return nil, err
+		}
+
+		user := endpoint.User
+		if user == "" {
+			user = "git"
+		}
+
+		data, err := os.ReadFile(filepath.Join(flagValues.secretPath, "ssh-privatekey"))
+		if err != nil {
+			return nil, err
+		}
+
+		publicKeys, err := gogitssh.NewPublicKeys(user, data, "")
+		if err != nil {
+			return nil, &ExitError{Code: 110, Message: shpgit.AuthInvalidKey.ToMessage(), Cause: err, Reason: shpgit.AuthInvalidKey}
+		}
+
+		// go-git cannot accept new host keys like the Git CLI, therefore the host key is always verified
+		knownHostsFile := filepath.Join(flagValues.secretPath, "known_hosts")
+		if !hasFile(knownHostsFile) {
+			return nil, &ExitError{
+				Code:    110,
+				Message: "the go-git backend requires a known_hosts file in the SSH secret to verify the host key of the Git server",
+			}
+		}
+
+		if publicKeys.HostKeyCallback, err = gogitssh.NewKnownHostsCallback(knownHostsFile); err != nil {
+			return nil, err
+		}
+
+		remote.auth = publicKeys
+
+	case typeUsernamePassword:
+		username, err := os.ReadFile(filepath.Join(flagValues.secretPath, "username"))
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := os.ReadFile(filepath.Join(flagValues.secretPath, "password"))
+		if err != nil {
+			return nil, err
+		}
+
+		remote.auth = &gogithttp.BasicAuth{Username: string(username), Password: string(password)}
+
+	case typeGitHubApp:
+		token, err := githubAppInstallationToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// GitHub accepts installation tokens as password for basic authentication
+		remote.auth = &gogithttp.BasicAuth{Username: "x-access-token", Password: token}
+
+	case typeBearerToken:
+		token, err := os.ReadFile(filepath.Join(flagValues.secretPath, "token"))
+		if err != nil {
+			return nil, err
+		}
+
+		remote.auth = &gogithttp.TokenAuth{Token: strings.TrimSpace(string(token))}
+	}
+
+	return remote, nil
+}
+
+// advertisedReferences returns the references of the repository, which contain the
+// commits that annotated tags point at, unlike the references that go-git lists
+func advertisedReferences(ctx context.Context, remote *goGitRemote) (*packp.AdvRefs, error) {
+	endpoint, err := transport.NewEndpoint(remote.url)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.CaBundle = remote.caBundle
+
+	transportClient, err := client.NewClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := transportClient.NewUploadPackSession(endpoint, remote.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	defer session.Close()
+	return session.AdvertisedReferencesContext(ctx)
+}
+
+// resolveGoGitRevision determines the reference to fetch for the requested revision, which
+// is the default branch, a branch, a tag, a reference, a refspec, or a commit SHA
+func resolveGoGitRevision(advRefs *packp.AdvRefs) (*goGitRevision, error) {
+	refs, err := advRefs.AllReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range advRefs.References {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	// commitOf returns the commit of a reference, annotated tags are advertised together with their commit
+	var commitOf = func(name plumbing.ReferenceName) (plumbing.Hash, bool) {
+		if hash, ok := advRefs.Peeled[name.String()]; ok {
+			return hash, true
+		}
+
+		ref, err := storer.ResolveReference(refs, name)
+		if err != nil {
+			return plumbing.ZeroHash, false
+		}
+
+		return ref.Hash(), true
+	}
+
+	var branchRevision = func(branch plumbing.ReferenceName) *goGitRevision {
+		return &goGitRevision{
+			refSpec: gogitconfig.RefSpec(fmt.Sprintf("+%s:%s", branch, branch)),
+			branch:  branch,
+		}
+	}
+
+	var fetchHeadRevision = func(name plumbing.ReferenceName, hash plumbing.Hash) *goGitRevision {
+		return &goGitRevision{
+			refSpec: gogitconfig.RefSpec(fmt.Sprintf("+%s:%s", name, fetchHead)),
+			hash:    hash,
+		}
+	}
+
+	revision := flagValues.revision
+	switch {
+	case revision == "":
+		if head, err := refs.Reference(plumbing.HEAD); err == nil && head.Type() == plumbing.SymbolicReference {
+			return branchRevision(head.Target()), nil
+		}
+
+		// servers that do not advertise the target of HEAD, use the first branch with the same commit
+		for _, name := range names {
+			if advRefs.Head != nil && plumbing.ReferenceName(name).IsBranch() && advRefs.References[name] == *advRefs.Head {
+				return branchRevision(plumbing.ReferenceName(name)), nil
+			}
+		}
+
+		return nil, revisionNotFoundError(plumbing.HEAD.String(), nil)
+
+	case isRefspec(revision):
+		refSpec := gogitconfig.RefSpec(revision)
+		if !strings.Contains(revision, ":") {
+			refSpec = gogitconfig.RefSpec(fmt.Sprintf("+%s:%s", strings.TrimPrefix(revision, "+"), fetchHead))
+		}
+
+		if err := refSpec.Validate(); err != nil || refSpec.IsWildcard() {
+			return nil, &ExitError{
+				Code:    102,
+				Message: fmt.Sprintf("the refspec %q is not supported by the go-git backend, it must reference exactly one reference", revision),
+				Cause:   err,
+			}
+		}
+
+		hash, ok := commitOf(plumbing.ReferenceName(refSpec.Src()))
+		if !ok {
+			return nil, revisionNotFoundError(revision, nil)
+		}
+
+		return &goGitRevision{refSpec: refSpec, hash: hash}, nil
+	}
+
+	if branch := plumbing.NewBranchReferenceName(revision); advRefs.References[branch.String()] != plumbing.ZeroHash {
+		return branchRevision(branch), nil
+	}
+
+	if tag := plumbing.NewTagReferenceName(revision); advRefs.References[tag.String()] != plumbing.ZeroHash {
+		hash, _ := commitOf(tag)
+		return &goGitRevision{
+			refSpec: gogitconfig.RefSpec(fmt.Sprintf("+%s:%s", tag, tag)),
+			hash:    hash,
+		}, nil
+	}
+
+	if commitShaRegEx.MatchString(revision) {
+		// a commit that a reference points at is fetched using the reference
+		for _, name := range names {
+			if hash, ok := commitOf(plumbing.ReferenceName(name)); ok && strings.HasPrefix(hash.String(), revision) {
+				return fetchHeadRevision(plumbing.ReferenceName(name), hash), nil
+			}
+		}
+
+		// a full commit SHA can be fetched directly if the server allows it
+		if len(revision) == fullCommitShaLength {
+			return fetchHeadRevision(plumbing.ReferenceName(revision), plumbing.NewHash(revision)), nil
+		}
+
+		// an empty refspec makes the caller look up the commit in the history
+		return &goGitRevision{}, nil
+	}
+
+	return nil, revisionNotFoundError(revision, nil)
+}
+
+// sparseCheckout checks out the revision, but only writes the files of the selected directories to the
+// worktree, and marks the other files to be skipped. Like the cone mode of git sparse-checkout, it keeps the
+// files of the parent directories. The SparseCheckoutDirectories option of go-git cannot be used, because
+// it applies the skip flag of the first file in a directory to the whole directory.
+func sparseCheckout(repository *gogit.Repository, revision *goGitRevision, paths []string) error {
+	hash, head := revision.hash, plumbing.NewHashReference(plumbing.HEAD, revision.hash)
+	if revision.branch != "" {
+		ref, err := repository.Reference(revision.branch, true)
+		if err != nil {
+			return err
+		}
+
+		hash, head = ref.Hash(), plumbing.NewSymbolicReference(plumbing.HEAD, revision.branch)
+	}
+
+	commit, err := repository.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	idx := &index.Index{Version: 2}
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+
+		indexEntry := &index.Entry{Name: name, Hash: entry.Hash, Mode: entry.Mode}
+		if inSparseCone(name, paths) {
+			if err := checkoutSparseFile(repository, name, entry); err != nil {
+				return err
+			}
+		} else {
+			// the skip-worktree flag requires an index of version 3
+			indexEntry.SkipWorktree = true
+			idx.Version = 3
+		}
+
+		idx.Entries = append(idx.Entries, indexEntry)
+	}
+
+	if err := repository.Storer.SetIndex(idx); err != nil {
+		return err
+	}
+
+	return repository.Storer.SetReference(head)
+}
+
+// checkoutSparseFile writes a file, symbolic link or the directory of a submodule of the tree to the worktree
+func checkoutSparseFile(repository *gogit.Repository, name string, entry object.TreeEntry) error {
+	if err := verifySparseFilePath(name); err != nil {
+		return err
+	}
+
+	target := filepath.Join(flagValues.target, filepath.FromSlash(name))
+	if entry.Mode == filemode.Submodule {
+		return os.MkdirAll(target, 0755)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	blob, err := repository.BlobObject(entry.Hash)
+	if err != nil {
+		return err
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	if entry.Mode == filemode.Symlink {
+		linkTarget, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		return os.Symlink(string(linkTarget), target)
+	}
+
+	mode, err := entry.Mode.ToOSFileMode()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// verifySparseFilePath rejects names of the tree that would be written outside of the worktree or into its
+// Git directory, either directly or through a symbolic link that an earlier entry of the tree created
+func verifySparseFilePath(name string) error {
+	components := strings.Split(name, "/")
+	for _, component := range components {
+		if component == "" || component == "." || component == ".." || strings.EqualFold(component, ".git") {
+			return fmt.Errorf("refusing to check out %q, the path is invalid", name)
+		}
+	}
+
+	dir := flagValues.target
+	for _, component := range components {
+		dir = filepath.Join(dir, component)
+
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to check out %q, the path contains a symbolic link", name)
+		}
+	}
+
+	return nil
+}
+
+// inSparseCone returns true if the file is in one of the directories, or directly in one of their parent directories
+func inSparseCone(name string, paths []string) bool {
+	dir := path.Dir(name)
+	if dir == "." {
+		return true
+	}
+
+	for _, entry := range paths {
+		if strings.HasPrefix(name, entry+"/") || strings.HasPrefix(entry+"/", dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateGoGitSubmodules initializes and checks out the selected submodules, and their nested submodules if
+// recursion is enabled. The credentials are only used for submodules on the same host as the repository.
+func updateGoGitSubmodules(ctx context.Context, worktree *gogit.Worktree, remote *goGitRemote, parent string, paths []string) ([]submodule, error) {
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []submodule
+	for _, sm := range submodules {
+		if len(paths) > 0 && !containsPath(paths, sm.Config().Path) {
+			continue
+		}
+
+		var auth transport.AuthMethod
+		if sameHost(remote.url, sm.Config().URL) {
+			auth = remote.auth
+		}
+
+		log.Printf("Updating submodule %s using go-git\n", path.Join(parent, sm.Config().Path))
+		if err := sm.UpdateContext(ctx, &gogit.SubmoduleUpdateOptions{Init: true, Auth: auth}); err != nil {
+			return nil, goGitError(err, auth != nil)
+		}
+
+		status, err := sm.Status()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, submodule{Path: path.Join(parent, sm.Config().Path), CommitSha: status.Current.String()})
+
+		if !flagValues.recurseSubmodules {
+			continue
+		}
+
+		repository, err := sm.Repository()
+		if err != nil {
+			return nil, err
+		}
+
+		nestedWorktree, err := repository.Worktree()
+		if err != nil {
+			return nil, err
+		}
+
+		nested, err := updateGoGitSubmodules(ctx, nestedWorktree, remote, path.Join(parent, sm.Config().Path), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, nested...)
+	}
+
+	return result, nil
+}
+
+// writeGoGitResults writes the details of the checked out commit into the result files
+func writeGoGitResults(repository *gogit.Repository, head *plumbing.Reference, advRefs *packp.AdvRefs, submodules []submodule) error {
+	commit, err := repository.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+
+	// the tags that point at the commit in the order of git tag --points-at
+	var tags, annotatedTags []string
+	for name, hash := range advRefs.References {
+		ref := plumbing.ReferenceName(name)
+		if !ref.IsTag() {
+			continue
+		}
+
+		if peeled, ok := advRefs.Peeled[name]; ok && peeled == head.Hash() {
+			tags = append(tags, ref.Short())
+			annotatedTags = append(annotatedTags, ref.Short())
+		} else if !ok && hash == head.Hash() {
+			tags = append(tags, ref.Short())
+		}
+	}
+
+	sort.Strings(tags)
+	sort.Strings(annotatedTags)
+
+	// git describe prefers annotated tags, and falls back to the abbreviated commit SHA
+	describe := head.Hash().String()[:7]
+	switch {
+	case len(annotatedTags) > 0:
+		describe = annotatedTags[0]
+
+	case len(tags) > 0:
+		describe = tags[0]
+	}
+
+	var results = []struct{ file, content string }{
+		{flagValues.resultFileCommitSha, head.Hash().String()},
+		{flagValues.resultFileCommitAuthor, commit.Author.Name},
+		{flagValues.resultFileCommitTime, commit.Committer.When.Format("2006-01-02T15:04:05-07:00")},
+		{flagValues.resultFileCommitter, commit.Committer.Name},
+		{flagValues.resultFileCommitMsg, commitSubject(commit.Message)},
+		{flagValues.resultFileTags, strings.Join(tags, ",")},
+		{flagValues.resultFileDescribe, describe},
+		{flagValues.resultFileURL, displayURL},
+	}
+
+	if strings.TrimSpace(flagValues.revision) == "" {
+		results = append(results, struct{ file, content string }{strings.TrimSpace(flagValues.resultFileBranchName), head.Name().Short()})
+	}
+
+	if flagValues.resultFileSubmodules != "" && len(submodules) > 0 {
+		data, err := json.Marshal(submodules)
+		if err != nil {
+			return err
+		}
+
+		results = append(results, struct{ file, content string }{flagValues.resultFileSubmodules, string(data)})
+	}
+
+	for _, result := range results {
+		if result.file == "" {
+			continue
+		}
+
+		if err := os.WriteFile(result.file, []byte(result.content), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitSubject returns the first paragraph of the commit message in a single line, like %s of git log
+func commitSubject(message string) string {
+	paragraph := strings.SplitN(strings.TrimSpace(message), "\n\n", 2)[0]
+
+	var lines []string
+	for _, line := range strings.Split(paragraph, "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	return strings.Join(lines, " ")
+}
+
+// usesLFS returns true if the Git attributes of the repository root select the Git LFS filter
+func usesLFS(target string) bool {
+	data, err := os.ReadFile(filepath.Join(target, ".gitattributes"))
+	return err == nil && strings.Contains(string(data), "filter=lfs")
+}
+
+func containsPath(paths []string, value string) bool {
+	for _, entry := range paths {
+		if strings.Trim(filepath.ToSlash(filepath.Clean(entry)), "/") == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameHost returns true if both URLs point at the same host, relative submodule URLs are on the same host
+func sameHost(repoURL string, submoduleURL string) bool {
+	if strings.HasPrefix(submoduleURL, "../") || strings.HasPrefix(submoduleURL, "./") {
+		return true
+	}
+
+	repoEndpoint, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return false
+	}
+
+	submoduleEndpoint, err := transport.NewEndpoint(submoduleURL)
+	if err != nil {
+		return false
+	}
+
+	return repoEndpoint.Host == submoduleEndpoint.Host
+}
+
+func revisionNotFoundError(revision string, cause error) error {
+	return &ExitError{
+		Code:    128,
+		Message: fmt.Sprintf("fatal: couldn't find remote ref %s", revision),
+		Cause:   cause,
+		Reason:  shpgit.RevisionNotFound,
+	}
+}
+
+// goGitError converts an error of go-git into an exit error, the reason is determined for the
+// transport errors of go-git, the other errors are classified like the output of the Git CLI
+func goGitError(err error, hasCredentials bool) error {
+	var reason = shpgit.Unknown
+	switch {
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		reason = shpgit.RepositoryNotFound
+
+	case errors.Is(err, transport.ErrAuthenticationRequired) && !hasCredentials:
+		reason = shpgit.AuthPrompted
+
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		reason = shpgit.AuthInvalidUserOrPass
+
+	case strings.Contains(err.Error(), "ssh: unable to authenticate"):
+		reason = shpgit.AuthInvalidKey
+	}
+
+	return &ExitError{
+		Code:    128,
+		Message: strings.ReplaceAll(err.Error(), flagValues.url, displayURL),
+		Cause:   err,
+		Reason:  reason,
+	}
+}
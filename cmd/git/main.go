@@ -6,15 +6,23 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	shpgit "github.com/shipwright-io/build/pkg/git"
 	"github.com/spf13/pflag"
 )
@@ -25,10 +33,19 @@ const (
 	typeUndef credentialType = iota
 	typePrivateKey
 	typeUsernamePassword
+	typeGitHubApp
+	typeBearerToken
 )
 
+const fullCommitShaLength = 40
+
 var useNoTagsFlag = false
 var useDepthForSubmodule = false
+var skipLFSSmudge = false
+
+// gitConfigEnv contains Git configuration that is passed to Git using environment
+// variables, so that secrets like tokens do not show up in the logged commands
+var gitConfigEnv []string
 
 var displayURL string
 
@@ -59,13 +76,48 @@ type settings struct {
 	gitURLRewrite          bool
 	resultFileErrorMessage string
 	resultFileErrorReason  string
+	sparseCheckoutPaths    []string
+	skipSubmodules         bool
+	recurseSubmodules      bool
+	submodulePaths         []string
+	skipLFS                bool
+	lfsIncludes            []string
+	resultFileSubmodules   string
+	resultFileCommitTime   string
+	resultFileCommitter    string
+	resultFileCommitMsg    string
+	resultFileTags         string
+	resultFileDescribe     string
+	resultFileURL          string
+	signatureKeysPath      string
+	verifyTagSignature     bool
+	githubAPIURL           string
+	caBundlePath           string
+	backend                string
 }
 
 var flagValues settings
 
+// submodule is the result entry for a checked out submodule
+type submodule struct {
+	Path      string `json:"path"`
+	CommitSha string `json:"commitSha"`
+}
+
+// systemCABundles are the locations of the certificate authority bundle
+// of common Linux distributions, the first one that exists is used
+var systemCABundles = []string{
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/ssl/ca-bundle.pem",
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem",
+	"/etc/ssl/cert.pem",
+}
+
 var (
-	sshGitURLRegEx = regexp.MustCompile(`^(git@|ssh:\/\/).+$`)
-	commitShaRegEx = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	sshGitURLRegEx  = regexp.MustCompile(`^(git@|ssh:\/\/).+$`)
+	commitShaRegEx  = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	sshKeyTypeRegEx = regexp.MustCompile(`^(ssh-|ecdsa-|sk-)`)
 )
 
 func init() {
@@ -76,12 +128,20 @@ func init() {
 	// the flags for `url`, and `target` will always be used, but `revision`
 	// depends on the respective use case.
 	pflag.StringVar(&flagValues.url, "url", "", "The URL of the Git repository")
-	pflag.StringVar(&flagValues.revision, "revision", "", "The revision of the Git repository to be cloned. Optional, defaults to the default branch.")
+	pflag.StringVar(&flagValues.revision, "revision", "", "The revision of the Git repository to be cloned, this is a branch, tag, commit SHA, reference such as refs/pull/123/head, or refspec. Optional, defaults to the default branch.")
 	pflag.StringVar(&flagValues.target, "target", "", "The target directory of the clone operation")
 	pflag.StringVar(&flagValues.resultFileCommitSha, "result-file-commit-sha", "", "A file to write the commit sha to.")
 	pflag.StringVar(&flagValues.resultFileCommitAuthor, "result-file-commit-author", "", "A file to write the commit author to.")
 	pflag.StringVar(&flagValues.resultFileBranchName, "result-file-branch-name", "", "A file to write the branch name to.")
-	pflag.StringVar(&flagValues.secretPath, "secret-path", "", "A directory that contains a secret. Either username and password for basic authentication. Or a SSH private key and optionally a known hosts file. Optional.")
+	pflag.StringVar(&flagValues.resultFileSubmodules, "result-file-submodules", "", "A file to write the paths and commit SHAs of the submodules to.")
+	pflag.StringVar(&flagValues.resultFileCommitTime, "result-file-commit-timestamp", "", "A file to write the commit timestamp to.")
+	pflag.StringVar(&flagValues.resultFileCommitter, "result-file-committer", "", "A file to write the committer to.")
+	pflag.StringVar(&flagValues.resultFileCommitMsg, "result-file-commit-message", "", "A file to write the subject of the commit message to.")
+	pflag.StringVar(&flagValues.resultFileTags, "result-file-tags", "", "A file to write the comma-separated tags that point at the commit to.")
+	pflag.StringVar(&flagValues.resultFileDescribe, "result-file-describe", "", "A file to write the output of git describe to.")
+	pflag.StringVar(&flagValues.resultFileURL, "result-file-url", "", "A file to write the URL of the Git repository without credentials to.")
+	pflag.StringVar(&flagValues.secretPath, "secret-path", "", "A directory that contains a secret. Either username and password for basic authentication. Or a SSH private key and optionally a known hosts file. Or the app ID, installation ID and private key of a GitHub App. Or a bearer token. Optional.")
+	pflag.StringVar(&flagValues.githubAPIURL, "github-api-url", "https://api.github.com", "The base URL of the GitHub API to request installation tokens for a GitHub App from, unless the secret defines it.")
 
 	// Flags with paths for writing error related information
 	pflag.StringVar(&flagValues.resultFileErrorMessage, "result-file-error-message", "", "A file to write the error message to.")
@@ -92,7 +152,31 @@ func init() {
 	// for (in the context of Shipwright build).
 	pflag.UintVar(&flagValues.depth, "depth", 1, "Create a shallow clone based on the given depth")
 
+	// Optional flag to only check out selected directories of the repository, for
+	// example the context directory of a build in a large monorepo, and to only
+	// download the file contents of these directories (partial clone).
+	pflag.StringArrayVar(&flagValues.sparseCheckoutPaths, "sparse-checkout-path", nil, "A directory of the Git repository to check out, can be specified multiple times. Optional, the whole repository is checked out by default.")
+
+	// Optional flags to control which submodules and which Git LFS files are fetched,
+	// both are fetched completely by default.
+	pflag.BoolVar(&flagValues.skipSubmodules, "skip-submodules", false, "Do not fetch the submodules of the Git repository")
+	pflag.BoolVar(&flagValues.recurseSubmodules, "recurse-submodules", true, "Fetch nested submodules")
+	pflag.StringArrayVar(&flagValues.submodulePaths, "submodule-path", nil, "The path of a submodule to fetch, can be specified multiple times. Optional, all submodules are fetched by default.")
+	pflag.BoolVar(&flagValues.skipLFS, "skip-lfs", false, "Do not fetch Git LFS files, the files contain the Git LFS pointers instead")
+	pflag.StringArrayVar(&flagValues.lfsIncludes, "lfs-include", nil, "A pattern of Git LFS files to fetch, can be specified multiple times. Optional, all Git LFS files are fetched by default.")
+
+	// Optional flags to require a valid signature of the fetched commit, or of the
+	// tag that the revision references, from one of the trusted public keys
+	pflag.StringVar(&flagValues.signatureKeysPath, "signature-keys-path", "", "A directory that contains the trusted GPG or SSH public keys. Optional, the signature is only verified if set.")
+	pflag.BoolVar(&flagValues.verifyTagSignature, "verify-tag-signature", false, "Verify the signature of the tag that the revision references instead of the signature of the commit")
+
+	// Optional flag to trust additional certificate authorities, for example
+	// of a Git server inside a corporate network
+	pflag.StringVar(&flagValues.caBundlePath, "ca-bundle-path", "", "A file with PEM-encoded certificates of certificate authorities to trust in addition to the system ones. Optional.")
+
 	// Mostly internal flag
+	pflag.StringVar(&flagValues.backend, "backend", backendGit, "The implementation to clone with, either git to use the Git CLI, or go-git to use a Go library that requires no Git, SSH and Git LFS binaries, but supports neither signature verification nor Git LFS.")
+
 	pflag.BoolVar(&flagValues.skipValidation, "skip-validation", false, "skip pre-requisite validation")
 	pflag.BoolVar(&flagValues.gitURLRewrite, "git-url-rewrite", false, "set Git config to use url-insteadOf setting based on Git repository URL")
 }
@@ -105,7 +189,13 @@ func main() {
 			exitcode = err.Code
 		}
 
-		if writeErr := writeErrorResults(shpgit.NewErrorResultFromMessage(err.Error())); writeErr != nil {
+		errorResult := shpgit.NewErrorResultFromMessage(err.Error())
+		if exitError, ok := err.(*ExitError); ok && exitError.Reason != shpgit.Unknown {
+			// the reason is already known, for example for credential and signature checks
+			errorResult = &shpgit.ErrorResult{Message: exitError.Message, Reason: exitError.Reason}
+		}
+
+		if writeErr := writeErrorResults(errorResult); writeErr != nil {
 			log.Printf("Could not write error results: %s", writeErr.Error())
 		}
 
@@ -116,7 +206,7 @@ func main() {
 
 // Execute performs flag parsing, input validation and the Git clone
 func Execute(ctx context.Context) error {
-	flagValues = settings{depth: 1}
+	flagValues = settings{depth: 1, recurseSubmodules: true, githubAPIURL: "https://api.github.com", backend: backendGit}
 	pflag.Parse()
 
 	if flagValues.help {
@@ -129,13 +219,18 @@ func Execute(ctx context.Context) error {
 		return err
 	}
 
-	// Check if Git CLI supports --no-tags for clone
-	out, _ := git(ctx, "clone", "-h")
-	useNoTagsFlag = strings.Contains(out, "--no-tags")
+	if flagValues.backend == backendGit {
+		// Check if Git CLI supports --no-tags for clone
+		out, _ := git(ctx, "clone", "-h")
+		useNoTagsFlag = strings.Contains(out, "--no-tags")
+
+		// Check if Git CLI support --single-branch and therefore shallow clones using --depth
+		out, _ = git(ctx, "submodule", "-h")
+		useDepthForSubmodule = strings.Contains(out, "single-branch")
+	}
 
-	// Check if Git CLI support --single-branch and therefore shallow clones using --depth
-	out, _ = git(ctx, "submodule", "-h")
-	useDepthForSubmodule = strings.Contains(out, "single-branch")
+	skipLFSSmudge = false
+	gitConfigEnv = nil
 
 	// Create clean version of the URL that should be safe to be displayed in logs
 	displayURL = cleanURL()
@@ -152,6 +247,14 @@ func runGitClone(ctx context.Context) error {
 		return &ExitError{Code: 101, Message: "the 'target' argument must not be empty"}
 	}
 
+	if flagValues.backend != backendGit && flagValues.backend != backendGoGit {
+		return &ExitError{Code: 102, Message: fmt.Sprintf("the 'backend' argument must be either %s or %s", backendGit, backendGoGit)}
+	}
+
+	if flagValues.backend == backendGoGit {
+		return runGoGitClone(ctx)
+	}
+
 	if err := clone(ctx); err != nil {
 		return err
 	}
@@ -178,6 +281,72 @@ func runGitClone(ctx context.Context) error {
 		}
 	}
 
+	// further commit details use the pretty formats of git log
+	for _, result := range []struct{ file, format string }{
+		{flagValues.resultFileCommitTime, "%cI"},
+		{flagValues.resultFileCommitter, "%cn"},
+		{flagValues.resultFileCommitMsg, "%s"},
+	} {
+		if result.file == "" {
+			continue
+		}
+
+		output, err := git(ctx, "-C", flagValues.target, "log", "-1", fmt.Sprintf("--pretty=format:%s", result.format))
+		if err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(result.file, []byte(output), 0644); err != nil {
+			return err
+		}
+	}
+
+	if flagValues.resultFileTags != "" {
+		output, err := git(ctx, "-C", flagValues.target, "tag", "--points-at", "HEAD")
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(flagValues.resultFileTags, []byte(strings.Join(strings.Fields(output), ",")), 0644); err != nil {
+			return err
+		}
+	}
+
+	if flagValues.resultFileDescribe != "" {
+		output, err := git(ctx, "-C", flagValues.target, "describe", "--tags", "--always")
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(flagValues.resultFileDescribe, []byte(output), 0644); err != nil {
+			return err
+		}
+	}
+
+	if flagValues.resultFileURL != "" {
+		if err := os.WriteFile(flagValues.resultFileURL, []byte(displayURL), 0644); err != nil {
+			return err
+		}
+	}
+
+	if flagValues.resultFileSubmodules != "" && !flagValues.skipSubmodules {
+		submodules, err := submodules(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(submodules) > 0 {
+			data, err := json.Marshal(submodules)
+			if err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(flagValues.resultFileSubmodules, data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
 	if strings.TrimSpace(flagValues.revision) == "" && strings.TrimSpace(flagValues.resultFileBranchName) != "" {
 		output, err := git(ctx, "-C", flagValues.target, "rev-parse", "--abbrev-ref", "HEAD")
 		if err != nil {
@@ -193,14 +362,18 @@ func runGitClone(ctx context.Context) error {
 }
 
 func checkEnvironment(ctx context.Context) error {
-	if flagValues.skipValidation {
+	// the go-git backend does not call any command line tools
+	if flagValues.skipValidation || flagValues.backend == backendGoGit {
 		return nil
 	}
 
 	var checks = []struct{ toolName, versionArg string }{
 		{toolName: "ssh", versionArg: "-V"},
 		{toolName: "git", versionArg: "version"},
-		{toolName: "git-lfs", versionArg: "version"},
+	}
+
+	if !flagValues.skipLFS {
+		checks = append(checks, struct{ toolName, versionArg string }{toolName: "git-lfs", versionArg: "version"})
 	}
 
 	for _, check := range checks {
@@ -234,22 +407,35 @@ func clone(ctx context.Context) error {
 		cloneArgs = append(cloneArgs, "--no-tags")
 	}
 
-	var commitSha string
-	switch {
-	case commitShaRegEx.MatchString(flagValues.revision):
-		commitSha = flagValues.revision
-		cloneArgs = append(cloneArgs, "--no-checkout")
+	// commit SHAs, references such as refs/pull/123/head, and refspecs cannot be cloned
+	// using --branch, they are fetched into an empty repository instead
+	fetchRevision := commitShaRegEx.MatchString(flagValues.revision) || isRefspec(flagValues.revision)
 
-	default:
-		cloneArgs = append(cloneArgs, "--single-branch")
+	cloneArgs = append(cloneArgs, "--single-branch")
 
-		if flagValues.revision != "" {
-			cloneArgs = append(cloneArgs, "--branch", flagValues.revision)
-		}
+	if flagValues.revision != "" {
+		cloneArgs = append(cloneArgs, "--branch", flagValues.revision)
+	}
 
-		if flagValues.depth > 0 {
-			cloneArgs = append(cloneArgs, "--depth", fmt.Sprintf("%d", flagValues.depth))
+	if flagValues.depth > 0 {
+		cloneArgs = append(cloneArgs, "--depth", fmt.Sprintf("%d", flagValues.depth))
+	}
+
+	sparseCheckoutPaths := sparseCheckoutPaths()
+	if len(sparseCheckoutPaths) > 0 {
+		cloneArgs = append(cloneArgs, "--filter=blob:none", "--sparse")
+	}
+
+	// Git replaces the system certificate authorities with the ones of
+	// http.sslCAInfo, therefore it gets a file that contains both
+	if flagValues.caBundlePath != "" {
+		caBundle, err := caBundle()
+		if err != nil {
+			return err
 		}
+
+		defer os.Remove(caBundle)
+		gitConfigEnv = append(gitConfigEnv, fmt.Sprintf("GIT_SSL_CAINFO=%s", caBundle))
 	}
 
 	var addtlGitArgs []string
@@ -336,23 +522,35 @@ func clone(ctx context.Context) error {
 				}
 			}
 
-		case typeUsernamePassword:
+		case typeUsernamePassword, typeGitHubApp:
 			repoURL, err := url.Parse(flagValues.url)
 			if err != nil {
 				return err
 			}
 
-			username, err := os.ReadFile(filepath.Join(flagValues.secretPath, "username"))
-			if err != nil {
-				return err
-			}
+			switch credType {
+			case typeUsernamePassword:
+				username, err := os.ReadFile(filepath.Join(flagValues.secretPath, "username"))
+				if err != nil {
+					return err
+				}
 
-			password, err := os.ReadFile(filepath.Join(flagValues.secretPath, "password"))
-			if err != nil {
-				return err
-			}
+				password, err := os.ReadFile(filepath.Join(flagValues.secretPath, "password"))
+				if err != nil {
+					return err
+				}
 
-			repoURL.User = url.UserPassword(string(username), string(password))
+				repoURL.User = url.UserPassword(string(username), string(password))
+
+			case typeGitHubApp:
+				token, err := githubAppInstallationToken(ctx)
+				if err != nil {
+					return err
+				}
+
+				// GitHub accepts installation tokens as password for basic authentication
+				repoURL.User = url.UserPassword("x-access-token", token)
+			}
 
 			credHelperFile, err := os.CreateTemp(os.TempDir(), "cred-helper-file")
 			if err != nil {
@@ -369,30 +567,93 @@ func clone(ctx context.Context) error {
 				"-c",
 				fmt.Sprintf("credential.helper=%s", fmt.Sprintf("store --file %s", credHelperFile.Name())),
 			)
+
+		case typeBearerToken:
+			repoURL, err := url.Parse(flagValues.url)
+			if err != nil {
+				return err
+			}
+
+			token, err := os.ReadFile(filepath.Join(flagValues.secretPath, "token"))
+			if err != nil {
+				return err
+			}
+
+			// The header is only sent to the host of the repository, and it is
+			// passed using environment variables to keep it out of the logs
+			addGitConfigEnv(
+				fmt.Sprintf("http.%s://%s/.extraHeader", repoURL.Scheme, repoURL.Host),
+				fmt.Sprintf("Authorization: Bearer %s", strings.TrimSpace(string(token))),
+			)
 		}
 	}
 
-	cloneArgs = append(cloneArgs, addtlGitArgs...)
-	cloneArgs = append(cloneArgs, "--", flagValues.url, flagValues.target)
-	if _, err := git(ctx, cloneArgs...); err != nil {
-		return err
+	// Git LFS files are either not fetched at all, or only the ones matching
+	// the include patterns are pulled after the checkout
+	skipLFSSmudge = flagValues.skipLFS || len(flagValues.lfsIncludes) > 0
+
+	if fetchRevision {
+		if err := fetch(ctx, addtlGitArgs, sparseCheckoutPaths); err != nil {
+			return err
+		}
+	} else {
+		cloneArgs = append(cloneArgs, addtlGitArgs...)
+		cloneArgs = append(cloneArgs, "--", flagValues.url, flagValues.target)
+		if _, err := git(ctx, cloneArgs...); err != nil {
+			return err
+		}
+
+		// the sparse clone only contains the files in the root directory, the
+		// file contents of the selected directories are downloaded on demand
+		if len(sparseCheckoutPaths) > 0 {
+			sparseCheckoutArgs := []string{"-C", flagValues.target}
+			sparseCheckoutArgs = append(sparseCheckoutArgs, addtlGitArgs...)
+			sparseCheckoutArgs = append(sparseCheckoutArgs, "sparse-checkout", "set", "--cone", "--")
+			sparseCheckoutArgs = append(sparseCheckoutArgs, sparseCheckoutPaths...)
+			if _, err := git(ctx, sparseCheckoutArgs...); err != nil {
+				return err
+			}
+		}
 	}
 
-	if commitSha != "" {
-		if _, err := git(ctx, "-C", flagValues.target, "checkout", commitSha); err != nil {
+	if flagValues.signatureKeysPath != "" {
+		if err := verifySignature(ctx, addtlGitArgs); err != nil {
 			return err
 		}
 	}
 
-	submoduleArgs := []string{"-C", flagValues.target}
-	submoduleArgs = append(submoduleArgs, addtlGitArgs...)
-	submoduleArgs = append(submoduleArgs, "submodule", "update", "--init", "--recursive")
-	if useDepthForSubmodule && flagValues.depth > 0 {
-		submoduleArgs = append(submoduleArgs, "--depth", fmt.Sprintf("%d", flagValues.depth))
+	if !flagValues.skipSubmodules {
+		submoduleArgs := []string{"-C", flagValues.target}
+		submoduleArgs = append(submoduleArgs, addtlGitArgs...)
+		submoduleArgs = append(submoduleArgs, "submodule", "update", "--init")
+		if flagValues.recurseSubmodules {
+			submoduleArgs = append(submoduleArgs, "--recursive")
+		}
+
+		if useDepthForSubmodule && flagValues.depth > 0 {
+			submoduleArgs = append(submoduleArgs, "--depth", fmt.Sprintf("%d", flagValues.depth))
+		}
+
+		if len(flagValues.submodulePaths) > 0 {
+			submoduleArgs = append(submoduleArgs, "--")
+			submoduleArgs = append(submoduleArgs, flagValues.submodulePaths...)
+		}
+
+		if _, err := git(ctx, submoduleArgs...); err != nil {
+			return err
+		}
 	}
 
-	if _, err := git(ctx, submoduleArgs...); err != nil {
-		return err
+	if !flagValues.skipLFS && len(flagValues.lfsIncludes) > 0 {
+		if err := pullLFS(ctx, addtlGitArgs); err != nil {
+			return err
+		}
+	}
+
+	if flagValues.resultFileTags != "" || flagValues.resultFileDescribe != "" {
+		if err := createRemoteTags(ctx, addtlGitArgs); err != nil {
+			return err
+		}
 	}
 
 	revision := flagValues.revision
@@ -415,7 +676,383 @@ func clone(ctx context.Context) error {
 	return nil
 }
 
+// fetch initializes an empty repository in the target directory and fetches only the
+// requested revision, which is a commit SHA, a reference, or a refspec
+func fetch(ctx context.Context, addtlGitArgs []string, sparseCheckoutPaths []string) error {
+	if _, err := git(ctx, "init", "--quiet", flagValues.target); err != nil {
+		return err
+	}
+
+	fetchArgs := []string{"-C", flagValues.target}
+	fetchArgs = append(fetchArgs, addtlGitArgs...)
+	fetchArgs = append(fetchArgs, "fetch", "--quiet", "--no-tags")
+
+	// a partial clone requires a named remote, which Git registers as promisor
+	// remote to download the file contents of the selected directories from
+	repository := flagValues.url
+	if len(sparseCheckoutPaths) > 0 {
+		if _, err := git(ctx, "-C", flagValues.target, "remote", "add", "origin", "--", flagValues.url); err != nil {
+			return err
+		}
+
+		sparseCheckoutArgs := []string{"-C", flagValues.target, "sparse-checkout", "set", "--cone", "--"}
+		sparseCheckoutArgs = append(sparseCheckoutArgs, sparseCheckoutPaths...)
+		if _, err := git(ctx, sparseCheckoutArgs...); err != nil {
+			return err
+		}
+
+		repository = "origin"
+		fetchArgs = append(fetchArgs, "--filter=blob:none")
+	}
+
+	// a full commit SHA can be fetched directly if the server allows it, which most Git
+	// hosting services do, abbreviated commit SHAs need to be looked up in the history
+	if !commitShaRegEx.MatchString(flagValues.revision) || len(flagValues.revision) == fullCommitShaLength {
+		shallowFetchArgs := fetchArgs
+		if flagValues.depth > 0 {
+			shallowFetchArgs = append(shallowFetchArgs, "--depth", fmt.Sprintf("%d", flagValues.depth))
+		}
+
+		shallowFetchArgs = append(shallowFetchArgs, "--", repository, flagValues.revision)
+		_, err := git(ctx, shallowFetchArgs...)
+		switch {
+		case err == nil:
+			_, err = git(ctx, "-C", flagValues.target, "checkout", "--quiet", "FETCH_HEAD")
+			return err
+
+		case !commitShaRegEx.MatchString(flagValues.revision):
+			return err
+		}
+
+		log.Printf("Failed to fetch commit %s directly, fetching all branches instead\n", flagValues.revision)
+	}
+
+	fetchArgs = append(fetchArgs, "--", repository, "+refs/heads/*:refs/remotes/origin/*")
+	if _, err := git(ctx, fetchArgs...); err != nil {
+		return err
+	}
+
+	commitSha, err := git(ctx, "-C", flagValues.target, "rev-parse", "--verify", "--quiet", fmt.Sprintf("%s^{commit}", flagValues.revision))
+	if err != nil {
+		return &ExitError{
+			Code:    128,
+			Message: fmt.Sprintf("fatal: couldn't find remote ref %s", flagValues.revision),
+			Cause:   err,
+		}
+	}
+
+	_, err = git(ctx, "-C", flagValues.target, "checkout", "--quiet", commitSha)
+	return err
+}
+
+// verifySignature verifies that the checked out commit, or the tag that the revision references, has
+// a valid signature from one of the trusted GPG or SSH public keys in the signature keys directory
+func verifySignature(ctx context.Context, addtlGitArgs []string) error {
+	gnupgHome, err := os.MkdirTemp(os.TempDir(), "gnupg")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(gnupgHome)
+
+	allowedSigners, err := trustedKeys(ctx, gnupgHome)
+	if err != nil {
+		return err
+	}
+
+	allowedSignersFile, err := os.CreateTemp(os.TempDir(), "allowed-signers")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(allowedSignersFile.Name())
+
+	if err := os.WriteFile(allowedSignersFile.Name(), []byte(strings.Join(allowedSigners, "\n")+"\n"), 0400); err != nil {
+		return err
+	}
+
+	verifyArgs := []string{"-C", flagValues.target, "-c", fmt.Sprintf("gpg.ssh.allowedSignersFile=%s", allowedSignersFile.Name())}
+	if !flagValues.verifyTagSignature {
+		verifyArgs = append(verifyArgs, "verify-commit", "HEAD")
+	} else {
+		tag, err := fetchTag(ctx, addtlGitArgs)
+		if err != nil {
+			return err
+		}
+
+		verifyArgs = append(verifyArgs, "verify-tag", tag)
+	}
+
+	// GPG signatures are verified against the keys imported into the temporary
+	// keyring, SSH signatures against the keys in the allowed signers file
+	if _, err := gitWithEnv(ctx, []string{fmt.Sprintf("GNUPGHOME=%s", gnupgHome)}, verifyArgs...); err != nil {
+		log.Printf("Failed to verify the signature: %v\n", err)
+		return &ExitError{
+			Code:    130,
+			Message: shpgit.SignatureInvalid.ToMessage(),
+			Cause:   err,
+			Reason:  shpgit.SignatureInvalid,
+		}
+	}
+
+	return nil
+}
+
+// trustedKeys imports the GPG public keys of the signature keys directory into the keyring of the
+// GPG home directory, and returns the SSH public keys as entries of an allowed signers file
+func trustedKeys(ctx context.Context, gnupgHome string) ([]string, error) {
+	entries, err := os.ReadDir(flagValues.signatureKeysPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var gpgKeys int
+	var allowedSigners []string
+	for _, entry := range entries {
+		// mounted Secrets and ConfigMaps contain hidden directories with the actual files
+		filename := filepath.Join(flagValues.signatureKeysPath, entry.Name())
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if info, err := os.Stat(filename); err != nil || info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.Contains(string(data), "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+			if out, err := exec.CommandContext(ctx, "gpg", "--batch", "--quiet", "--homedir", gnupgHome, "--import", filename).CombinedOutput(); err != nil {
+				return nil, &ExitError{Code: 130, Message: fmt.Sprintf("failed to import the GPG public keys of %s: %s", entry.Name(), strings.TrimSpace(string(out))), Cause: err}
+			}
+
+			gpgKeys++
+			continue
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			// keys in authorized keys format are trusted for any principal,
+			// lines in allowed signers format are used as they are
+			if sshKeyTypeRegEx.MatchString(line) {
+				line = "* " + line
+			}
+
+			allowedSigners = append(allowedSigners, line)
+		}
+	}
+
+	if gpgKeys == 0 && len(allowedSigners) == 0 {
+		return nil, &ExitError{
+			Code:    130,
+			Message: fmt.Sprintf("no GPG or SSH public keys found in %s", flagValues.signatureKeysPath),
+			Reason:  shpgit.SignatureInvalid,
+		}
+	}
+
+	return allowedSigners, nil
+}
+
+// fetchTag fetches the annotated tag that the revision references, which the clone does not contain,
+// and returns its reference in case the tag points at the checked out commit
+func fetchTag(ctx context.Context, addtlGitArgs []string) (string, error) {
+	tagName := strings.TrimPrefix(flagValues.revision, "refs/tags/")
+	if tagName == "" || isRefspec(tagName) || commitShaRegEx.MatchString(tagName) {
+		return "", &ExitError{
+			Code:    130,
+			Message: fmt.Sprintf("the revision %q does not reference a tag, which is required to verify the tag signature", flagValues.revision),
+			Reason:  shpgit.SignatureInvalid,
+		}
+	}
+
+	tag := fmt.Sprintf("refs/tags/%s", tagName)
+
+	fetchArgs := []string{"-C", flagValues.target}
+	fetchArgs = append(fetchArgs, addtlGitArgs...)
+	fetchArgs = append(fetchArgs, "fetch", "--quiet", "--no-tags", "--depth", "1", "--", flagValues.url, fmt.Sprintf("+%s:%s", tag, tag))
+	if _, err := git(ctx, fetchArgs...); err != nil {
+		return "", err
+	}
+
+	head, err := git(ctx, "-C", flagValues.target, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	commitSha, err := git(ctx, "-C", flagValues.target, "rev-parse", "--verify", fmt.Sprintf("%s^{commit}", tag))
+	if err != nil {
+		return "", err
+	}
+
+	if commitSha != head {
+		return "", &ExitError{
+			Code:    130,
+			Message: fmt.Sprintf("the tag %s does not point at the checked out commit %s", tagName, head),
+			Reason:  shpgit.SignatureInvalid,
+		}
+	}
+
+	return tag, nil
+}
+
+// createRemoteTags creates local tags for the remote tags that point at the checked out commit. The
+// repository is cloned without tags, but they are needed to determine the tags and describe results.
+func createRemoteTags(ctx context.Context, addtlGitArgs []string) error {
+	head, err := git(ctx, "-C", flagValues.target, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	lsRemoteArgs := append([]string{}, addtlGitArgs...)
+	lsRemoteArgs = append(lsRemoteArgs, "ls-remote", "--tags", "--", flagValues.url)
+	output, err := git(ctx, lsRemoteArgs...)
+	if err != nil {
+		// the tags are informational only and must not fail the build
+		log.Printf("Failed to list the tags of the repository: %v\n", err)
+		return nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		// annotated tags are listed twice, the second entry with the
+		// suffix ^{} contains the commit that the tag points at
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != head {
+			continue
+		}
+
+		ref := strings.TrimSuffix(fields[1], "^{}")
+		if _, err := git(ctx, "-C", flagValues.target, "rev-parse", "--verify", "--quiet", ref); err == nil {
+			continue
+		}
+
+		if _, err := git(ctx, "-C", flagValues.target, "update-ref", ref, head); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pullLFS fetches and checks out the Git LFS files that match the include patterns
+// in the repository and in its submodules
+func pullLFS(ctx context.Context, addtlGitArgs []string) error {
+	skipLFSSmudge = false
+	include := fmt.Sprintf("--include=%s", strings.Join(flagValues.lfsIncludes, ","))
+
+	lfsArgs := []string{"-C", flagValues.target}
+	lfsArgs = append(lfsArgs, addtlGitArgs...)
+	lfsArgs = append(lfsArgs, "lfs", "pull", include)
+	if _, err := git(ctx, lfsArgs...); err != nil {
+		return err
+	}
+
+	if flagValues.skipSubmodules {
+		return nil
+	}
+
+	foreachArgs := []string{"-C", flagValues.target}
+	foreachArgs = append(foreachArgs, addtlGitArgs...)
+	foreachArgs = append(foreachArgs, "submodule", "foreach", "--quiet")
+	if flagValues.recurseSubmodules {
+		foreachArgs = append(foreachArgs, "--recursive")
+	}
+
+	// the command is evaluated by a shell, therefore the patterns need to be quoted
+	foreachArgs = append(foreachArgs, fmt.Sprintf("git lfs pull '%s'", strings.ReplaceAll(include, "'", `'\''`)))
+	_, err := git(ctx, foreachArgs...)
+	return err
+}
+
+// submodules returns the paths and the commit SHAs of the checked out submodules
+func submodules(ctx context.Context) ([]submodule, error) {
+	statusArgs := []string{"-C", flagValues.target, "submodule", "status"}
+	if flagValues.recurseSubmodules {
+		statusArgs = append(statusArgs, "--recursive")
+	}
+
+	output, err := git(ctx, statusArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []submodule
+	for _, line := range strings.Split(output, "\n") {
+		// every line starts with a status character, submodules that are
+		// not initialized are prefixed with a minus sign and skipped
+		if len(line) == 0 || line[0] == '-' {
+			continue
+		}
+
+		fields := strings.Fields(line[1:])
+		if len(fields) < 2 {
+			continue
+		}
+
+		result = append(result, submodule{Path: fields[1], CommitSha: fields[0]})
+	}
+
+	return result, nil
+}
+
+// sparseCheckoutPaths returns the normalized directories to check out, or nil in case
+// the whole repository needs to be checked out
+func sparseCheckoutPaths() []string {
+	var paths []string
+	for _, path := range flagValues.sparseCheckoutPaths {
+		path = strings.Trim(filepath.ToSlash(filepath.Clean("/"+strings.TrimSpace(path))), "/")
+		if path == "" {
+			// the root directory of the repository is requested
+			return nil
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// isRefspec returns true if the revision is a full reference such as refs/pull/123/head, or a refspec
+func isRefspec(revision string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(revision, "+"), "refs/") || strings.Contains(revision, ":")
+}
+
+// addGitConfigEnv adds a Git configuration entry to the environment variables that are passed to Git,
+// at the next index after the entries which are already configured in the environment
+func addGitConfigEnv(key string, value string) {
+	const countVar = "GIT_CONFIG_COUNT="
+
+	count, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+
+	env := make([]string, 0, len(gitConfigEnv)+3)
+	for _, entry := range gitConfigEnv {
+		if strings.HasPrefix(entry, countVar) {
+			count, _ = strconv.Atoi(strings.TrimPrefix(entry, countVar))
+			continue
+		}
+
+		env = append(env, entry)
+	}
+
+	gitConfigEnv = append(env,
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", count, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, value),
+		fmt.Sprintf("%s%d", countVar, count+1),
+	)
+}
+
 func git(ctx context.Context, args ...string) (string, error) {
+	return gitWithEnv(ctx, nil, args...)
+}
+
+// gitWithEnv runs Git with additional environment variables that only apply to this command
+func gitWithEnv(ctx context.Context, env []string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
 
 	// Print the command to be executed, but replace the URL with a safe version
@@ -425,6 +1062,15 @@ func git(ctx context.Context, args ...string) (string, error) {
 	os.Setenv("GIT_TERMINAL_PROMPT", "0")
 	cmd.Stdin = nil
 
+	// Pass the Git configuration that contains secrets
+	cmd.Env = append(os.Environ(), gitConfigEnv...)
+	cmd.Env = append(cmd.Env, env...)
+
+	// Keep the Git LFS pointers instead of downloading the file contents
+	if skipLFSSmudge {
+		cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
+	}
+
 	out, err := cmd.CombinedOutput()
 
 	var output string
@@ -506,13 +1152,198 @@ func checkCredentials() (credentialType, error) {
 		}
 	}
 
+	// Checking whether mounted secret contains the app ID, installation ID and
+	// private key of a GitHub App to request an installation token with
+	hasAppID := hasFile(flagValues.secretPath, "github-app-id")
+	hasInstallationID := hasFile(flagValues.secretPath, "github-app-installation-id")
+	hasAppPrivateKey := hasFile(flagValues.secretPath, "github-app-private-key")
+	switch {
+	case hasAppID && hasInstallationID && hasAppPrivateKey && strings.HasPrefix(flagValues.url, "https://"):
+		return typeGitHubApp, nil
+
+	case hasAppID && hasInstallationID && hasAppPrivateKey && strings.HasPrefix(flagValues.url, "http://"):
+		return typeUndef, &ExitError{
+			Code:    110,
+			Message: shpgit.AuthUnexpectedHTTP.ToMessage(),
+			Reason:  shpgit.AuthUnexpectedHTTP,
+		}
+
+	case hasAppID || hasInstallationID || hasAppPrivateKey:
+		return typeUndef, &ExitError{
+			Code:    110,
+			Message: shpgit.AuthGitHubAppIncomplete.ToMessage(),
+			Reason:  shpgit.AuthGitHubAppIncomplete,
+		}
+	}
+
+	// Checking whether mounted secret contains a token for bearer authentication
+	hasToken := hasFile(flagValues.secretPath, "token")
+	switch {
+	case hasToken && strings.HasPrefix(flagValues.url, "https://"):
+		return typeBearerToken, nil
+
+	case hasToken && strings.HasPrefix(flagValues.url, "http://"):
+		return typeUndef, &ExitError{
+			Code:    110,
+			Message: shpgit.AuthUnexpectedHTTP.ToMessage(),
+			Reason:  shpgit.AuthUnexpectedHTTP,
+		}
+	}
+
 	return typeUndef, &ExitError{
 		Code:    110,
-		Message: "Unsupported type of credentials provided, either SSH private key or username/password is supported",
+		Message: "Unsupported type of credentials provided, either SSH private key, username/password, GitHub App or token is supported",
 		Reason:  shpgit.Unknown,
 	}
 }
 
+// githubAppInstallationToken requests a short-lived installation token for the GitHub App
+// in the secret, authenticating as the app using a JSON Web Token signed with its private key
+func githubAppInstallationToken(ctx context.Context) (string, error) {
+	var values = map[string]string{}
+	for _, key := range []string{"github-app-id", "github-app-installation-id", "github-app-private-key", "github-api-url"} {
+		data, err := os.ReadFile(filepath.Join(flagValues.secretPath, key))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+
+		values[key] = strings.TrimSpace(string(data))
+	}
+
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(values["github-app-private-key"]))
+	if err != nil {
+		return "", githubAppError(fmt.Sprintf("invalid private key: %v", err), err)
+	}
+
+	// GitHub rejects tokens that are issued in the future, therefore
+	// the issue time is backdated to allow for clock drift
+	now := time.Now()
+	appToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer:    values["github-app-id"],
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}).SignedString(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	apiURL := values["github-api-url"]
+	if apiURL == "" {
+		apiURL = flagValues.githubAPIURL
+	}
+
+	// the JSON Web Token authenticates as the app, it must not be sent in plain text
+	if parsedAPIURL, err := url.Parse(apiURL); err != nil || parsedAPIURL.Scheme != "https" {
+		return "", githubAppError(fmt.Sprintf("the GitHub API URL %s must use https", apiURL), err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/app/installations/%s/access_tokens",
+		strings.TrimSuffix(apiURL, "/"),
+		url.PathEscape(values["github-app-installation-id"]),
+	), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", appToken))
+
+	client, err := httpClient()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", githubAppError(err.Error(), err)
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var installationToken struct {
+		Token   string `json:"token"`
+		Message string `json:"message"`
+	}
+
+	_ = json.Unmarshal(body, &installationToken)
+
+	if resp.StatusCode != http.StatusCreated || installationToken.Token == "" {
+		return "", githubAppError(fmt.Sprintf("%s %s", resp.Status, installationToken.Message), nil)
+	}
+
+	log.Printf("Requested an installation token for GitHub App %s\n", values["github-app-id"])
+	return installationToken.Token, nil
+}
+
+// caBundle writes the system certificate authorities and the ones of the
+// CA bundle to a temporary file, and returns the name of the file
+func caBundle() (string, error) {
+	data, err := os.ReadFile(flagValues.caBundlePath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, systemCABundle := range systemCABundles {
+		if systemData, err := os.ReadFile(systemCABundle); err == nil {
+			data = append(append(systemData, '\n'), data...)
+			break
+		}
+	}
+
+	file, err := os.CreateTemp(os.TempDir(), "ca-bundle")
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// httpClient returns a client for requests that the Git step sends itself,
+// which trusts the certificate authorities of the CA bundle
+func httpClient() (*http.Client, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	if flagValues.caBundlePath != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		data, err := os.ReadFile(flagValues.caBundlePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("failed to find a PEM-encoded certificate in the CA bundle %s", flagValues.caBundlePath)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
+func githubAppError(detail string, cause error) error {
+	return &ExitError{
+		Code:    110,
+		Message: fmt.Sprintf("%s (%s)", shpgit.AuthGitHubAppFailed.ToMessage(), strings.TrimSpace(detail)),
+		Cause:   cause,
+		Reason:  shpgit.AuthGitHubAppFailed,
+	}
+}
+
 func writeErrorResults(failure *shpgit.ErrorResult) (err error) {
 	if flagValues.resultFileErrorReason == "" || flagValues.resultFileErrorMessage == "" {
 		return nil
@@ -532,6 +1363,8 @@ func writeErrorResults(failure *shpgit.ErrorResult) (err error) {
 	return os.WriteFile(flagValues.resultFileErrorReason, []byte(failure.Reason.String()), 0666)
 }
 
+// cleanURL returns the URL of the Git repository without any credentials, it is used in the
+// logs and reported as the URL source result
 func cleanURL() string {
 	// non HTTP/HTTPS URLs are returned as-is (i.e. Git+SSH URLs)
 	if !strings.HasPrefix(flagValues.url, "http") {
@@ -541,10 +1374,11 @@ func cleanURL() string {
 	// return redacted version of the URL if it is a parsable URL
 	if repoURL, err := url.Parse(flagValues.url); err == nil {
 		if repoURL.User != nil {
-			log.Println("URL has inline credentials, which need to be redacted for log out. If possible, use an alternative approach.")
+			log.Println("URL has inline credentials, which need to be removed for log out. If possible, use an alternative approach.")
 		}
 
-		return repoURL.Redacted()
+		repoURL.User = nil
+		return repoURL.String()
 	}
 
 	// in any case, as a fallback, return it as-is
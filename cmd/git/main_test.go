@@ -7,15 +7,22 @@ package main_test
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/golang-jwt/jwt/v4"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -111,6 +118,16 @@ var _ = Describe("Git Resource", func() {
 			))).To(HaveOccurred())
 		})
 
+		It("should fail in case --backend is unknown", func() {
+			err := run(withArgs(
+				"--url", "https://github.com/foo/bar",
+				"--target", "/workspace/source",
+				"--backend", "svn",
+			))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*ExitError).Code).To(Equal(102))
+		})
+
 		It("should fail in case url does not exist", func() {
 			withTempDir(func(target string) {
 				Expect(run(withArgs(
@@ -205,6 +222,476 @@ var _ = Describe("Git Resource", func() {
 		})
 	})
 
+	for _, backend := range []string{"git", "go-git"} {
+		backend := backend
+		Context(fmt.Sprintf("fetching references and commits from a local repository using the %s backend", backend), func() {
+			var repo, pullRequestCommit, mainCommit string
+
+			var gitCmd = func(args ...string) string {
+				out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+				Expect(err).ToNot(HaveOccurred(), string(out))
+				return strings.TrimSpace(string(out))
+			}
+
+			BeforeEach(func() {
+				repo = GinkgoT().TempDir()
+
+				gitCmd("init", "--quiet", repo)
+				gitCmd("-C", repo, "commit", "--quiet", "--allow-empty", "--message", "main")
+				mainCommit = gitCmd("-C", repo, "rev-parse", "HEAD")
+
+				// create a commit that is only reachable through a pull request reference
+				gitCmd("-C", repo, "commit", "--quiet", "--allow-empty", "--message", "pull request")
+				pullRequestCommit = gitCmd("-C", repo, "rev-parse", "HEAD")
+				gitCmd("-C", repo, "update-ref", "refs/pull/1/head", pullRequestCommit)
+				gitCmd("-C", repo, "reset", "--quiet", "--hard", mainCommit)
+			})
+
+			var fetchRevision = func(revision string) (string, error) {
+				var commitSha string
+				var err error
+
+				withTempFile("commit-sha", func(filename string) {
+					withTempDir(func(target string) {
+						err = run(withArgs(
+							"--backend", backend,
+							"--url", "file://"+repo,
+							"--target", target,
+							"--revision", revision,
+							"--result-file-commit-sha", filename,
+						))
+
+						commitSha = filecontent(filename)
+					})
+				})
+
+				return commitSha, err
+			}
+
+			It("should fetch a pull request reference", func() {
+				Expect(fetchRevision("refs/pull/1/head")).To(Equal(pullRequestCommit))
+			})
+
+			It("should fetch a refspec", func() {
+				Expect(fetchRevision("+refs/pull/1/head:refs/heads/pr-1")).To(Equal(pullRequestCommit))
+			})
+
+			It("should fetch a commit-sha (long)", func() {
+				Expect(fetchRevision(pullRequestCommit)).To(Equal(pullRequestCommit))
+			})
+
+			It("should fetch a commit-sha (short)", func() {
+				Expect(fetchRevision(mainCommit[:7])).To(Equal(mainCommit))
+			})
+
+			It("should fail with a revision not found error for a non-existing reference", func() {
+				_, err := fetchRevision("refs/pull/2/head")
+				Expect(err).To(HaveOccurred())
+
+				errorResult := shpgit.NewErrorResultFromMessage(err.Error())
+				Expect(errorResult.Reason.String()).To(Equal(shpgit.RevisionNotFound.String()))
+			})
+		})
+	}
+
+	for _, backend := range []string{"git", "go-git"} {
+		backend := backend
+		Context(fmt.Sprintf("store metadata of a local repository in result files using the %s backend", backend), func() {
+			var repo, featureCommit string
+
+			var gitCmd = func(args ...string) string {
+				cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+				cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME=Committer", "GIT_COMMITTER_DATE=2023-02-01T10:00:00+01:00")
+				out, err := cmd.CombinedOutput()
+				Expect(err).ToNot(HaveOccurred(), string(out))
+				return strings.TrimSpace(string(out))
+			}
+
+			BeforeEach(func() {
+				repo = GinkgoT().TempDir()
+
+				gitCmd("init", "--quiet", repo)
+				gitCmd("-C", repo, "commit", "--quiet", "--allow-empty", "--message", "initial")
+				gitCmd("-C", repo, "tag", "v0.9.0")
+				gitCmd("-C", repo, "commit", "--quiet", "--allow-empty", "--message", "Release v1.0.0\n\nWith a body")
+				gitCmd("-C", repo, "tag", "--annotate", "--message", "release", "v1.0.0")
+				gitCmd("-C", repo, "tag", "latest")
+
+				// create a commit without a tag on a separate branch
+				gitCmd("-C", repo, "checkout", "--quiet", "-b", "feature")
+				gitCmd("-C", repo, "commit", "--quiet", "--allow-empty", "--message", "feature")
+				featureCommit = gitCmd("-C", repo, "rev-parse", "HEAD")
+				gitCmd("-C", repo, "checkout", "--quiet", "-")
+			})
+
+			var results = func(args ...string) map[string]string {
+				var result = map[string]string{}
+				var names = []string{"commit-timestamp", "committer", "commit-message", "tags", "describe", "url"}
+
+				withTempDir(func(resultDir string) {
+					withTempDir(func(target string) {
+						args = append(args, "--backend", backend, "--url", "file://"+repo, "--target", target)
+						for _, name := range names {
+							args = append(args, "--result-file-"+name, filepath.Join(resultDir, name))
+						}
+
+						Expect(run(withArgs(args...))).To(Succeed())
+
+						for _, name := range names {
+							result[name] = filecontent(filepath.Join(resultDir, name))
+						}
+					})
+				})
+
+				return result
+			}
+
+			It("should store the commit details, tags and the describe output", func() {
+				result := results()
+
+				Expect(result["commit-timestamp"]).To(Equal("2023-02-01T10:00:00+01:00"))
+				Expect(result["committer"]).To(Equal("Committer"))
+				Expect(result["commit-message"]).To(Equal("Release v1.0.0"))
+				Expect(result["tags"]).To(Equal("latest,v1.0.0"))
+				Expect(result["describe"]).To(Or(Equal("latest"), Equal("v1.0.0")))
+				Expect(result["url"]).To(Equal("file://" + repo))
+			})
+
+			It("should store the abbreviated commit SHA as describe output if no tag points at the commit", func() {
+				result := results("--revision", "feature")
+
+				Expect(result["tags"]).To(BeEmpty())
+				Expect(result["describe"]).ToNot(BeEmpty())
+				Expect(featureCommit).To(HavePrefix(result["describe"]))
+			})
+		})
+	}
+
+	for _, backend := range []string{"git", "go-git"} {
+		backend := backend
+		Context(fmt.Sprintf("sparse checkout of a local repository using the %s backend", backend), func() {
+			var repo, commitSha string
+
+			var writeFile = func(path string, content string) {
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				file(path, 0644, []byte(content))
+			}
+
+			var gitCmd = func(args ...string) string {
+				out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+				Expect(err).ToNot(HaveOccurred(), string(out))
+				return strings.TrimSpace(string(out))
+			}
+
+			BeforeEach(func() {
+				repo = GinkgoT().TempDir()
+
+				gitCmd("init", "--quiet", repo)
+				gitCmd("-C", repo, "config", "uploadpack.allowFilter", "true")
+				gitCmd("-C", repo, "config", "uploadpack.allowAnySHA1InWant", "true")
+
+				writeFile(filepath.Join(repo, "README.md"), "readme")
+				writeFile(filepath.Join(repo, "services", "frontend", "Dockerfile"), "FROM scratch")
+				writeFile(filepath.Join(repo, "services", "backend", "Dockerfile"), "FROM scratch")
+				writeFile(filepath.Join(repo, "libs", "common", "lib.go"), "package common")
+
+				gitCmd("-C", repo, "add", "--all")
+				gitCmd("-C", repo, "commit", "--quiet", "--message", "monorepo")
+				commitSha = gitCmd("-C", repo, "rev-parse", "HEAD")
+			})
+
+			var checkout = func(revision string, paths ...string) (target string) {
+				target = GinkgoT().TempDir()
+
+				args := []string{
+					"--backend", backend,
+					"--url", "file://" + repo,
+					"--target", target,
+					"--revision", revision,
+				}
+
+				for _, path := range paths {
+					args = append(args, "--sparse-checkout-path", path)
+				}
+
+				Expect(run(withArgs(args...))).To(Succeed())
+				return target
+			}
+
+			It("should only check out the selected directories when cloning a branch", func() {
+				target := checkout("", "services/frontend", "libs/common")
+
+				Expect(filepath.Join(target, "README.md")).To(BeAnExistingFile())
+				Expect(filepath.Join(target, "services", "frontend", "Dockerfile")).To(BeAnExistingFile())
+				Expect(filepath.Join(target, "libs", "common", "lib.go")).To(BeAnExistingFile())
+				Expect(filepath.Join(target, "services", "backend")).ToNot(BeAnExistingFile())
+			})
+
+			It("should only check out the selected directories when fetching a commit", func() {
+				target := checkout(commitSha, "./services/frontend/")
+
+				Expect(filepath.Join(target, "services", "frontend", "Dockerfile")).To(BeAnExistingFile())
+				Expect(filepath.Join(target, "services", "backend")).ToNot(BeAnExistingFile())
+				Expect(filepath.Join(target, "libs")).ToNot(BeAnExistingFile())
+			})
+
+			It("should keep the file modes and symbolic links of the selected directories", func() {
+				file(filepath.Join(repo, "services", "frontend", "run.sh"), 0755, []byte("#!/bin/sh"))
+				Expect(os.Symlink("Dockerfile", filepath.Join(repo, "services", "frontend", "Containerfile"))).To(Succeed())
+				gitCmd("-C", repo, "add", "--all")
+				gitCmd("-C", repo, "commit", "--quiet", "--message", "scripts")
+
+				target := checkout("", "services/frontend")
+
+				info, err := os.Stat(filepath.Join(target, "services", "frontend", "run.sh"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Mode().Perm() & 0100).ToNot(BeZero())
+
+				link, err := os.Readlink(filepath.Join(target, "services", "frontend", "Containerfile"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(link).To(Equal("Dockerfile"))
+			})
+
+			It("should create a sparse checkout that the Git CLI can read", func() {
+				target := checkout("", "services/frontend")
+
+				Expect(gitCmd("-C", target, "status", "--porcelain")).To(BeEmpty())
+				Expect(gitCmd("-C", target, "ls-files", "services/backend")).To(Equal("services/backend/Dockerfile"))
+			})
+
+			It("should check out the whole repository if the root directory is selected", func() {
+				target := checkout("", "services/frontend", ".")
+
+				Expect(filepath.Join(target, "services", "backend", "Dockerfile")).To(BeAnExistingFile())
+				Expect(filepath.Join(target, "libs", "common", "lib.go")).To(BeAnExistingFile())
+			})
+
+			Context("with a crafted tree", func() {
+				BeforeEach(func() {
+					if backend != "go-git" {
+						Skip("the Git CLI validates the paths of the tree itself")
+					}
+				})
+
+				// the Git CLI refuses to commit these trees, therefore they are written with the plumbing commands
+				var hashObject = func(content string) string {
+					path := filepath.Join(GinkgoT().TempDir(), "object")
+					file(path, 0644, []byte(content))
+					return gitCmd("-C", repo, "hash-object", "-w", path)
+				}
+
+				var mktree = func(entries ...string) string {
+					cmd := exec.Command("git", "-C", repo, "mktree")
+					cmd.Stdin = strings.NewReader(strings.Join(entries, "\n") + "\n")
+					out, err := cmd.CombinedOutput()
+					Expect(err).ToNot(HaveOccurred(), string(out))
+					return strings.TrimSpace(string(out))
+				}
+
+				var commit = func(tree string) string {
+					return gitCmd("-C", repo, "commit-tree", "-m", "crafted", tree)
+				}
+
+				var sparseCheckout = func(target string, revision string) error {
+					return run(withArgs(
+						"--backend", backend,
+						"--url", "file://"+repo,
+						"--target", target,
+						"--revision", revision,
+						"--sparse-checkout-path", "libs",
+					))
+				}
+
+				It("should refuse to check out a file into a Git directory", func() {
+					hooks := mktree("100755 blob " + hashObject("#!/bin/sh") + "\tpost-checkout")
+					dotGit := mktree("040000 tree " + hooks + "\thooks")
+					libs := mktree("040000 tree " + dotGit + "\t.Git")
+
+					target := GinkgoT().TempDir()
+					Expect(sparseCheckout(target, commit(mktree("040000 tree "+libs+"\tlibs")))).To(MatchError(ContainSubstring("refusing to check out")))
+					Expect(filepath.Join(target, "libs", ".Git", "hooks", "post-checkout")).ToNot(BeAnExistingFile())
+				})
+
+				It("should refuse to check out a file outside of the target directory", func() {
+					grandparent := mktree("100644 blob " + hashObject("evil") + "\tevil")
+					parent := mktree("040000 tree " + grandparent + "\t..")
+					libs := mktree("040000 tree " + parent + "\t..")
+
+					target := filepath.Join(GinkgoT().TempDir(), "target")
+					Expect(sparseCheckout(target, commit(mktree("040000 tree "+libs+"\tlibs")))).To(MatchError(ContainSubstring("refusing to check out")))
+					Expect(filepath.Join(filepath.Dir(target), "evil")).ToNot(BeAnExistingFile())
+				})
+
+				It("should refuse to check out a file beyond a symbolic link", func() {
+					outside := GinkgoT().TempDir()
+					libs := mktree("100644 blob " + hashObject("evil") + "\tevil")
+					tree := mktree(
+						"120000 blob "+hashObject(outside)+"\tlibs",
+						"040000 tree "+libs+"\tlibs",
+					)
+
+					Expect(sparseCheckout(GinkgoT().TempDir(), commit(tree))).To(MatchError(ContainSubstring("refusing to check out")))
+					Expect(filepath.Join(outside, "evil")).ToNot(BeAnExistingFile())
+				})
+			})
+		})
+	}
+
+	Context("cloning a local repository using the go-git backend", func() {
+		var repo string
+
+		var gitCmd = func(args ...string) string {
+			out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		BeforeEach(func() {
+			repo = GinkgoT().TempDir()
+
+			gitCmd("init", "--quiet", repo)
+			file(filepath.Join(repo, ".gitattributes"), 0644, []byte("*.bin filter=lfs diff=lfs merge=lfs -text\n"))
+			gitCmd("-C", repo, "add", "--all")
+			gitCmd("-C", repo, "commit", "--quiet", "--message", "track binaries with Git LFS")
+		})
+
+		It("should fail for a repository that uses Git LFS unless Git LFS is skipped", func() {
+			err := run(withArgs(
+				"--backend", "go-git",
+				"--url", "file://"+repo,
+				"--target", GinkgoT().TempDir(),
+			))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*ExitError).Reason).To(Equal(shpgit.LFSFetchFailed))
+
+			Expect(run(withArgs(
+				"--backend", "go-git",
+				"--url", "file://"+repo,
+				"--target", GinkgoT().TempDir(),
+				"--skip-lfs",
+			))).To(Succeed())
+		})
+
+		It("should fail if a signature verification is requested", func() {
+			err := run(withArgs(
+				"--backend", "go-git",
+				"--url", "file://"+repo,
+				"--target", GinkgoT().TempDir(),
+				"--signature-keys-path", GinkgoT().TempDir(),
+			))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*ExitError).Code).To(Equal(102))
+		})
+
+		It("should create a repository that the Git CLI can read", func() {
+			target := GinkgoT().TempDir()
+			Expect(run(withArgs(
+				"--backend", "go-git",
+				"--url", "file://"+repo,
+				"--target", target,
+				"--skip-lfs",
+			))).To(Succeed())
+
+			Expect(gitCmd("-C", target, "status", "--porcelain")).To(BeEmpty())
+			Expect(gitCmd("-C", target, "rev-parse", "HEAD")).To(Equal(gitCmd("-C", repo, "rev-parse", "HEAD")))
+		})
+
+		It("should fail for an SSH URL if the secret contains no known hosts", func() {
+			secret := GinkgoT().TempDir()
+			out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "test", "-f", filepath.Join(secret, "ssh-privatekey")).CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+
+			err = run(withArgs(
+				"--backend", "go-git",
+				"--url", "ssh://git@localhost/repo.git",
+				"--target", GinkgoT().TempDir(),
+				"--secret-path", secret,
+			))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*ExitError).Code).To(Equal(110))
+			Expect(err.Error()).To(ContainSubstring("known_hosts"))
+		})
+	})
+
+	Context("verifying signatures of a local repository", func() {
+		var repo, keys, trustedKey, untrustedKey string
+
+		var gitCmd = func(args ...string) string {
+			out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "gpg.format=ssh"}, args...)...).CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		var sshKey = func(dir string) string {
+			key := filepath.Join(dir, "id_ed25519")
+			out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "test", "-f", key).CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+			return key
+		}
+
+		BeforeEach(func() {
+			repo = GinkgoT().TempDir()
+			keys = GinkgoT().TempDir()
+			trustedKey = sshKey(GinkgoT().TempDir())
+			untrustedKey = sshKey(GinkgoT().TempDir())
+
+			data, err := os.ReadFile(trustedKey + ".pub")
+			Expect(err).ToNot(HaveOccurred())
+			file(filepath.Join(keys, "trusted.pub"), 0644, data)
+
+			gitCmd("init", "--quiet", "--initial-branch", "main", repo)
+			gitCmd("-C", repo, "-c", "user.signingkey="+trustedKey, "commit", "--quiet", "--allow-empty", "--gpg-sign", "--message", "signed")
+			gitCmd("-C", repo, "-c", "user.signingkey="+trustedKey, "tag", "--sign", "--message", "signed", "v1.0.0")
+			gitCmd("-C", repo, "tag", "--annotate", "--message", "unsigned", "v1.0.0-unsigned")
+			gitCmd("-C", repo, "checkout", "--quiet", "-b", "unsigned")
+			gitCmd("-C", repo, "commit", "--quiet", "--allow-empty", "--message", "unsigned")
+			gitCmd("-C", repo, "checkout", "--quiet", "-b", "untrusted")
+			gitCmd("-C", repo, "-c", "user.signingkey="+untrustedKey, "commit", "--quiet", "--allow-empty", "--gpg-sign", "--message", "untrusted")
+			gitCmd("-C", repo, "checkout", "--quiet", "-")
+		})
+
+		var verify = func(revision string, args ...string) error {
+			return run(withArgs(append([]string{
+				"--url", "file://" + repo,
+				"--target", GinkgoT().TempDir(),
+				"--revision", revision,
+				"--signature-keys-path", keys,
+			}, args...)...))
+		}
+
+		var expectSignatureInvalid = func(err error) {
+			Expect(err).To(HaveOccurred())
+			exitError, ok := err.(*ExitError)
+			Expect(ok).To(BeTrue())
+			Expect(exitError.Reason).To(Equal(shpgit.SignatureInvalid))
+		}
+
+		It("should succeed for a commit signed by a trusted key", func() {
+			Expect(verify("main")).To(Succeed())
+		})
+
+		It("should fail for an unsigned commit", func() {
+			expectSignatureInvalid(verify("unsigned"))
+		})
+
+		It("should fail for a commit signed by an untrusted key", func() {
+			expectSignatureInvalid(verify("untrusted"))
+		})
+
+		It("should succeed for a tag signed by a trusted key", func() {
+			Expect(verify("v1.0.0", "--verify-tag-signature")).To(Succeed())
+		})
+
+		It("should fail for an unsigned tag", func() {
+			expectSignatureInvalid(verify("v1.0.0-unsigned", "--verify-tag-signature"))
+		})
+
+		It("should fail if no trusted keys are provided", func() {
+			Expect(os.Remove(filepath.Join(keys, "trusted.pub"))).To(Succeed())
+			expectSignatureInvalid(verify("main"))
+		})
+	})
+
 	Context("cloning private repositories using SSH keys", func() {
 		const exampleRepo = "git@github.com:shipwright-io/sample-nodejs-private.git"
 
@@ -499,6 +986,398 @@ var _ = Describe("Git Resource", func() {
 						Expect(http.DetectContentType(data)).To(Equal("image/png"))
 					})
 				})
+
+				It("should keep the Git LFS pointers if Git LFS is skipped", func() {
+					withTempDir(func(target string) {
+						Expect(run(withArgs(
+							"--url", exampleRepo,
+							"--target", target,
+							"--skip-lfs",
+						))).ToNot(HaveOccurred())
+
+						Expect(filecontent(filepath.Join(target, "assets", "shipwright-logo-lightbg-512.png"))).To(HavePrefix("version https://git-lfs.github.com/spec/v1"))
+					})
+				})
+
+				It("should only fetch the Git LFS files that match the include patterns", func() {
+					withTempDir(func(target string) {
+						Expect(run(withArgs(
+							"--url", exampleRepo,
+							"--target", target,
+							"--lfs-include", "assets/shipwright-logo-darkbg-512.png",
+						))).ToNot(HaveOccurred())
+
+						Expect(filecontent(filepath.Join(target, "assets", "shipwright-logo-lightbg-512.png"))).To(HavePrefix("version https://git-lfs.github.com/spec/v1"))
+
+						data, err := os.ReadFile(filepath.Join(target, "assets", "shipwright-logo-darkbg-512.png"))
+						Expect(err).ToNot(HaveOccurred())
+						Expect(http.DetectContentType(data)).To(Equal("image/png"))
+					})
+				})
+			})
+
+			for _, backend := range []string{"git", "go-git"} {
+				backend := backend
+				Context(fmt.Sprintf("cloning repositories with local submodules using the %s backend", backend), func() {
+					var repo, frontendCommit, backendCommit, nestedCommit string
+
+					var gitCmd = func(args ...string) string {
+						out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "protocol.file.allow=always"}, args...)...).CombinedOutput()
+						Expect(err).ToNot(HaveOccurred(), string(out))
+						return strings.TrimSpace(string(out))
+					}
+
+					var newRepo = func(name string) (string, string) {
+						path := filepath.Join(repo, name)
+						gitCmd("init", "--quiet", path)
+						gitCmd("-C", path, "commit", "--quiet", "--allow-empty", "--message", name)
+						return path, gitCmd("-C", path, "rev-parse", "HEAD")
+					}
+
+					BeforeEach(func() {
+						repo = GinkgoT().TempDir()
+
+						// submodules with a local file URL are only allowed if explicitly configured
+						os.Setenv("GIT_CONFIG_COUNT", "1")
+						os.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+						os.Setenv("GIT_CONFIG_VALUE_0", "always")
+						DeferCleanup(func() {
+							os.Unsetenv("GIT_CONFIG_COUNT")
+							os.Unsetenv("GIT_CONFIG_KEY_0")
+							os.Unsetenv("GIT_CONFIG_VALUE_0")
+						})
+
+						var nested, frontend, backend string
+						nested, nestedCommit = newRepo("nested")
+						frontend, _ = newRepo("frontend")
+						gitCmd("-C", frontend, "submodule", "--quiet", "add", "file://"+nested, "nested")
+						gitCmd("-C", frontend, "commit", "--quiet", "--message", "add nested")
+						frontendCommit = gitCmd("-C", frontend, "rev-parse", "HEAD")
+						backend, backendCommit = newRepo("backend")
+
+						main, _ := newRepo("main")
+						gitCmd("-C", main, "submodule", "--quiet", "add", "file://"+frontend, "frontend")
+						gitCmd("-C", main, "submodule", "--quiet", "add", "file://"+backend, "backend")
+						gitCmd("-C", main, "commit", "--quiet", "--message", "add submodules")
+					})
+
+					var cloneWithSubmodules = func(args ...string) (string, string) {
+						var target, result string
+
+						withTempFile("submodules", func(filename string) {
+							target = GinkgoT().TempDir()
+
+							Expect(run(withArgs(append([]string{
+								"--backend", backend,
+								"--url", "file://" + filepath.Join(repo, "main"),
+								"--target", target,
+								"--depth", "0",
+								"--result-file-submodules", filename,
+							}, args...)...))).To(Succeed())
+
+							result = filecontent(filename)
+						})
+
+						return target, result
+					}
+
+					It("should fetch all submodules recursively and report their commits", func() {
+						target, result := cloneWithSubmodules()
+
+						Expect(filepath.Join(target, "frontend", "nested", ".git")).To(BeAnExistingFile())
+						Expect(filepath.Join(target, "backend", ".git")).To(BeAnExistingFile())
+						Expect(result).To(MatchJSON(fmt.Sprintf(`[
+							{"path": "backend", "commitSha": %q},
+							{"path": "frontend", "commitSha": %q},
+							{"path": "frontend/nested", "commitSha": %q}
+						]`, backendCommit, frontendCommit, nestedCommit)))
+					})
+
+					It("should not fetch nested submodules if recursion is disabled", func() {
+						target, result := cloneWithSubmodules("--recurse-submodules=false")
+
+						Expect(filepath.Join(target, "frontend", ".git")).To(BeAnExistingFile())
+						Expect(filepath.Join(target, "frontend", "nested", ".git")).ToNot(BeAnExistingFile())
+						Expect(result).To(MatchJSON(fmt.Sprintf(`[
+							{"path": "backend", "commitSha": %q},
+							{"path": "frontend", "commitSha": %q}
+						]`, backendCommit, frontendCommit)))
+					})
+
+					It("should only fetch the selected submodules", func() {
+						target, result := cloneWithSubmodules("--submodule-path", "backend")
+
+						Expect(filepath.Join(target, "backend", ".git")).To(BeAnExistingFile())
+						Expect(filepath.Join(target, "frontend", ".git")).ToNot(BeAnExistingFile())
+						Expect(result).To(MatchJSON(fmt.Sprintf(`[{"path": "backend", "commitSha": %q}]`, backendCommit)))
+					})
+
+					It("should not fetch submodules if they are skipped", func() {
+						target, result := cloneWithSubmodules("--skip-submodules")
+
+						Expect(filepath.Join(target, "frontend", ".git")).ToNot(BeAnExistingFile())
+						Expect(filepath.Join(target, "backend", ".git")).ToNot(BeAnExistingFile())
+						Expect(result).To(BeEmpty())
+					})
+				})
+			}
+
+			Context("cloning repositories using GitHub App and token credentials", func() {
+				const installationToken = "ghs_installationtoken"
+				const bearerToken = "bearertoken"
+
+				var repoURL, apiURL, caFile string
+				var privateKey *rsa.PrivateKey
+
+				var gitCmd = func(args ...string) string {
+					out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+					Expect(err).ToNot(HaveOccurred(), string(out))
+					return strings.TrimSpace(string(out))
+				}
+
+				BeforeEach(func() {
+					var err error
+					privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+					Expect(err).ToNot(HaveOccurred())
+
+					root := GinkgoT().TempDir()
+					gitCmd("init", "--quiet", "--bare", filepath.Join(root, "repo.git"))
+					work := GinkgoT().TempDir()
+					gitCmd("clone", "--quiet", filepath.Join(root, "repo.git"), work)
+					gitCmd("-C", work, "commit", "--quiet", "--allow-empty", "--message", "initial")
+					gitCmd("-C", work, "push", "--quiet", "origin", "HEAD")
+
+					gitPath, err := exec.LookPath("git")
+					Expect(err).ToNot(HaveOccurred())
+
+					// serve the repository using the Git smart HTTP protocol, the installation
+					// token is accepted using basic authentication, the other token as bearer token
+					backend := &cgi.Handler{
+						Path: gitPath,
+						Args: []string{"http-backend"},
+						Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+					}
+
+					gitServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						username, password, ok := r.BasicAuth()
+						if ok && username == "x-access-token" && password == installationToken || r.Header.Get("Authorization") == "Bearer "+bearerToken {
+							backend.ServeHTTP(w, r)
+							return
+						}
+
+						w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+						w.WriteHeader(http.StatusUnauthorized)
+					}))
+					DeferCleanup(gitServer.Close)
+
+					repoURL = gitServer.URL + "/repo.git"
+
+					caFile = filepath.Join(GinkgoT().TempDir(), "ca.crt")
+					file(caFile, 0644, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: gitServer.Certificate().Raw}))
+					os.Setenv("GIT_SSL_CAINFO", caFile)
+					DeferCleanup(func() { os.Unsetenv("GIT_SSL_CAINFO") })
+
+					// the GitHub API only issues an installation token for a JSON Web Token of the app
+					apiServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if r.Method != "POST" || r.URL.Path != "/app/installations/42/access_tokens" {
+							w.WriteHeader(http.StatusNotFound)
+							return
+						}
+
+						var claims jwt.RegisteredClaims
+						_, err := jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &claims, func(*jwt.Token) (interface{}, error) {
+							return &privateKey.PublicKey, nil
+						})
+						if err != nil || claims.Issuer != "1234" {
+							w.WriteHeader(http.StatusUnauthorized)
+							fmt.Fprint(w, `{"message": "A JSON web token could not be decoded"}`)
+							return
+						}
+
+						w.WriteHeader(http.StatusCreated)
+						fmt.Fprintf(w, `{"token": %q, "expires_at": "2016-07-11T22:14:10Z"}`, installationToken)
+					}))
+					DeferCleanup(apiServer.Close)
+
+					apiURL = apiServer.URL
+				})
+
+				var githubAppSecret = func(key *rsa.PrivateKey) string {
+					secret := GinkgoT().TempDir()
+					file(filepath.Join(secret, "github-app-id"), 0644, []byte("1234"))
+					file(filepath.Join(secret, "github-app-installation-id"), 0644, []byte("42"))
+					file(filepath.Join(secret, "github-app-private-key"), 0644, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+					return secret
+				}
+
+				It("should Git clone a repository using an installation token of a GitHub App", func() {
+					Expect(run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", githubAppSecret(privateKey),
+						"--github-api-url", apiURL,
+						"--ca-bundle-path", caFile,
+					))).To(Succeed())
+				})
+
+				It("should use the GitHub API URL of the secret", func() {
+					secret := githubAppSecret(privateKey)
+					file(filepath.Join(secret, "github-api-url"), 0644, []byte(apiURL+"/"))
+
+					Expect(run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", secret,
+						"--ca-bundle-path", caFile,
+					))).To(Succeed())
+				})
+
+				It("should fail if the GitHub App cannot authenticate", func() {
+					otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", githubAppSecret(otherKey),
+						"--github-api-url", apiURL,
+						"--ca-bundle-path", caFile,
+					))
+					Expect(err).To(HaveOccurred())
+					Expect(err.(*ExitError).Reason).To(Equal(shpgit.AuthGitHubAppFailed))
+					Expect(err.Error()).To(ContainSubstring("401 Unauthorized"))
+				})
+
+				It("should refuse to send the token of the GitHub App to an API URL without https", func() {
+					var requested bool
+					plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						requested = true
+						w.WriteHeader(http.StatusNotFound)
+					}))
+					DeferCleanup(plainServer.Close)
+
+					err := run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", githubAppSecret(privateKey),
+						"--github-api-url", plainServer.URL,
+					))
+					Expect(err).To(HaveOccurred())
+					Expect(err.(*ExitError).Reason).To(Equal(shpgit.AuthGitHubAppFailed))
+					Expect(err.Error()).To(ContainSubstring("must use https"))
+					Expect(requested).To(BeFalse())
+				})
+
+				It("should fail if the GitHub App credentials are incomplete", func() {
+					secret := githubAppSecret(privateKey)
+					Expect(os.Remove(filepath.Join(secret, "github-app-installation-id"))).To(Succeed())
+
+					err := run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", secret,
+					))
+					Expect(err).To(HaveOccurred())
+					Expect(err.(*ExitError).Reason).To(Equal(shpgit.AuthGitHubAppIncomplete))
+				})
+
+				It("should Git clone a repository using a bearer token without logging it", func() {
+					secret := GinkgoT().TempDir()
+					file(filepath.Join(secret, "token"), 0644, []byte(bearerToken+"\n"))
+
+					var logOutput bytes.Buffer
+					Expect(run(
+						withLogOutput(&logOutput),
+						withArgs(
+							"--url", repoURL,
+							"--target", GinkgoT().TempDir(),
+							"--secret-path", secret,
+						),
+					)).To(Succeed())
+					Expect(logOutput.String()).ToNot(ContainSubstring(bearerToken))
+				})
+
+				It("should Git clone a repository using a bearer token and keep the Git configuration of the environment", func() {
+					secret := GinkgoT().TempDir()
+					file(filepath.Join(secret, "token"), 0644, []byte(bearerToken+"\n"))
+
+					// the CA bundle is only configured in the environment, the clone fails if the entry is overwritten
+					os.Unsetenv("GIT_SSL_CAINFO")
+					os.Setenv("GIT_CONFIG_COUNT", "1")
+					os.Setenv("GIT_CONFIG_KEY_0", "http.sslCAInfo")
+					os.Setenv("GIT_CONFIG_VALUE_0", caFile)
+					defer func() {
+						os.Setenv("GIT_SSL_CAINFO", caFile)
+						os.Unsetenv("GIT_CONFIG_COUNT")
+						os.Unsetenv("GIT_CONFIG_KEY_0")
+						os.Unsetenv("GIT_CONFIG_VALUE_0")
+					}()
+
+					Expect(run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", secret,
+					))).To(Succeed())
+				})
+
+				It("should Git clone a repository using the go-git backend, the installation token of a GitHub App and the CA bundle", func() {
+					Expect(run(withArgs(
+						"--backend", "go-git",
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", githubAppSecret(privateKey),
+						"--github-api-url", apiURL,
+						"--ca-bundle-path", caFile,
+					))).To(Succeed())
+				})
+
+				It("should Git clone a repository using the go-git backend and a bearer token", func() {
+					secret := GinkgoT().TempDir()
+					file(filepath.Join(secret, "token"), 0644, []byte(bearerToken+"\n"))
+
+					Expect(run(withArgs(
+						"--backend", "go-git",
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", secret,
+						"--ca-bundle-path", caFile,
+					))).To(Succeed())
+				})
+
+				It("should fail with an authentication error using the go-git backend and an invalid bearer token", func() {
+					secret := GinkgoT().TempDir()
+					file(filepath.Join(secret, "token"), 0644, []byte("invalid"))
+
+					err := run(withArgs(
+						"--backend", "go-git",
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", secret,
+						"--ca-bundle-path", caFile,
+					))
+					Expect(err).To(HaveOccurred())
+					Expect(err.(*ExitError).Reason).To(Equal(shpgit.AuthInvalidUserOrPass))
+				})
+
+				It("should Git clone a repository using the certificate authorities of the CA bundle", func() {
+					os.Unsetenv("GIT_SSL_CAINFO")
+
+					secret := GinkgoT().TempDir()
+					file(filepath.Join(secret, "token"), 0644, []byte(bearerToken))
+
+					Expect(run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", secret,
+					))).ToNot(Succeed())
+
+					Expect(run(withArgs(
+						"--url", repoURL,
+						"--target", GinkgoT().TempDir(),
+						"--secret-path", secret,
+						"--ca-bundle-path", caFile,
+					))).To(Succeed())
+				})
 			})
 		})
 
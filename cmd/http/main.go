@@ -0,0 +1,278 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/shipwright-io/build/pkg/bundle"
+	"github.com/shipwright-io/build/pkg/image"
+)
+
+type settings struct {
+	help             bool
+	url              string
+	target           string
+	fileName         string
+	checksum         string
+	extract          bool
+	secretPath       string
+	caBundlePath     string
+	retries          int
+	retryDelay       time.Duration
+	resultFileDigest string
+}
+
+var flagValues settings
+
+func init() {
+	// Explicitly define the help flag so that --help can be invoked and returns status code 0
+	pflag.BoolVar(&flagValues.help, "help", false, "Print the help")
+
+	pflag.StringVar(&flagValues.url, "url", "", "The URL of the file to download (mandatory)")
+	pflag.StringVar(&flagValues.target, "target", "/workspace/source", "The target directory to place the file")
+	pflag.StringVar(&flagValues.fileName, "file-name", "", "The name of the file in the target directory, defaults to the last segment of the URL path")
+	pflag.StringVar(&flagValues.checksum, "checksum", "", "The checksum of the file in the format sha256:<hex> (optional)")
+	pflag.BoolVar(&flagValues.extract, "extract", false, "Extract the file, which must be a tar or gzip compressed tar file, into the target directory")
+	pflag.StringVar(&flagValues.secretPath, "secret-path", "", "A directory that contains either username and password for basic authentication, or a bearer token (optional)")
+	pflag.StringVar(&flagValues.caBundlePath, "ca-bundle-path", "", "A file with PEM-encoded certificates of certificate authorities to trust in addition to the system ones (optional)")
+	pflag.IntVar(&flagValues.retries, "retries", 3, "The number of retries of a download that failed because of a network error or a server error")
+	pflag.DurationVar(&flagValues.retryDelay, "retry-delay", time.Second, "The delay before the first retry, which doubles with every further retry")
+	pflag.StringVar(&flagValues.resultFileDigest, "result-file-digest", "", "A file to write the sha256 digest of the downloaded file")
+}
+
+func main() {
+	if err := Do(context.Background()); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// Do is the main entry point of the http command
+func Do(ctx context.Context) error {
+	flagValues = settings{
+		target:     "/workspace/source",
+		retries:    3,
+		retryDelay: time.Second,
+	}
+	pflag.Parse()
+
+	if flagValues.help {
+		pflag.Usage()
+		return nil
+	}
+
+	if flagValues.url == "" {
+		return fmt.Errorf("mandatory flag --url is not set")
+	}
+
+	fileURL, err := url.Parse(flagValues.url)
+	if err != nil {
+		return err
+	}
+
+	if fileURL.Scheme != "http" && fileURL.Scheme != "https" {
+		return fmt.Errorf("the URL %q is not supported, only the http:// and https:// schemes are supported", flagValues.url)
+	}
+
+	if flagValues.checksum != "" {
+		if err := bundle.ValidateChecksum(flagValues.checksum); err != nil {
+			return err
+		}
+	}
+
+	if flagValues.extract && flagValues.fileName != "" {
+		return fmt.Errorf("the flags --extract and --file-name cannot be used together")
+	}
+
+	fileName, err := targetFileName(fileURL)
+	if err != nil {
+		return err
+	}
+
+	client, err := httpClient()
+	if err != nil {
+		return err
+	}
+
+	header, err := credentials(fileURL)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(flagValues.target, 0755); err != nil {
+		return err
+	}
+
+	downloadOptions := bundle.DownloadOptions{
+		Header:     header,
+		Retries:    flagValues.retries,
+		RetryDelay: flagValues.retryDelay,
+		OnRetry: func(err error, delay time.Duration) {
+			log.Printf("Download failed, retrying in %s: %v", delay, err)
+		},
+	}
+
+	log.Printf("Downloading %q", flagValues.url)
+	var digest string
+	if flagValues.extract {
+		digest, err = bundle.DownloadAndUnpack(ctx, client, flagValues.url, flagValues.checksum, flagValues.target, bundle.DefaultUnpackOptions(), downloadOptions)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("File content was extracted to %s\n", flagValues.target)
+
+	} else {
+		digest, err = download(ctx, client, fileName, downloadOptions)
+		if err != nil {
+			return err
+		}
+	}
+
+	if flagValues.resultFileDigest != "" {
+		return os.WriteFile(flagValues.resultFileDigest, []byte(digest), 0644)
+	}
+
+	return nil
+}
+
+// targetFileName returns the name of the file in the target directory, which
+// must not contain a path
+func targetFileName(fileURL *url.URL) (string, error) {
+	if flagValues.extract {
+		return "", nil
+	}
+
+	fileName := flagValues.fileName
+	if fileName == "" {
+		fileName = path.Base(fileURL.Path)
+		if fileName == "." || fileName == "/" {
+			return "", fmt.Errorf("cannot derive the file name from the URL %q, use --file-name to set it", flagValues.url)
+		}
+	}
+
+	if strings.ContainsAny(fileName, `/\`) || fileName == "." || fileName == ".." {
+		return "", fmt.Errorf("the file name %q must not contain a path", fileName)
+	}
+
+	return fileName, nil
+}
+
+func httpClient() (*http.Client, error) {
+	// downloads of large files can take long, the timeout of the step applies
+	client := &http.Client{}
+
+	if flagValues.caBundlePath != "" {
+		pool, err := image.CertPool(flagValues.caBundlePath)
+		if err != nil {
+			return nil, err
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
+// download stores the file in the target directory, it is spooled to a temporary
+// file in the target directory, so that the target directory only contains the
+// file after the checksum was verified
+func download(ctx context.Context, client *http.Client, fileName string, downloadOptions bundle.DownloadOptions) (string, error) {
+	spool, err := os.CreateTemp(flagValues.target, ".download-*")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	digest, err := bundle.Download(ctx, client, flagValues.url, spool, downloadOptions)
+	if err != nil {
+		return "", err
+	}
+
+	if flagValues.checksum != "" && digest != flagValues.checksum {
+		return "", fmt.Errorf("downloaded file does not match the checksum, expected %s, got %s", flagValues.checksum, digest)
+	}
+
+	if err := spool.Chmod(0644); err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(flagValues.target, fileName)
+	if err := os.Rename(spool.Name(), target); err != nil {
+		return "", err
+	}
+
+	log.Printf("File was stored as %s\n", target)
+	return digest, nil
+}
+
+// credentials returns the header with the credentials of the secret path, either
+// username and password for basic authentication or a token. The credentials are
+// only sent over HTTPS.
+func credentials(fileURL *url.URL) (http.Header, error) {
+	if flagValues.secretPath == "" {
+		return nil, nil
+	}
+
+	readFile := func(key string) (string, bool, error) {
+		data, err := os.ReadFile(filepath.Join(flagValues.secretPath, key))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return "", false, nil
+		case err != nil:
+			return "", false, err
+		}
+
+		return strings.TrimSpace(string(data)), true, nil
+	}
+
+	username, hasUsername, err := readFile("username")
+	if err != nil {
+		return nil, err
+	}
+
+	password, hasPassword, err := readFile("password")
+	if err != nil {
+		return nil, err
+	}
+
+	token, hasToken, err := readFile("token")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !(hasUsername && hasPassword) && !hasToken:
+		return nil, fmt.Errorf("unsupported type of credentials provided, either username and password or token is supported")
+
+	case fileURL.Scheme != "https":
+		return nil, fmt.Errorf("credentials are only sent over HTTPS, the URL %q uses the %s:// scheme", flagValues.url, fileURL.Scheme)
+
+	case hasUsername && hasPassword:
+		basic := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		return http.Header{"Authorization": []string{"Basic " + basic}}, nil
+
+	default:
+		return http.Header{"Authorization": []string{"Bearer " + token}}, nil
+	}
+}
@@ -0,0 +1,368 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shipwright-io/build/cmd/http"
+)
+
+var _ = Describe("HTTP Downloader", func() {
+	const logo = "<svg></svg>"
+
+	var run = func(args ...string) error {
+		// discard log output
+		log.SetOutput(io.Discard)
+
+		// discard stderr output
+		var tmp = os.Stderr
+		os.Stderr = nil
+		defer func() { os.Stderr = tmp }()
+
+		os.Args = append([]string{"tool"}, args...)
+		return Do(context.Background())
+	}
+
+	var withTempDir = func(f func(target string)) {
+		path, err := os.MkdirTemp(os.TempDir(), "http")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(path)
+
+		f(path)
+	}
+
+	filecontent := func(path string) string {
+		data, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	serve := func(handler http.HandlerFunc) string {
+		server := httptest.NewServer(handler)
+		DeferCleanup(server.Close)
+		return server.URL
+	}
+
+	checksum := func(data []byte) string {
+		return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	}
+
+	Context("validations and error cases", func() {
+		It("should succeed in case the help is requested", func() {
+			Expect(run("--help")).To(Succeed())
+		})
+
+		It("should fail in case the URL is not specified", func() {
+			Expect(run()).To(MatchError("mandatory flag --url is not set"))
+		})
+
+		It("should fail in case the URL has an unsupported scheme", func() {
+			Expect(run(
+				"--url", "ftp://example.com/logo.svg",
+			)).To(MatchError(ContainSubstring("only the http:// and https:// schemes are supported")))
+		})
+
+		It("should fail in case the checksum has an invalid format", func() {
+			Expect(run(
+				"--url", "https://example.com/logo.svg",
+				"--checksum", "md5:abc",
+			)).To(MatchError(`checksum "md5:abc" is not in the format sha256:<hex>`))
+		})
+
+		It("should fail in case a file name is specified for a file to extract", func() {
+			Expect(run(
+				"--url", "https://example.com/source.tar.gz",
+				"--extract",
+				"--file-name", "source.tar.gz",
+			)).To(MatchError("the flags --extract and --file-name cannot be used together"))
+		})
+
+		It("should fail in case the file name contains a path", func() {
+			Expect(run(
+				"--url", "https://example.com/logo.svg",
+				"--file-name", "../logo.svg",
+			)).To(MatchError(`the file name "../logo.svg" must not contain a path`))
+		})
+
+		It("should fail in case the file name cannot be derived from the URL", func() {
+			Expect(run(
+				"--url", "https://example.com/",
+			)).To(MatchError(ContainSubstring("use --file-name to set it")))
+		})
+
+		It("should fail in case the secret contains unsupported credentials", func() {
+			withTempDir(func(secretPath string) {
+				Expect(os.WriteFile(filepath.Join(secretPath, "username"), []byte("user"), 0644)).To(Succeed())
+
+				Expect(run(
+					"--url", "https://example.com/logo.svg",
+					"--secret-path", secretPath,
+				)).To(MatchError(ContainSubstring("unsupported type of credentials provided")))
+			})
+		})
+	})
+
+	Context("Downloading a file", func() {
+		var fileURL string
+
+		BeforeEach(func() {
+			fileURL = serve(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(logo))
+			}) + "/icons/logo.svg"
+		})
+
+		It("should store the file with the name of the URL path and write its digest", func() {
+			withTempDir(func(target string) {
+				resultFile := filepath.Join(target, "digest")
+
+				Expect(run(
+					"--url", fileURL,
+					"--target", target,
+					"--result-file-digest", resultFile,
+				)).To(Succeed())
+
+				Expect(filecontent(filepath.Join(target, "logo.svg"))).To(Equal(logo))
+				Expect(filecontent(resultFile)).To(Equal(checksum([]byte(logo))))
+
+				info, err := os.Stat(filepath.Join(target, "logo.svg"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0644)))
+			})
+		})
+
+		It("should store the file with the specified file name in a new target directory", func() {
+			withTempDir(func(root string) {
+				target := filepath.Join(root, "static", "icons")
+
+				Expect(run(
+					"--url", fileURL,
+					"--target", target,
+					"--file-name", "shipwright.svg",
+					"--checksum", checksum([]byte(logo)),
+				)).To(Succeed())
+
+				Expect(filecontent(filepath.Join(target, "shipwright.svg"))).To(Equal(logo))
+			})
+		})
+
+		It("should fail and not store the file if it does not match the checksum", func() {
+			withTempDir(func(target string) {
+				Expect(run(
+					"--url", fileURL,
+					"--target", target,
+					"--checksum", "sha256:"+strings.Repeat("0", 64),
+				)).To(MatchError(ContainSubstring("does not match the checksum")))
+
+				entries, err := os.ReadDir(target)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("Downloading a file to extract", func() {
+		var (
+			tarballURL string
+			data       []byte
+		)
+
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			Expect(tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 5, Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte("hello"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tw.Close()).To(Succeed())
+			Expect(gw.Close()).To(Succeed())
+
+			data = buf.Bytes()
+			tarballURL = serve(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(data)
+			}) + "/source.tar.gz"
+		})
+
+		It("should extract the gzip compressed tar file into the target directory", func() {
+			withTempDir(func(target string) {
+				Expect(run(
+					"--url", tarballURL,
+					"--target", target,
+					"--checksum", checksum(data),
+					"--extract",
+				)).To(Succeed())
+
+				Expect(filecontent(filepath.Join(target, "README.md"))).To(Equal("hello"))
+				Expect(filepath.Join(target, "source.tar.gz")).ToNot(BeAnExistingFile())
+			})
+		})
+	})
+
+	Context("Retrying a download", func() {
+		It("should retry the download after server errors", func() {
+			var requests int32
+			fileURL := serve(func(w http.ResponseWriter, _ *http.Request) {
+				if atomic.AddInt32(&requests, 1) < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					_, _ = w.Write([]byte("unavailable"))
+					return
+				}
+
+				_, _ = w.Write([]byte(logo))
+			}) + "/logo.svg"
+
+			withTempDir(func(target string) {
+				Expect(run(
+					"--url", fileURL,
+					"--target", target,
+					"--retry-delay", "1ms",
+				)).To(Succeed())
+
+				Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+				Expect(filecontent(filepath.Join(target, "logo.svg"))).To(Equal(logo))
+			})
+		})
+
+		It("should fail after the number of retries is exceeded", func() {
+			var requests int32
+			fileURL := serve(func(w http.ResponseWriter, _ *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(http.StatusBadGateway)
+			}) + "/logo.svg"
+
+			withTempDir(func(target string) {
+				Expect(run(
+					"--url", fileURL,
+					"--target", target,
+					"--retries", "2",
+					"--retry-delay", "1ms",
+				)).To(MatchError(ContainSubstring("HTTP status code 502")))
+
+				Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+			})
+		})
+
+		It("should not retry the download after client errors", func() {
+			var requests int32
+			fileURL := serve(func(w http.ResponseWriter, _ *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(http.StatusNotFound)
+			}) + "/logo.svg"
+
+			withTempDir(func(target string) {
+				Expect(run(
+					"--url", fileURL,
+					"--target", target,
+					"--retry-delay", "1ms",
+				)).To(MatchError(ContainSubstring("HTTP status code 404")))
+
+				Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+			})
+		})
+	})
+
+	Context("Downloading a file with credentials", func() {
+		var (
+			fileURL      string
+			caBundlePath string
+		)
+
+		BeforeEach(func() {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				username, password, ok := r.BasicAuth()
+				if (ok && username == "user" && password == "secret") || r.Header.Get("Authorization") == "Bearer token" {
+					_, _ = w.Write([]byte(logo))
+					return
+				}
+
+				w.WriteHeader(http.StatusUnauthorized)
+			}))
+			DeferCleanup(server.Close)
+
+			fileURL = server.URL + "/logo.svg"
+
+			caBundlePath = filepath.Join(GinkgoT().TempDir(), "ca-bundle.crt")
+			Expect(os.WriteFile(caBundlePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)).To(Succeed())
+		})
+
+		It("should authenticate with username and password", func() {
+			withTempDir(func(secretPath string) {
+				Expect(os.WriteFile(filepath.Join(secretPath, "username"), []byte("user"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(secretPath, "password"), []byte("secret\n"), 0644)).To(Succeed())
+
+				withTempDir(func(target string) {
+					Expect(run(
+						"--url", fileURL,
+						"--target", target,
+						"--secret-path", secretPath,
+						"--ca-bundle-path", caBundlePath,
+					)).To(Succeed())
+
+					Expect(filecontent(filepath.Join(target, "logo.svg"))).To(Equal(logo))
+				})
+			})
+		})
+
+		It("should authenticate with a bearer token", func() {
+			withTempDir(func(secretPath string) {
+				Expect(os.WriteFile(filepath.Join(secretPath, "token"), []byte("token"), 0644)).To(Succeed())
+
+				withTempDir(func(target string) {
+					Expect(run(
+						"--url", fileURL,
+						"--target", target,
+						"--secret-path", secretPath,
+						"--ca-bundle-path", caBundlePath,
+					)).To(Succeed())
+
+					Expect(filecontent(filepath.Join(target, "logo.svg"))).To(Equal(logo))
+				})
+			})
+		})
+
+		It("should fail without credentials", func() {
+			withTempDir(func(target string) {
+				Expect(run(
+					"--url", fileURL,
+					"--target", target,
+					"--ca-bundle-path", caBundlePath,
+				)).To(MatchError(ContainSubstring("HTTP status code 401")))
+			})
+		})
+
+		It("should refuse to send the credentials over plain HTTP", func() {
+			withTempDir(func(secretPath string) {
+				Expect(os.WriteFile(filepath.Join(secretPath, "token"), []byte("token"), 0644)).To(Succeed())
+
+				withTempDir(func(target string) {
+					Expect(run(
+						"--url", strings.Replace(fileURL, "https://", "http://", 1),
+						"--target", target,
+						"--secret-path", secretPath,
+					)).To(MatchError(ContainSubstring("credentials are only sent over HTTPS")))
+
+					Expect(filepath.Join(target, "logo.svg")).ToNot(BeAnExistingFile())
+				})
+			})
+		})
+	})
+})
@@ -15,8 +15,10 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	containerreg "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/shipwright-io/build/pkg/image"
 	"github.com/spf13/pflag"
 )
@@ -37,12 +39,15 @@ type settings struct {
 	help bool
 	push string
 	annotation,
-	label *[]string
+	label,
+	sourceResult *[]string
 	insecure bool
 	image,
 	resultFileImageDigest,
 	resultFileImageSize,
-	secretPath string
+	secretPath,
+	caBundlePath string
+	tokenCredentials image.TokenCredentials
 }
 
 func getAnnotation() []string {
@@ -76,11 +81,18 @@ func initializeFlag() {
 	pflag.StringVar(&flagValues.image, "image", "", "The name of image in container registry")
 	pflag.StringVar(&flagValues.secretPath, "secret-path", "", "A directory that contains access credentials (optional)")
 	pflag.BoolVar(&flagValues.insecure, "insecure", false, "Flag indicating the the container registry is insecure")
+	pflag.StringVar(&flagValues.caBundlePath, "ca-bundle-path", "", "A file with PEM-encoded certificates of certificate authorities to trust in addition to the system ones (optional)")
+
+	pflag.StringVar(&flagValues.tokenCredentials.TokenFile, "token-file", "", "A file that contains a token to authenticate with the registry, for example a projected ServiceAccount token (optional)")
+	pflag.StringVar(&flagValues.tokenCredentials.ExchangeEndpoint, "token-exchange-endpoint", "", "An OAuth 2.0 token exchange endpoint to exchange the token for a registry token (optional)")
+	pflag.StringVar(&flagValues.tokenCredentials.Audience, "token-exchange-audience", "", "The audience to request from the token exchange endpoint (optional)")
+	pflag.StringVar(&flagValues.tokenCredentials.Username, "token-username", "", "The username to use with the token as password, the token is used as bearer token if not set (optional)")
 
 	pflag.StringVar(&flagValues.push, "push", "", "Push the image contained in this directory")
 
 	flagValues.annotation = pflag.StringArray("annotation", nil, "New annotations to add")
 	flagValues.label = pflag.StringArray("label", nil, "New labels to add")
+	flagValues.sourceResult = pflag.StringArray("source-result", nil, "A result of the source step in the form name=file, references such as $(source.name) in annotations and labels are replaced with the content of the file")
 	pflag.StringVar(&flagValues.resultFileImageDigest, "result-file-image-digest", "", "A file to write the image digest to")
 	pflag.StringVar(&flagValues.resultFileImageSize, "result-file-image-size", "", "A file to write the image size to")
 }
@@ -134,12 +146,37 @@ func runImageProcessing(ctx context.Context) error {
 		return err
 	}
 
+	// replace references to results of the source step
+	if flagValues.sourceResult != nil && len(*flagValues.sourceResult) > 0 {
+		sourceResults, err := splitKeyVals(*flagValues.sourceResult)
+		if err != nil {
+			return err
+		}
+
+		if err := resolveSourceResults(sourceResults, annotations, labels); err != nil {
+			return err
+		}
+	}
+
 	// prepare the registry options
-	options, _, err := image.GetOptions(ctx, imageName, flagValues.insecure, flagValues.secretPath, "Shipwright Build")
+	options, _, err := image.GetOptions(ctx, imageName, flagValues.insecure, flagValues.secretPath, flagValues.caBundlePath, "Shipwright Build")
 	if err != nil {
 		return err
 	}
 
+	if flagValues.tokenCredentials.IsConfigured() {
+		if flagValues.secretPath != "" {
+			return &ExitError{Code: 100, Message: "the 'secret-path' and 'token-file' arguments cannot be used together"}
+		}
+
+		auth, err := flagValues.tokenCredentials.AuthConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		options = append(options, remote.WithAuth(authn.FromConfig(*auth)))
+	}
+
 	// load the image or image index (usually multi-platform image)
 	var img containerreg.Image
 	var imageIndex containerreg.ImageIndex
@@ -196,6 +233,26 @@ func runImageProcessing(ctx context.Context) error {
 	return nil
 }
 
+// resolveSourceResults replaces the references to results of the source step in the values
+// with the content of the result files, a result file that does not exist is an empty result
+func resolveSourceResults(resultFiles map[string]string, values ...map[string]string) error {
+	for name, file := range resultFiles {
+		data, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read the result %q of the source step: %w", name, err)
+		}
+
+		reference := fmt.Sprintf("$(source.%s)", name)
+		for _, valueMap := range values {
+			for key, value := range valueMap {
+				valueMap[key] = strings.ReplaceAll(value, reference, strings.TrimSpace(string(data)))
+			}
+		}
+	}
+
+	return nil
+}
+
 // splitKeyVals splits key value pairs which is in form hello=world
 func splitKeyVals(kvPairs []string) (map[string]string, error) {
 	m := map[string]string{}
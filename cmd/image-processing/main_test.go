@@ -326,6 +326,37 @@ var _ = Describe("Image Processing Resource", func() {
 			Expect(getImageAnnotation(tag.String(), "org.opencontainers.image.url")).
 				To(Equal("https://my-company.com/images"))
 		})
+
+		It("should mutate an image with a label and an annotation that reference source results", func() {
+			tag := pushImage("test-source-results")
+
+			withTempFile("commit-sha", func(commitShaFile string) {
+				Expect(os.WriteFile(commitShaFile, []byte("0e0583421a5e4bf562ffe33f3651e16ba0c78591"), 0644)).To(Succeed())
+
+				withDockerConfigJSON(func(dockerConfigJSONPath string) {
+					Expect(run(
+						"--image",
+						tag.String(),
+						"--label",
+						"revision=$(source.commit-sha)",
+						"--annotation",
+						"org.opencontainers.image.source=https://github.com/org/repo$(source.url)",
+						"--source-result",
+						"commit-sha="+commitShaFile,
+						"--source-result",
+						"url=/tmp/does-not-exist",
+						"--secret-path",
+						dockerConfigJSONPath,
+					)).ToNot(HaveOccurred())
+				})
+			})
+
+			Expect(getImageConfigLabel(tag.String(), "revision")).
+				To(Equal("0e0583421a5e4bf562ffe33f3651e16ba0c78591"))
+
+			Expect(getImageAnnotation(tag.String(), "org.opencontainers.image.source")).
+				To(Equal("https://github.com/org/repo"))
+		})
 	})
 
 	Context("store result after image mutation", func() {
@@ -83,6 +83,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	for _, warning := range buildconfig.DeprecationWarnings() {
+		ctxlog.Info(ctx, warning)
+	}
+
 	mgr, err := controller.NewManager(ctx, buildCfg, cfg, manager.Options{
 		LeaderElection:          true,
 		LeaderElectionID:        "shipwright-build-controller-lock",
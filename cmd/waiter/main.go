@@ -4,8 +4,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -13,8 +17,16 @@ import (
 
 // settings composed by command-line flag values.
 type settings struct {
-	lockFile string        // path to lock file
-	timeout  time.Duration // how long wait for 'done'
+	lockFile        string        // path to lock file
+	timeout         time.Duration // how long wait for 'done'
+	uploadPort      int           // port of the upload endpoint, zero disables it
+	uploadTokenFile string        // path to the file with the upload token
+	uploadTarget    string        // directory to unpack the uploaded source code
+	uploadMaxSize   int64         // maximum total size of the uploaded files
+
+	resultFileState         string // path to the result file of the state
+	resultFileTimeLeft      string // path to the result file of the time left until timeout
+	resultFileReceivedBytes string // path to the result file of the bytes received by the upload
 }
 
 const longDesc = `
@@ -38,10 +50,24 @@ Or, alternatively:
 
 	$ rm -f <lock-file>
 
+Or upload the source code as tar stream, which ends the waiting after it was unpacked,
+when the upload endpoint is enabled with --upload-port:
+
+	$ waiter start --upload-port=8080 --upload-token-file=<token-file> --upload-target=<dir>
+	$ curl -X PUT -H "Authorization: Bearer <token>" --data-binary @source.tar.gz http://<host>:8080/upload
+
+## Progress
+
+The state of the waiter (Waiting, Receiving, Done, TimedOut or Canceled), the time left
+until timeout and the bytes received by the upload endpoint are written every second to
+the files informed with --result-file-state, --result-file-time-left and
+--result-file-received-bytes.
+
 ## Return-Code
 
 In the case of timeout, the waiter will return error, it only exits gracefully via
-"waiter done", or the removal of the lock-file (before timeout).
+"waiter done", or the removal of the lock-file (before timeout). When the waiting is
+canceled by SIGTERM or SIGINT, the waiter ends promptly with exit code 3.
 `
 
 var (
@@ -53,6 +79,9 @@ var (
 // defaultTimeout default timeout duration.
 var defaultTimeout = 60 * time.Second
 
+// exitCodeCanceled is the exit code when the waiting was canceled.
+const exitCodeCanceled = 3
+
 // defaultLockFile default location of the lock-file.
 var defaultLockFile = "/tmp/waiter.lock"
 
@@ -66,6 +95,17 @@ func init() {
 	flags.StringVar(&flagValues.lockFile, "lock-file", defaultLockFile, "lock file full path")
 	flags.DurationVar(&flagValues.timeout, "timeout", defaultTimeout, "how long to wait until 'done'")
 
+	startFlags := startCmd.Flags()
+
+	startFlags.IntVar(&flagValues.uploadPort, "upload-port", 0, "port of the endpoint that receives the source code upload, disabled if zero")
+	startFlags.StringVar(&flagValues.uploadTokenFile, "upload-token-file", "", "file with the bearer token that authenticates the upload")
+	startFlags.StringVar(&flagValues.uploadTarget, "upload-target", "/workspace/source", "directory to unpack the uploaded source code")
+	startFlags.Int64Var(&flagValues.uploadMaxSize, "upload-max-size", 0, "maximum total size of the uploaded files in bytes, zero means no limit")
+
+	startFlags.StringVar(&flagValues.resultFileState, "result-file-state", "", "a file to write the state of the waiter to")
+	startFlags.StringVar(&flagValues.resultFileTimeLeft, "result-file-time-left", "", "a file to write the time left until timeout to")
+	startFlags.StringVar(&flagValues.resultFileReceivedBytes, "result-file-received-bytes", "", "a file to write the bytes received by the upload endpoint to")
+
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(doneCmd)
 }
@@ -83,9 +123,9 @@ func newStartCmd() *cobra.Command {
 		Use:          "start",
 		Short:        "Starts the wait, and holds until `done` is issued.",
 		SilenceUsage: true,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			w := NewWaiter(flagValues)
-			return w.Wait()
+			return w.Wait(cmd.Context())
 		},
 	}
 }
@@ -105,7 +145,17 @@ func newDoneCmd() *cobra.Command {
 
 // main waiter's entrypoint.
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// a canceled TaskRun terminates the POD, the waiting ends then
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if errors.Is(err, ErrCanceled) {
+		log.Printf("[CANCELLED] %v\n", err)
+		os.Exit(exitCodeCanceled)
+	}
+	if err != nil {
 		log.Fatalf("[ERROR] %v\n", err)
 	}
 	os.Exit(0)
@@ -126,6 +126,21 @@ var _ = Describe("Waiter", func() {
 			Eventually(startCh, defaultTimeout).Should(BeClosed())
 		})
 	})
+
+	Describe("expect to end with a distinct exit code when canceled", func() {
+		var startCh = make(chan interface{})
+
+		BeforeEach(func() {
+			session := run("start")
+			session.Terminate()
+
+			go inspectSession(session, startCh, gexec.Exit(exitCodeCanceled))
+		})
+
+		It("stops when the termination signal is received", func() {
+			Eventually(startCh, defaultTimeout).Should(BeClosed())
+		})
+	})
 })
 
 var _ = AfterSuite(func() {
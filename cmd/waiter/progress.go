@@ -0,0 +1,123 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// States of the waiter, reported in the state result file.
+const (
+	stateWaiting   = "Waiting"
+	stateReceiving = "Receiving"
+	stateDone      = "Done"
+	stateTimedOut  = "TimedOut"
+	stateCanceled  = "Canceled"
+)
+
+// progress keeps track of the state of the waiter, the time left until the timeout and the bytes
+// received by the upload endpoint, and writes them to the result files.
+type progress struct {
+	flagValues *settings // command-line flags
+	deadline   time.Time // when the waiting times out
+
+	mu            sync.Mutex
+	state         string
+	receivedBytes int64
+}
+
+// newProgress instantiates the progress in the waiting state.
+func newProgress(flagValues *settings) *progress {
+	return &progress{
+		flagValues: flagValues,
+		deadline:   time.Now().Add(flagValues.timeout),
+		state:      stateWaiting,
+	}
+}
+
+// setState changes the state and writes the result files.
+func (p *progress) setState(state string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = state
+	return p.write()
+}
+
+// startUpload resets the received bytes of a previous, failed, upload.
+func (p *progress) startUpload() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = stateReceiving
+	p.receivedBytes = 0
+}
+
+// receive adds the informed amount of bytes to the received bytes.
+func (p *progress) receive(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.receivedBytes += int64(n)
+}
+
+// report writes the result files with the current state, the time left changes in between.
+func (p *progress) report() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.write()
+}
+
+// timeLeft returns the time left until the timeout, rounded to seconds.
+func (p *progress) timeLeft() time.Duration {
+	left := time.Until(p.deadline).Round(time.Second)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// write writes the result files which are configured, the caller must hold the lock.
+func (p *progress) write() error {
+	for file, value := range map[string]string{
+		p.flagValues.resultFileState:         p.state,
+		p.flagValues.resultFileTimeLeft:      p.timeLeft().String(),
+		p.flagValues.resultFileReceivedBytes: strconv.FormatInt(p.receivedBytes, 10),
+	} {
+		if file == "" {
+			continue
+		}
+
+		if err := os.WriteFile(file, []byte(value), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// progressReader counts the bytes read from the upload, and stops reading when the waiting
+// got canceled.
+type progressReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	progress *progress
+}
+
+// Read reads from the underlying reader and adds the bytes read to the progress.
+func (r *progressReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := r.reader.Read(p)
+	r.progress.receive(n)
+	return n, err
+}
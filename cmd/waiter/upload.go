@@ -0,0 +1,134 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/shipwright-io/build/pkg/bundle"
+)
+
+// uploadPath is the path of the endpoint that receives the source code.
+const uploadPath = "/upload"
+
+// uploadTokenHeader is the header that carries the token when the request is sent through a proxy,
+// like the one of the Kubernetes API server, that consumes the Authorization header.
+const uploadTokenHeader = "X-Shipwright-Upload-Token"
+
+// uploadHandler receives the source code as tar, or gzip compressed tar, stream and unpacks it
+// into the target directory. The request must carry the token as bearer token, or in the upload
+// token header. After the source code was unpacked, the release function is called to end the
+// waiting. The received bytes are reported to the progress.
+type uploadHandler struct {
+	token    string
+	target   string
+	options  bundle.UnpackOptions
+	release  func() error
+	progress *progress
+
+	mu   sync.Mutex
+	done bool
+}
+
+// ServeHTTP handles the upload requests, only one upload is processed at a time.
+func (u *uploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "PUT, POST")
+		http.Error(w, "only PUT and POST are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !u.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "invalid upload token", http.StatusUnauthorized)
+		return
+	}
+
+	if !u.mu.TryLock() {
+		http.Error(w, "another upload is in progress", http.StatusConflict)
+		return
+	}
+	defer u.mu.Unlock()
+
+	if u.done {
+		http.Error(w, "the source code was already uploaded", http.StatusConflict)
+		return
+	}
+
+	log.Printf("Receiving source code upload from %s\n", r.RemoteAddr)
+	u.progress.startUpload()
+
+	body := &progressReader{ctx: r.Context(), reader: r.Body, progress: u.progress}
+	if err := bundle.UnpackArchive(body, u.target, u.options); err != nil {
+		log.Printf("Failed to unpack the uploaded source code: %v\n", err)
+		if err := u.clean(); err != nil {
+			log.Printf("Failed to remove the partially unpacked source code: %v\n", err)
+		}
+		if err := u.progress.setState(stateWaiting); err != nil {
+			log.Printf("Failed to report the state: %v\n", err)
+		}
+		http.Error(w, fmt.Sprintf("failed to unpack the source code: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	u.done = true
+	if err := u.release(); err != nil {
+		http.Error(w, fmt.Sprintf("failed to release the waiter: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Source code was unpacked to %s\n", u.target)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// authorized compares the token of the request with the upload token in constant time.
+func (u *uploadHandler) authorized(r *http.Request) bool {
+	token := r.Header.Get(uploadTokenHeader)
+	if token == "" {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Bearer ") {
+			return false
+		}
+
+		token = strings.TrimPrefix(authorization, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(u.token)) == 1
+}
+
+// clean removes what a failed upload unpacked into the target directory, so that a repeated
+// upload starts from an empty directory. The directory itself is kept, it can be a volume mount.
+func (u *uploadHandler) clean() error {
+	entries, err := os.ReadDir(u.target)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(u.target, entry.Name())
+
+		// directories of the upload can be read-only, their content can only be removed
+		// once they are writable
+		_ = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				_ = os.Chmod(path, 0700)
+			}
+			return nil
+		})
+
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
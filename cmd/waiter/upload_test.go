@@ -0,0 +1,252 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/shipwright-io/build/pkg/bundle"
+)
+
+var _ = Describe("Upload", func() {
+	const token = "upload-token"
+
+	// tarball returns a gzip compressed tar stream with the informed files and their content.
+	var tarball = func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		for name, content := range files {
+			Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte(content))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+		Expect(gw.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	// readFile returns the content of the informed file.
+	var readFile = func(name string) string {
+		data, err := os.ReadFile(name)
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	// upload sends the informed body with the informed token and returns the status code.
+	var upload = func(url string, token string, body []byte) int {
+		req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	Describe("handler", func() {
+		var (
+			target   string
+			released int
+			reported *progress
+			server   *httptest.Server
+		)
+
+		BeforeEach(func() {
+			target = GinkgoT().TempDir()
+			released = 0
+			reported = newProgress(&settings{timeout: time.Minute})
+
+			server = httptest.NewServer(&uploadHandler{
+				token:   token,
+				target:  target,
+				options: bundle.DefaultUnpackOptions(),
+				release: func() error {
+					released++
+					return nil
+				},
+				progress: reported,
+			})
+			DeferCleanup(server.Close)
+		})
+
+		It("unpacks the source code and releases the waiter", func() {
+			Expect(upload(server.URL, token, tarball(map[string]string{"main.go": "package main"}))).To(Equal(http.StatusCreated))
+
+			data, err := os.ReadFile(filepath.Join(target, "main.go"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("package main"))
+			Expect(released).To(Equal(1))
+		})
+
+		It("reports the received bytes", func() {
+			body := tarball(map[string]string{"main.go": "package main"})
+			Expect(upload(server.URL, token, body)).To(Equal(http.StatusCreated))
+
+			Expect(reported.state).To(Equal(stateReceiving))
+			Expect(reported.receivedBytes).To(Equal(int64(len(body))))
+		})
+
+		It("accepts the upload token in the upload token header", func() {
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(tarball(map[string]string{"main.go": "package main"})))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Authorization", "Bearer kubernetes-token")
+			req.Header.Set("X-Shipwright-Upload-Token", token)
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+			Expect(released).To(Equal(1))
+		})
+
+		It("rejects requests without the upload token", func() {
+			Expect(upload(server.URL, "", tarball(map[string]string{"main.go": "package main"}))).To(Equal(http.StatusUnauthorized))
+			Expect(upload(server.URL, "wrong-token", tarball(map[string]string{"main.go": "package main"}))).To(Equal(http.StatusUnauthorized))
+
+			Expect(filepath.Join(target, "main.go")).ToNot(BeAnExistingFile())
+			Expect(released).To(Equal(0))
+		})
+
+		It("rejects a second upload", func() {
+			Expect(upload(server.URL, token, tarball(map[string]string{"main.go": "package main"}))).To(Equal(http.StatusCreated))
+			Expect(upload(server.URL, token, tarball(map[string]string{"other.go": "package main"}))).To(Equal(http.StatusConflict))
+
+			Expect(filepath.Join(target, "other.go")).ToNot(BeAnExistingFile())
+			Expect(released).To(Equal(1))
+		})
+
+		It("keeps waiting if the upload cannot be unpacked safely", func() {
+			Expect(upload(server.URL, token, tarball(map[string]string{"../escape.go": "package main"}))).To(Equal(http.StatusBadRequest))
+			Expect(released).To(Equal(0))
+			Expect(reported.state).To(Equal(stateWaiting))
+
+			Expect(upload(server.URL, token, tarball(map[string]string{"main.go": "package main"}))).To(Equal(http.StatusCreated))
+			Expect(released).To(Equal(1))
+		})
+
+		It("removes what a failed upload unpacked", func() {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			Expect(tw.WriteHeader(&tar.Header{Name: "src/", Mode: 0555, Typeflag: tar.TypeDir})).To(Succeed())
+			Expect(tw.WriteHeader(&tar.Header{Name: "src/main.go", Mode: 0644, Size: 12, Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte("package main"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tw.WriteHeader(&tar.Header{Name: "../escape.go", Mode: 0644, Typeflag: tar.TypeReg})).To(Succeed())
+			Expect(tw.Close()).To(Succeed())
+			Expect(gw.Close()).To(Succeed())
+
+			Expect(upload(server.URL, token, buf.Bytes())).To(Equal(http.StatusBadRequest))
+
+			entries, err := os.ReadDir(target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+			Expect(released).To(Equal(0))
+		})
+	})
+
+	Describe("waiter with upload endpoint", func() {
+		It("ends the waiting after the source code was uploaded", func() {
+			dir := GinkgoT().TempDir()
+			body := tarball(map[string]string{"main.go": "package main"})
+			tokenFile := filepath.Join(dir, "token")
+			Expect(os.WriteFile(tokenFile, []byte(token+"\n"), 0600)).To(Succeed())
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			port := listener.Addr().(*net.TCPAddr).Port
+			Expect(listener.Close()).To(Succeed())
+
+			w := NewWaiter(settings{
+				lockFile:        filepath.Join(dir, "waiter.lock"),
+				timeout:         time.Minute,
+				uploadPort:      port,
+				uploadTokenFile: tokenFile,
+				uploadTarget:    filepath.Join(dir, "source"),
+
+				resultFileState:         filepath.Join(dir, "state"),
+				resultFileTimeLeft:      filepath.Join(dir, "time-left"),
+				resultFileReceivedBytes: filepath.Join(dir, "received-bytes"),
+			})
+
+			result := make(chan error, 1)
+			go func() { result <- w.Wait(context.Background()) }()
+
+			url := fmt.Sprintf("http://127.0.0.1:%d%s", port, uploadPath)
+			Eventually(func() error {
+				conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+				if err != nil {
+					return err
+				}
+				return conn.Close()
+			}).Should(Succeed())
+
+			Expect(readFile(filepath.Join(dir, "state"))).To(Equal(stateWaiting))
+
+			Expect(upload(url, token, body)).To(Equal(http.StatusCreated))
+			Eventually(result).Should(Receive(BeNil()))
+
+			Expect(filepath.Join(dir, "source", "main.go")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "waiter.lock")).ToNot(BeAnExistingFile())
+
+			Expect(readFile(filepath.Join(dir, "state"))).To(Equal(stateDone))
+			Expect(readFile(filepath.Join(dir, "received-bytes"))).To(Equal(fmt.Sprintf("%d", len(body))))
+			Expect(time.ParseDuration(readFile(filepath.Join(dir, "time-left")))).To(BeNumerically(">", 50*time.Second))
+		})
+
+		It("fails if the upload token file does not exist", func() {
+			dir := GinkgoT().TempDir()
+
+			w := NewWaiter(settings{
+				lockFile:        filepath.Join(dir, "waiter.lock"),
+				timeout:         time.Minute,
+				uploadPort:      8080,
+				uploadTokenFile: filepath.Join(dir, "token"),
+			})
+
+			Expect(w.Wait(context.Background())).To(MatchError(ContainSubstring("failed to read the upload token")))
+			Expect(filepath.Join(dir, "waiter.lock")).ToNot(BeAnExistingFile())
+		})
+
+		It("ends promptly when the waiting is canceled", func() {
+			dir := GinkgoT().TempDir()
+
+			w := NewWaiter(settings{
+				lockFile:        filepath.Join(dir, "waiter.lock"),
+				timeout:         time.Hour,
+				resultFileState: filepath.Join(dir, "state"),
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			result := make(chan error, 1)
+			go func() { result <- w.Wait(ctx) }()
+
+			Eventually(filepath.Join(dir, "state")).Should(BeAnExistingFile())
+			cancel()
+
+			Eventually(result).Should(Receive(MatchError(ErrCanceled)))
+			Expect(readFile(filepath.Join(dir, "state"))).To(Equal(stateCanceled))
+			Expect(filepath.Join(dir, "waiter.lock")).ToNot(BeAnExistingFile())
+		})
+	})
+})
@@ -4,12 +4,18 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/shipwright-io/build/pkg/bundle"
 )
 
 // Waiter represents the actor that will wait for timeout, using a lock-file to keep it actively
@@ -21,6 +27,9 @@ type Waiter struct {
 // ErrTimeout emitted when timeout is reached.
 var ErrTimeout = errors.New("timeout waiting for condition")
 
+// ErrCanceled emitted when the waiting is canceled, for instance by a termination signal.
+var ErrCanceled = errors.New("waiting was canceled")
+
 // save writes the lock-file with informed PID.
 func (w *Waiter) save(pid int) error {
 	return os.WriteFile(w.flagValues.lockFile, []byte(strconv.Itoa(pid)), 0600)
@@ -39,41 +48,131 @@ func (w *Waiter) read() (int, error) {
 	return pid, nil
 }
 
-// validate that a lock file not longer exists, otherwise timeout if needed
-func (w *Waiter) retry() error {
+// validate that a lock file not longer exists, otherwise timeout if needed, or end when the
+// waiting is canceled. The progress is reported every second.
+func (w *Waiter) retry(ctx context.Context, progress *progress) error {
 	timer := time.NewTimer(w.flagValues.timeout)
 	defer timer.Stop()
 
 	// Verify on file existence every 100ms
-	ticker := time.Tick(100 * time.Millisecond)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	report := time.NewTicker(time.Second)
+	defer report.Stop()
 
 	for {
 		select {
+		case <-ctx.Done():
+			return ErrCanceled
 		case <-timer.C:
 			return fmt.Errorf("%w: elapsed %v seconds", ErrTimeout, w.flagValues.timeout.Seconds())
-		case <-ticker:
+		case <-ticker.C:
 			if _, err := os.Stat(w.flagValues.lockFile); err != nil && os.IsNotExist(err) {
 				log.Printf("Done! Condition has been reached\n")
 				return nil
 			}
 			// do nothing and continue the ticker
+		case <-report.C:
+			if err := progress.report(); err != nil {
+				log.Printf("Failed to report the progress: %v\n", err)
+			}
 		}
 	}
+}
+
+// serveUpload starts the endpoint that receives the source code upload, the returned function
+// stops it. Uploads in progress are aborted when the informed context is canceled.
+func (w *Waiter) serveUpload(ctx context.Context, progress *progress) (func(), error) {
+	data, err := os.ReadFile(w.flagValues.uploadTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the upload token: %w", err)
+	}
 
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, fmt.Errorf("upload token file '%s' is empty", w.flagValues.uploadTokenFile)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", w.flagValues.uploadPort))
+	if err != nil {
+		return nil, err
+	}
+
+	options := bundle.DefaultUnpackOptions()
+	options.MaxSize = w.flagValues.uploadMaxSize
+
+	mux := http.NewServeMux()
+	mux.Handle(uploadPath, &uploadHandler{
+		token:    token,
+		target:   w.flagValues.uploadTarget,
+		options:  options,
+		release:  func() error { return os.Remove(w.flagValues.lockFile) },
+		progress: progress,
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Upload endpoint failed: %v\n", err)
+		}
+	}()
+
+	log.Printf("Waiting for the source code upload on port %d\n", w.flagValues.uploadPort)
+
+	return func() {
+		// let the response of the upload, that ended the waiting, be sent
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}, nil
 }
 
-// Wait wait for the lock-file to be removed, or timeout.
-func (w *Waiter) Wait() error {
+// Wait wait for the lock-file to be removed, timeout, or the informed context to be canceled.
+// The state of the waiting is reported to the result files.
+func (w *Waiter) Wait(ctx context.Context) error {
 	pid := os.Getpid()
 	if err := w.save(pid); err != nil {
 		return err
 	}
 
+	progress := newProgress(w.flagValues)
+	if err := progress.report(); err != nil {
+		_ = os.RemoveAll(w.flagValues.lockFile)
+		return err
+	}
+
+	if w.flagValues.uploadPort > 0 {
+		stop, err := w.serveUpload(ctx, progress)
+		if err != nil {
+			_ = os.RemoveAll(w.flagValues.lockFile)
+			return err
+		}
+		defer stop()
+	}
+
 	// waiting for the lock-file removal...
-	err := w.retry()
+	err := w.retry(ctx, progress)
+
+	state := stateDone
+	switch {
+	case errors.Is(err, ErrCanceled):
+		state = stateCanceled
+	case errors.Is(err, ErrTimeout):
+		state = stateTimedOut
+	}
+
 	if err != nil {
 		_ = os.RemoveAll(w.flagValues.lockFile)
 	}
+
+	if reportErr := progress.setState(state); reportErr != nil && err == nil {
+		return reportErr
+	}
 	return err
 }
 
@@ -5,6 +5,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -21,6 +22,14 @@ const LocalCopy BuildSourceType = "LocalCopy"
 // NOTICE: HTTP artifact downloads are deprecated. This feature will be removed in a future release.
 const HTTP BuildSourceType = "HTTP"
 
+// Git defines an additional Git repository, which is cloned into the target directory
+// of the source next to the source code of `.spec.source`.
+const Git BuildSourceType = "Git"
+
+// Bundle defines an additional bundle image, which is pulled and unpacked into the target
+// directory of the source next to the source code of `.spec.source`.
+const Bundle BuildSourceType = "Bundle"
+
 // BuildSource remote artifact definition, also known as "sources". Simple "name" and "url" pairs,
 // initially without "credentials" (authentication) support yet.
 type BuildSource struct {
@@ -37,8 +46,60 @@ type BuildSource struct {
 	// +optional
 	Timeout *metav1.Duration `json:"timeout,omitempty"`
 
-	// URL remote artifact location.
+	// URL remote artifact location, or the URL of the Git repository for the
+	// Git type.
 	//
 	// +optional
 	URL string `json:"url,omitempty"`
+
+	// Revision describes the Git revision (e.g., branch, tag, commit SHA,
+	// etc.) to fetch for the Git type.
+	//
+	// If not defined, it will fallback to the repository's default branch.
+	//
+	// +optional
+	Revision *string `json:"revision,omitempty"`
+
+	// BundleContainer describes the bundle image to pull for the Bundle type.
+	//
+	// +optional
+	BundleContainer *BundleContainer `json:"bundleContainer,omitempty"`
+
+	// Credentials references a Secret that contains credentials to access
+	// the Git repository, the registry of the bundle image, or the web server
+	// of the HTTP type.
+	//
+	// +optional
+	Credentials *corev1.LocalObjectReference `json:"credentials,omitempty"`
+
+	// TargetDir is the directory relative to the source root that the Git
+	// repository, the bundle image, or the file of the HTTP type is written to.
+	//
+	// If not defined, it defaults to the name of the source for the Git and
+	// Bundle types, and to the source root for the HTTP type.
+	//
+	// +optional
+	TargetDir *string `json:"targetDir,omitempty"`
+
+	// Checksum is the sha256 digest of the file of the HTTP type in the format
+	// sha256:<hex>. The build fails if the downloaded file does not match.
+	//
+	// +optional
+	Checksum *string `json:"checksum,omitempty"`
+
+	// FileName is the name of the file of the HTTP type in the target directory.
+	//
+	// If not defined, it defaults to the last segment of the URL path.
+	//
+	// +optional
+	FileName *string `json:"fileName,omitempty"`
+
+	// Extract defines whether the file of the HTTP type is a tar or gzip
+	// compressed tar file, which is extracted into the target directory
+	// instead of being stored as a file.
+	//
+	// If not defined, it defaults to false.
+	//
+	// +optional
+	Extract *bool `json:"extract,omitempty"`
 }
@@ -24,6 +24,12 @@ const (
 	SetOwnerReferenceFailed BuildReason = "SetOwnerReferenceFailed"
 	// SpecSourceSecretRefNotFound indicates the referenced secret in source is missing
 	SpecSourceSecretRefNotFound BuildReason = "SpecSourceSecretRefNotFound"
+	// SpecSourceSignatureKeysSecretRefNotFound indicates the referenced secret with the trusted keys for the source signature verification is missing
+	SpecSourceSignatureKeysSecretRefNotFound BuildReason = "SpecSourceSignatureKeysSecretRefNotFound"
+	// SpecSourceArchiveInvalid indicates the URL or the checksum of the source archive is invalid
+	SpecSourceArchiveInvalid BuildReason = "SpecSourceArchiveInvalid"
+	// SpecSourcesInvalid indicates an entry of the sources is invalid
+	SpecSourcesInvalid BuildReason = "SpecSourcesInvalid"
 	// SpecOutputSecretRefNotFound indicates the referenced secret in output is missing
 	SpecOutputSecretRefNotFound BuildReason = "SpecOutputSecretRefNotFound"
 	// SpecBuilderSecretRefNotFound indicates the referenced secret in builder is missing
@@ -60,6 +66,18 @@ const (
 	VolumeNotOverridable BuildReason = "VolumeNotOverridable"
 	// UndefinedVolume indicates that volume defined by build is not found in the strategy
 	UndefinedVolume BuildReason = "UndefinedVolume"
+	// UndefinedSecretMount indicates that a secret mount defined by build is not found in the strategy
+	UndefinedSecretMount BuildReason = "UndefinedSecretMount"
+	// MissingSecretMount indicates that a secret mount required by the strategy is not provided
+	MissingSecretMount BuildReason = "MissingSecretMount"
+	// SecretMountPathInvalid indicates that the mount path of a secret mount in the strategy is not absolute, or
+	// overlaps with the paths that Shipwright or Tekton use
+	SecretMountPathInvalid BuildReason = "SecretMountPathInvalid"
+	// SecretMountDuplicate indicates that a secret mount is provided more than once, or that secret mounts of the
+	// strategy use the same mount path
+	SecretMountDuplicate BuildReason = "SecretMountDuplicate"
+	// SpecSecretMountSecretRefNotFound indicates the referenced secret of a secret mount is missing
+	SpecSecretMountSecretRefNotFound BuildReason = "SpecSecretMountSecretRefNotFound"
 	// TriggerNameCanNotBeBlank indicates the trigger condition does not have a name
 	TriggerNameCanNotBeBlank BuildReason = "TriggerNameCanNotBeBlank"
 	// TriggerInvalidType indicates the trigger type is invalid
@@ -178,6 +196,23 @@ type BuildSpec struct {
 	// to be overridden. Must only contain volumes that exist in the corresponding BuildStrategy
 	// +optional
 	Volumes []BuildVolume `json:"volumes,omitempty"`
+
+	// Secrets contains secrets that are mounted as files into the build strategy steps. Must only
+	// contain secret mounts that exist in the corresponding BuildStrategy
+	// +optional
+	Secrets []BuildSecret `json:"secrets,omitempty"`
+
+	// Hermetic defines whether the steps of the build strategy run without network access. The
+	// steps that fetch the source code and process the image are not affected and keep their network access.
+	//
+	// +optional
+	Hermetic *bool `json:"hermetic,omitempty"`
+
+	// Network configures the certificate authorities and the proxy that the steps which fetch the
+	// source code and push the image use. It takes precedence over the cluster-wide settings.
+	//
+	// +optional
+	Network *BuildNetwork `json:"network,omitempty"`
 }
 
 // BuildVolume is a volume that will be mounted in build pod during build step
@@ -198,6 +233,50 @@ type BuildVolume struct {
 	corev1.VolumeSource `json:",inline"`
 }
 
+// BuildSecret is a secret that will be mounted as files into the build strategy steps
+// at the path that the Build Strategy declares for the secret mount
+type BuildSecret struct {
+	// Name of the secret mount in the Build Strategy
+	// +required
+	Name string `json:"name"`
+
+	// Name of the secret in the namespace of the Build
+	// +required
+	SecretName string `json:"secretName"`
+
+	// Items selects the keys of the secret and the file names under which they are mounted.
+	// All keys of the secret are mounted if no items are specified
+	// +optional
+	Items []corev1.KeyToPath `json:"items,omitempty"`
+}
+
+// BuildNetwork defines the certificate authorities and the proxy for the steps that fetch the
+// source code and push the image. The steps of the build strategy are not affected.
+type BuildNetwork struct {
+	// CABundle references a key of a ConfigMap in the namespace of the Build that contains
+	// PEM-encoded certificates of certificate authorities, which are trusted in addition to
+	// the system certificate authorities.
+	//
+	// +optional
+	CABundle *corev1.ConfigMapKeySelector `json:"caBundle,omitempty"`
+
+	// HTTPProxy is the proxy for HTTP connections. An empty value disables the cluster-wide proxy.
+	//
+	// +optional
+	HTTPProxy *string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the proxy for HTTPS connections. An empty value disables the cluster-wide proxy.
+	//
+	// +optional
+	HTTPSProxy *string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is a comma-separated list of hosts, domains and IP ranges that are connected to
+	// without the proxy.
+	//
+	// +optional
+	NoProxy *string `json:"noProxy,omitempty"`
+}
+
 // StrategyName returns the name of the configured strategy, or 'undefined' in
 // case the strategy is nil (not set)
 func (buildSpec *BuildSpec) StrategyName() string {
@@ -208,6 +287,11 @@ func (buildSpec *BuildSpec) StrategyName() string {
 	return buildSpec.Strategy.Name
 }
 
+// IsHermetic returns true if the build strategy steps are supposed to run without network access
+func (buildSpec *BuildSpec) IsHermetic() bool {
+	return buildSpec != nil && buildSpec.Hermetic != nil && *buildSpec.Hermetic
+}
+
 // Image refers to an container image with credentials
 type Image struct {
 	// Image is the reference of the image.
@@ -233,6 +317,14 @@ type Image struct {
 	//
 	// +optional
 	Labels map[string]string `json:"labels,omitempty"`
+
+	// StandardAnnotations defines whether the standard OCI annotations that
+	// describe the source of the image, and the annotations that identify the
+	// Build and BuildRun are applied on the image. Annotations defined in
+	// Annotations take precedence.
+	//
+	// +optional
+	StandardAnnotations *bool `json:"standardAnnotations,omitempty"`
 }
 
 // BuildStatus defines the observed state of Build
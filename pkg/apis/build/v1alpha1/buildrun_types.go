@@ -81,6 +81,12 @@ type BuildRunSpec struct {
 	// to be overridden. Must only contain volumes that exist in the corresponding BuildStrategy
 	// +optional
 	Volumes []BuildVolume `json:"volumes,omitempty"`
+
+	// Secrets contains secrets that are mounted as files into the build strategy steps. They
+	// override the secrets of the Build with the same name. Must only contain secret mounts that
+	// exist in the corresponding BuildStrategy
+	// +optional
+	Secrets []BuildSecret `json:"secrets,omitempty"`
 }
 
 // BuildRunRequestedState defines the buildrun state the user can provide to override whatever is the current state.
@@ -99,6 +105,14 @@ const (
 	// BuildRunStatePodEvicted indicates that if the pods got evicted
 	// due to some reason. (Probably ran out of ephemeral storage)
 	BuildRunStatePodEvicted = "PodEvicted"
+
+	// BuildRunStatePrivilegedStrategyNotAllowed indicates that the build strategy requires
+	// elevated privileges which are not allowed in the namespace of the BuildRun
+	BuildRunStatePrivilegedStrategyNotAllowed = "PrivilegedStrategyNotAllowed"
+
+	// BuildRunStateSecretMountSecretNotFound indicates that the secret of a secret mount of
+	// the Build or BuildRun does not exist
+	BuildRunStateSecretMountSecretNotFound = "SecretMountSecretNotFound"
 )
 
 // SourceResult holds the results emitted from the different sources
@@ -117,12 +131,107 @@ type SourceResult struct {
 	//
 	// +optional
 	Bundle *BundleSourceResult `json:"bundle,omitempty"`
+	// Archive holds the results emitted from the
+	// step definition of an archive source
+	//
+	// +optional
+	Archive *ArchiveSourceResult `json:"archive,omitempty"`
+
+	// HTTP holds the results emitted from the
+	// step definition of an HTTP source
+	//
+	// +optional
+	HTTP *HTTPSourceResult `json:"http,omitempty"`
+
+	// LocalCopy holds the state reported by the step that waits for
+	// the upload of a local source
+	//
+	// +optional
+	LocalCopy *LocalCopySourceResult `json:"localCopy,omitempty"`
 }
 
 // BundleSourceResult holds the results emitted from the bundle source
 type BundleSourceResult struct {
 	// Digest hold the image digest result
 	Digest string `json:"digest,omitempty"`
+
+	// Directory holds the name of the directory that was packed into the bundle image
+	//
+	// +optional
+	Directory string `json:"directory,omitempty"`
+
+	// CommitSha holds the commit sha of the Git worktree that contained the directory
+	//
+	// +optional
+	CommitSha string `json:"commitSha,omitempty"`
+
+	// IgnorePatterns holds the .shpignore patterns that were applied when the
+	// bundle image was created
+	//
+	// +optional
+	IgnorePatterns []string `json:"ignorePatterns,omitempty"`
+
+	// VerifiedFiles holds the number of unpacked files that were verified
+	// against the digests in the bundle image
+	//
+	// +optional
+	VerifiedFiles int `json:"verifiedFiles,omitempty"`
+
+	// PruneWarning holds the reason why the bundle image could not be deleted
+	// from the registry after it was pulled
+	//
+	// +optional
+	PruneWarning string `json:"pruneWarning,omitempty"`
+}
+
+// ArchiveSourceResult holds the results emitted from the archive source
+type ArchiveSourceResult struct {
+	// Digest holds the digest of the image or OCI artifact, or the checksum
+	// of the tar file
+	Digest string `json:"digest,omitempty"`
+}
+
+// HTTPSourceResult holds the results emitted from the HTTP source
+type HTTPSourceResult struct {
+	// Digest holds the sha256 digest of the downloaded file
+	Digest string `json:"digest,omitempty"`
+}
+
+// LocalCopyState is the state of the step that waits for the upload of a local source
+type LocalCopyState string
+
+const (
+	// LocalCopyWaiting indicates that the upload of the local source is awaited
+	LocalCopyWaiting LocalCopyState = "Waiting"
+
+	// LocalCopyReceiving indicates that the local source is being uploaded
+	LocalCopyReceiving LocalCopyState = "Receiving"
+
+	// LocalCopyDone indicates that the local source was uploaded
+	LocalCopyDone LocalCopyState = "Done"
+
+	// LocalCopyTimedOut indicates that the local source was not uploaded before the timeout
+	LocalCopyTimedOut LocalCopyState = "TimedOut"
+
+	// LocalCopyCanceled indicates that the BuildRun was canceled while the upload was awaited
+	LocalCopyCanceled LocalCopyState = "Canceled"
+)
+
+// LocalCopySourceResult holds the state reported by the step that waits for the
+// upload of a local source
+type LocalCopySourceResult struct {
+	// State is the state of the waiting
+	State LocalCopyState `json:"state,omitempty"`
+
+	// TimeLeft is the time that was left until the timeout when the state was reported
+	//
+	// +optional
+	TimeLeft *metav1.Duration `json:"timeLeft,omitempty"`
+
+	// ReceivedBytes is the amount of bytes of the uploaded local source
+	//
+	// +optional
+	ReceivedBytes int64 `json:"receivedBytes,omitempty"`
 }
 
 // GitSourceResult holds the results emitted from the git source
@@ -136,6 +245,51 @@ type GitSourceResult struct {
 	// BranchName holds the default branch name of the git source
 	// this will be set only when revision is not specified in Build object
 	BranchName string `json:"branchName,omitempty"`
+
+	// Submodules holds the commit shas of the fetched submodules
+	//
+	// +optional
+	Submodules []GitSubmoduleResult `json:"submodules,omitempty"`
+
+	// CommitTimestamp holds the committer timestamp of the git source
+	//
+	// +optional
+	CommitTimestamp *metav1.Time `json:"commitTimestamp,omitempty"`
+
+	// Committer holds the committer of a git source
+	//
+	// +optional
+	Committer string `json:"committer,omitempty"`
+
+	// CommitMessage holds the subject of the commit message of a git source
+	//
+	// +optional
+	CommitMessage string `json:"commitMessage,omitempty"`
+
+	// Tags holds the tags that point at the commit of a git source
+	//
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Describe holds the output of git describe for the commit of a git source,
+	// which is the abbreviated commit sha if no tag points at the commit
+	//
+	// +optional
+	Describe string `json:"describe,omitempty"`
+
+	// URL holds the URL of the git source without credentials
+	//
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// GitSubmoduleResult holds the result of a fetched submodule
+type GitSubmoduleResult struct {
+	// Path is the path of the submodule in the repository
+	Path string `json:"path"`
+
+	// CommitSha holds the commit sha of the submodule
+	CommitSha string `json:"commitSha"`
 }
 
 // Output holds the results emitted from the output step (build-and-push)
@@ -147,6 +301,22 @@ type Output struct {
 	Size int64 `json:"size,omitempty"`
 }
 
+// StepImage holds the container image of a TaskRun step, and the digest it
+// was resolved to when the BuildRun started
+type StepImage struct {
+	// Name is the name of the step
+	Name string `json:"name"`
+
+	// Image is the image reference as defined for the step
+	Image string `json:"image"`
+
+	// Digest holds the digest the image reference was resolved to, it is
+	// empty when the image reference could not be resolved upfront
+	//
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
 // BuildRunStatus defines the observed state of BuildRun
 type BuildRunStatus struct {
 	// Sources holds the results emitted from the step definition
@@ -160,6 +330,18 @@ type BuildRunStatus struct {
 	// +optional
 	Output *Output `json:"output,omitempty"`
 
+	// StepImages holds the images of all steps pinned to their digests at
+	// the time the TaskRun was created
+	//
+	// +optional
+	StepImages []StepImage `json:"stepImages,omitempty"`
+
+	// Hermetic indicates whether the steps of the build strategy ran without network access as
+	// the Build requested
+	//
+	// +optional
+	Hermetic *bool `json:"hermetic,omitempty"`
+
 	// Conditions holds the latest available observations of a resource's current state.
 	Conditions Conditions `json:"conditions,omitempty"`
 
@@ -202,6 +384,10 @@ type FailureDetails struct {
 	Reason   string    `json:"reason,omitempty"`
 	Message  string    `json:"message,omitempty"`
 	Location *FailedAt `json:"location,omitempty"`
+	// Retryable indicates that the failure is likely transient, so that a new BuildRun
+	// of the same Build can succeed
+	// +optional
+	Retryable bool `json:"retryable,omitempty"`
 }
 
 // BuildRef can be used to refer to a specific instance of a Build.
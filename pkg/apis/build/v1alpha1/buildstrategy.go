@@ -21,6 +21,7 @@ type BuildStrategySpec struct {
 	BuildSteps []BuildStep           `json:"buildSteps,omitempty"`
 	Parameters []Parameter           `json:"parameters,omitempty"`
 	Volumes    []BuildStrategyVolume `json:"volumes,omitempty"`
+	Secrets    []BuildStrategySecret `json:"secrets,omitempty"`
 }
 
 // ParameterType indicates the type of a parameter
@@ -81,6 +82,27 @@ type BuildStrategyVolume struct {
 	corev1.VolumeSource `json:",inline"`
 }
 
+// BuildStrategySecret declares a secret that a Build or BuildRun can mount as files into
+// the steps of the Build Strategy
+type BuildStrategySecret struct {
+	// Name of the secret mount, the Build or BuildRun references the secret mount by this name
+	// +required
+	Name string `json:"name"`
+
+	// Path within the build strategy steps at which the keys of the secret are mounted as files
+	// +required
+	MountPath string `json:"mountPath"`
+
+	// Description of the secret mount
+	// +optional
+	Description *string `json:"description,omitempty"`
+
+	// Indicates that a Build or BuildRun does not need to provide a secret for this mount.
+	// Defaults to false
+	// +optional
+	Optional *bool `json:"optional,omitempty"`
+}
+
 // BuildStep defines a partial step that needs to run in container for building the image.
 // If the build step declares a volumeMount, Shipwright will create an emptyDir volume mount for the named volume.
 // Build steps which share the same named volume in the volumeMount will share the same underlying emptyDir volume.
@@ -120,4 +142,5 @@ type BuilderStrategy interface {
 	GetBuildSteps() []BuildStep
 	GetParameters() []Parameter
 	GetVolumes() []BuildStrategyVolume
+	GetSecrets() []BuildStrategySecret
 }
@@ -84,6 +84,11 @@ func (s BuildStrategy) GetVolumes() []BuildStrategyVolume {
 	return s.Spec.Volumes
 }
 
+// GetSecrets returns the secret mounts defined by the build strategy
+func (s BuildStrategy) GetSecrets() []BuildStrategySecret {
+	return s.Spec.Secrets
+}
+
 func init() {
 	SchemeBuilder.Register(&BuildStrategy{}, &BuildStrategyList{})
 }
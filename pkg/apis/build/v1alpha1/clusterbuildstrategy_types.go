@@ -19,6 +19,10 @@ const (
 
 	// LabelClusterBuildStrategyGeneration is a label key for defining the cluster build strategy generation
 	LabelClusterBuildStrategyGeneration = ClusterBuildStrategyDomain + "/generation"
+
+	// AnnotationPrivilegedNamespaces is an annotation key for a comma-separated list of namespaces that are allowed to use
+	// the cluster build strategy even if it requires elevated privileges and such strategies are restricted
+	AnnotationPrivilegedNamespaces = ClusterBuildStrategyDomain + "/privileged-namespaces"
 )
 
 // +genclient
@@ -85,6 +89,11 @@ func (s ClusterBuildStrategy) GetVolumes() []BuildStrategyVolume {
 	return s.Spec.Volumes
 }
 
+// GetSecrets returns the secret mounts defined by the build strategy
+func (s ClusterBuildStrategy) GetSecrets() []BuildStrategySecret {
+	return s.Spec.Secrets
+}
+
 func init() {
 	SchemeBuilder.Register(&ClusterBuildStrategy{}, &ClusterBuildStrategyList{})
 }
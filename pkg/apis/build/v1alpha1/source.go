@@ -34,6 +34,101 @@ type BundleContainer struct {
 	Prune *PruneOption `json:"prune,omitempty"`
 }
 
+// Archive describes an archive that contains the source code
+type Archive struct {
+	// URL of the archive. Use the oci:// scheme for a container image, or an
+	// OCI artifact with tar layers, in a container registry, for example
+	// oci://quay.io/org/source:tag, and the https:// scheme for a tar or gzip
+	// compressed tar file on a web server.
+	URL string `json:"url"`
+
+	// Checksum is the sha256 digest of the tar file in the format sha256:<hex>.
+	// The tar file is only unpacked if its digest matches. It is required for
+	// the https:// scheme, and not supported for the oci:// scheme, where the
+	// URL can reference the digest of the image or OCI artifact instead.
+	//
+	// +optional
+	Checksum *string `json:"checksum,omitempty"`
+}
+
+// SparseCheckout describes the directories of the Git repository to check out
+type SparseCheckout struct {
+	// Paths lists directories of the repository that are checked out in
+	// addition to the context directory, if one is defined, for example
+	// shared libraries.
+	//
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+}
+
+// GitSubmodules describes which submodules of the Git repository are fetched
+type GitSubmodules struct {
+	// Enabled defines whether the submodules are fetched.
+	//
+	// If not defined, it defaults to true.
+	//
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Recursive defines whether the nested submodules of the submodules
+	// are fetched as well.
+	//
+	// If not defined, it defaults to true.
+	//
+	// +optional
+	Recursive *bool `json:"recursive,omitempty"`
+
+	// Paths limits the submodules that are fetched to the ones at the
+	// listed paths. All submodules are fetched if empty.
+	//
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+}
+
+// GitLFS describes which Git Large File Storage (LFS) files are fetched
+type GitLFS struct {
+	// Enabled defines whether the Git LFS files are fetched. If disabled,
+	// the files contain the Git LFS pointers instead of their content.
+	//
+	// If not defined, it defaults to true.
+	//
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Include limits the Git LFS files that are fetched to the ones that
+	// match the listed patterns. All Git LFS files are fetched if empty.
+	//
+	// +optional
+	Include []string `json:"include,omitempty"`
+}
+
+// GitSignatureVerification describes the trusted public keys that the fetched
+// Git commit or tag must be signed with
+type GitSignatureVerification struct {
+	// SecretRef references a Secret that contains the trusted GPG public keys
+	// in ASCII armor format, or SSH public keys in authorized keys or allowed
+	// signers format. Every key of the Secret is read.
+	//
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// ConfigMapRef references a ConfigMap that contains the trusted public
+	// keys in the same formats as the SecretRef. The SecretRef takes precedence
+	// if both are defined.
+	//
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// Tag defines whether the signature of the annotated tag that the revision
+	// references is verified instead of the signature of the commit. The tag
+	// must point at the fetched commit.
+	//
+	// If not defined, it defaults to false.
+	//
+	// +optional
+	Tag *bool `json:"tag,omitempty"`
+}
+
 // Source describes the Git source repository to fetch.
 type Source struct {
 	// URL describes the URL of the Git repository.
@@ -46,6 +141,12 @@ type Source struct {
 	// +optional
 	BundleContainer *BundleContainer `json:"bundleContainer,omitempty"`
 
+	// Archive references an image, OCI artifact, or tar file that contains
+	// the source code.
+	//
+	// +optional
+	Archive *Archive `json:"archive,omitempty"`
+
 	// Revision describes the Git revision (e.g., branch, tag, commit SHA,
 	// etc.) to fetch.
 	//
@@ -59,6 +160,40 @@ type Source struct {
 	// +optional
 	ContextDir *string `json:"contextDir,omitempty"`
 
+	// SignatureVerification requires the fetched commit, or tag, to have a
+	// valid GPG or SSH signature from one of the trusted public keys. The
+	// build fails if the signature is missing or cannot be verified.
+	//
+	// If not defined, signatures are not verified.
+	//
+	// +optional
+	SignatureVerification *GitSignatureVerification `json:"signatureVerification,omitempty"`
+
+	// SparseCheckout limits the checkout of the Git repository to the context
+	// directory and the listed paths. Only the file contents of these
+	// directories are downloaded, which reduces the time and the ephemeral
+	// storage needed to build from a large repository.
+	//
+	// If not defined, the whole repository is checked out.
+	//
+	// +optional
+	SparseCheckout *SparseCheckout `json:"sparseCheckout,omitempty"`
+
+	// Submodules configures which submodules of the Git repository are
+	// fetched.
+	//
+	// If not defined, all submodules are fetched recursively.
+	//
+	// +optional
+	Submodules *GitSubmodules `json:"submodules,omitempty"`
+
+	// LFS configures which Git Large File Storage (LFS) files are fetched.
+	//
+	// If not defined, all Git LFS files are fetched.
+	//
+	// +optional
+	LFS *GitLFS `json:"lfs,omitempty"`
+
 	// Credentials references a Secret that contains credentials to access
 	// the repository.
 	//
@@ -15,6 +15,43 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Archive) DeepCopyInto(out *Archive) {
+	*out = *in
+	if in.Checksum != nil {
+		in, out := &in.Checksum, &out.Checksum
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Archive.
+func (in *Archive) DeepCopy() *Archive {
+	if in == nil {
+		return nil
+	}
+	out := new(Archive)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchiveSourceResult) DeepCopyInto(out *ArchiveSourceResult) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchiveSourceResult.
+func (in *ArchiveSourceResult) DeepCopy() *ArchiveSourceResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchiveSourceResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Build) DeepCopyInto(out *Build) {
 	*out = *in
@@ -76,6 +113,42 @@ func (in *BuildList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildNetwork) DeepCopyInto(out *BuildNetwork) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPProxy != nil {
+		in, out := &in.HTTPProxy, &out.HTTPProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.HTTPSProxy != nil {
+		in, out := &in.HTTPSProxy, &out.HTTPSProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildNetwork.
+func (in *BuildNetwork) DeepCopy() *BuildNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildRef) DeepCopyInto(out *BuildRef) {
 	*out = *in
@@ -286,6 +359,13 @@ func (in *BuildRunSpec) DeepCopyInto(out *BuildRunSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]BuildSecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -314,6 +394,16 @@ func (in *BuildRunStatus) DeepCopyInto(out *BuildRunStatus) {
 		*out = new(Output)
 		**out = **in
 	}
+	if in.StepImages != nil {
+		in, out := &in.StepImages, &out.StepImages
+		*out = make([]StepImage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hermetic != nil {
+		in, out := &in.Hermetic, &out.Hermetic
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(Conditions, len(*in))
@@ -362,6 +452,29 @@ func (in *BuildRunStatus) DeepCopy() *BuildRunStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildSecret) DeepCopyInto(out *BuildSecret) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]corev1.KeyToPath, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildSecret.
+func (in *BuildSecret) DeepCopy() *BuildSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildSource) DeepCopyInto(out *BuildSource) {
 	*out = *in
@@ -370,6 +483,41 @@ func (in *BuildSource) DeepCopyInto(out *BuildSource) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.Revision != nil {
+		in, out := &in.Revision, &out.Revision
+		*out = new(string)
+		**out = **in
+	}
+	if in.BundleContainer != nil {
+		in, out := &in.BundleContainer, &out.BundleContainer
+		*out = new(BundleContainer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.TargetDir != nil {
+		in, out := &in.TargetDir, &out.TargetDir
+		*out = new(string)
+		**out = **in
+	}
+	if in.Checksum != nil {
+		in, out := &in.Checksum, &out.Checksum
+		*out = new(string)
+		**out = **in
+	}
+	if in.FileName != nil {
+		in, out := &in.FileName, &out.FileName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Extract != nil {
+		in, out := &in.Extract, &out.Extract
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -442,6 +590,23 @@ func (in *BuildSpec) DeepCopyInto(out *BuildSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]BuildSecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hermetic != nil {
+		in, out := &in.Hermetic, &out.Hermetic
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(BuildNetwork)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -564,6 +729,32 @@ func (in *BuildStrategyList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildStrategySecret) DeepCopyInto(out *BuildStrategySecret) {
+	*out = *in
+	if in.Description != nil {
+		in, out := &in.Description, &out.Description
+		*out = new(string)
+		**out = **in
+	}
+	if in.Optional != nil {
+		in, out := &in.Optional, &out.Optional
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildStrategySecret.
+func (in *BuildStrategySecret) DeepCopy() *BuildStrategySecret {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildStrategySecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildStrategySpec) DeepCopyInto(out *BuildStrategySpec) {
 	*out = *in
@@ -588,6 +779,13 @@ func (in *BuildStrategySpec) DeepCopyInto(out *BuildStrategySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]BuildStrategySecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -690,6 +888,11 @@ func (in *BundleContainer) DeepCopy() *BundleContainer {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundleSourceResult) DeepCopyInto(out *BundleSourceResult) {
 	*out = *in
+	if in.IgnorePatterns != nil {
+		in, out := &in.IgnorePatterns, &out.IgnorePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -840,9 +1043,80 @@ func (in *FailureDetails) DeepCopy() *FailureDetails {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitLFS) DeepCopyInto(out *GitLFS) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitLFS.
+func (in *GitLFS) DeepCopy() *GitLFS {
+	if in == nil {
+		return nil
+	}
+	out := new(GitLFS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSignatureVerification) DeepCopyInto(out *GitSignatureVerification) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Tag != nil {
+		in, out := &in.Tag, &out.Tag
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSignatureVerification.
+func (in *GitSignatureVerification) DeepCopy() *GitSignatureVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSignatureVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitSourceResult) DeepCopyInto(out *GitSourceResult) {
 	*out = *in
+	if in.Submodules != nil {
+		in, out := &in.Submodules, &out.Submodules
+		*out = make([]GitSubmoduleResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.CommitTimestamp != nil {
+		in, out := &in.CommitTimestamp, &out.CommitTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -856,6 +1130,69 @@ func (in *GitSourceResult) DeepCopy() *GitSourceResult {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSubmoduleResult) DeepCopyInto(out *GitSubmoduleResult) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSubmoduleResult.
+func (in *GitSubmoduleResult) DeepCopy() *GitSubmoduleResult {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSubmoduleResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSubmodules) DeepCopyInto(out *GitSubmodules) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Recursive != nil {
+		in, out := &in.Recursive, &out.Recursive
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSubmodules.
+func (in *GitSubmodules) DeepCopy() *GitSubmodules {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSubmodules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPSourceResult) DeepCopyInto(out *HTTPSourceResult) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSourceResult.
+func (in *HTTPSourceResult) DeepCopy() *HTTPSourceResult {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPSourceResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Image) DeepCopyInto(out *Image) {
 	*out = *in
@@ -883,6 +1220,11 @@ func (in *Image) DeepCopyInto(out *Image) {
 			(*out)[key] = val
 		}
 	}
+	if in.StandardAnnotations != nil {
+		in, out := &in.StandardAnnotations, &out.StandardAnnotations
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -896,6 +1238,27 @@ func (in *Image) DeepCopy() *Image {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalCopySourceResult) DeepCopyInto(out *LocalCopySourceResult) {
+	*out = *in
+	if in.TimeLeft != nil {
+		in, out := &in.TimeLeft, &out.TimeLeft
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalCopySourceResult.
+func (in *LocalCopySourceResult) DeepCopy() *LocalCopySourceResult {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalCopySourceResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectKeyRef) DeepCopyInto(out *ObjectKeyRef) {
 	*out = *in
@@ -1061,6 +1424,11 @@ func (in *Source) DeepCopyInto(out *Source) {
 		*out = new(BundleContainer)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Archive != nil {
+		in, out := &in.Archive, &out.Archive
+		*out = new(Archive)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Revision != nil {
 		in, out := &in.Revision, &out.Revision
 		*out = new(string)
@@ -1071,6 +1439,26 @@ func (in *Source) DeepCopyInto(out *Source) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.SignatureVerification != nil {
+		in, out := &in.SignatureVerification, &out.SignatureVerification
+		*out = new(GitSignatureVerification)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SparseCheckout != nil {
+		in, out := &in.SparseCheckout, &out.SparseCheckout
+		*out = new(SparseCheckout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Submodules != nil {
+		in, out := &in.Submodules, &out.Submodules
+		*out = new(GitSubmodules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LFS != nil {
+		in, out := &in.LFS, &out.LFS
+		*out = new(GitLFS)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Credentials != nil {
 		in, out := &in.Credentials, &out.Credentials
 		*out = new(corev1.LocalObjectReference)
@@ -1095,13 +1483,28 @@ func (in *SourceResult) DeepCopyInto(out *SourceResult) {
 	if in.Git != nil {
 		in, out := &in.Git, &out.Git
 		*out = new(GitSourceResult)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Bundle != nil {
 		in, out := &in.Bundle, &out.Bundle
 		*out = new(BundleSourceResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Archive != nil {
+		in, out := &in.Archive, &out.Archive
+		*out = new(ArchiveSourceResult)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPSourceResult)
 		**out = **in
 	}
+	if in.LocalCopy != nil {
+		in, out := &in.LocalCopy, &out.LocalCopy
+		*out = new(LocalCopySourceResult)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1115,6 +1518,43 @@ func (in *SourceResult) DeepCopy() *SourceResult {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SparseCheckout) DeepCopyInto(out *SparseCheckout) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SparseCheckout.
+func (in *SparseCheckout) DeepCopy() *SparseCheckout {
+	if in == nil {
+		return nil
+	}
+	out := new(SparseCheckout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepImage) DeepCopyInto(out *StepImage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepImage.
+func (in *StepImage) DeepCopy() *StepImage {
+	if in == nil {
+		return nil
+	}
+	out := new(StepImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Strategy) DeepCopyInto(out *Strategy) {
 	*out = *in
@@ -0,0 +1,298 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	containerreg "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// checksumFormat is the format of the checksum of a downloaded file
+var checksumFormat = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// DownloadOptions configures the requests of a download
+type DownloadOptions struct {
+	// Header is added to the requests, for example to authenticate
+	Header http.Header
+
+	// Retries is the number of retries of a download that failed because of
+	// a network error or a server error
+	Retries int
+
+	// RetryDelay is the delay before the first retry, which doubles with
+	// every further retry
+	RetryDelay time.Duration
+
+	// OnRetry is called with the error and the delay before a retry (optional)
+	OnRetry func(err error, delay time.Duration)
+
+	// MaxSize is the maximum size of the downloaded file in bytes, zero means no limit
+	MaxSize int64
+}
+
+// StatusError is the error of a download that the server answered with an
+// unexpected HTTP status code
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to download %s: HTTP status code %d", e.URL, e.StatusCode)
+}
+
+// Retryable returns true if the server might answer the next request successfully
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// sizeLimitError is the error of a download that exceeded the maximum size,
+// which is not retried
+type sizeLimitError struct {
+	url   string
+	limit int64
+}
+
+func (e *sizeLimitError) Error() string {
+	return fmt.Sprintf("failed to download %s: file is larger than %d bytes, which is the limit", e.url, e.limit)
+}
+
+// ValidateChecksum returns an error if the checksum is not in the format sha256:<hex>
+func ValidateChecksum(checksum string) error {
+	if !checksumFormat.MatchString(checksum) {
+		return fmt.Errorf("checksum %q is not in the format sha256:<hex>", checksum)
+	}
+
+	return nil
+}
+
+// isArtifact returns true if the manifest describes an OCI artifact, which
+// has a configuration of another type than the one of container images
+func isArtifact(manifest *containerreg.Manifest) bool {
+	switch manifest.Config.MediaType {
+	case types.OCIConfigJSON, types.DockerConfigJSON:
+		return false
+
+	default:
+		return true
+	}
+}
+
+// isTarLayer returns true if the media type of the layer is a tar stream,
+// the compression is detected when the layer is read
+func isTarLayer(mediaType types.MediaType) bool {
+	switch mediaType {
+	case types.OCILayer, types.OCIUncompressedLayer, types.OCILayerZStd, types.DockerLayer, types.DockerUncompressedLayer:
+		return true
+
+	default:
+		return strings.Contains(string(mediaType), "tar")
+	}
+}
+
+// unpackArtifact writes the content of the tar layers of an OCI artifact into
+// the target directory in the order of the layers
+func unpackArtifact(image containerreg.Image, targetPath string, options UnpackOptions) error {
+	layers, err := image.Layers()
+	if err != nil {
+		return err
+	}
+
+	u, err := newUnpacker(targetPath, options)
+	if err != nil {
+		return err
+	}
+
+	for _, layer := range layers {
+		mediaType, err := layer.MediaType()
+		if err != nil {
+			return err
+		}
+
+		if !isTarLayer(mediaType) {
+			return fmt.Errorf("artifact contains a layer with the unsupported media type %q, only tar layers are supported", mediaType)
+		}
+
+		if err := unpackLayer(u, layer); err != nil {
+			return err
+		}
+	}
+
+	return u.finish()
+}
+
+func unpackLayer(u *unpacker, layer containerreg.Layer) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	return u.unpack(rc)
+}
+
+// DownloadAndUnpack downloads a tar or gzip compressed tar file, and writes its
+// content into the target directory. The tarball is only unpacked if its sha256
+// digest matches the checksum in the format sha256:<hex>, an empty checksum skips
+// the verification. The digest is returned in the same format. Unless the download
+// options define a maximum size, the download is limited to the maximum size of
+// the unpack options.
+func DownloadAndUnpack(ctx context.Context, client *http.Client, url string, checksum string, targetPath string, options UnpackOptions, downloadOptions DownloadOptions) (string, error) {
+	if checksum != "" {
+		if err := ValidateChecksum(checksum); err != nil {
+			return "", err
+		}
+	}
+
+	// the tarball is spooled to a temporary file, so that no file is written into
+	// the target directory before the checksum is verified
+	spool, err := os.CreateTemp("", "archive-*")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	if downloadOptions.MaxSize == 0 {
+		downloadOptions.MaxSize = options.MaxSize
+	}
+
+	digest, err := Download(ctx, client, url, spool, downloadOptions)
+	if err != nil {
+		return "", err
+	}
+
+	if checksum != "" && digest != checksum {
+		return "", fmt.Errorf("downloaded file does not match the checksum, expected %s, got %s", checksum, digest)
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return digest, UnpackArchive(spool, targetPath, options)
+}
+
+// UnpackArchive writes the content of a tar or gzip compressed tar stream into
+// the target directory, the compression is detected from the stream
+func UnpackArchive(in io.Reader, targetPath string, options UnpackOptions) error {
+	r, err := decompress(bufio.NewReader(in))
+	if err != nil {
+		return err
+	}
+
+	return UnpackWithOptions(r, targetPath, options)
+}
+
+// Download writes the file of the URL into the spool file, and returns its sha256
+// digest in the format sha256:<hex>. Downloads that failed because of a network
+// error or a server error are retried with an exponential backoff, the spool file
+// is truncated before every attempt.
+func Download(ctx context.Context, client *http.Client, url string, spool *os.File, options DownloadOptions) (string, error) {
+	delay := options.RetryDelay
+
+	for attempt := 0; ; attempt++ {
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+
+		if err := spool.Truncate(0); err != nil {
+			return "", err
+		}
+
+		digest, err := download(ctx, client, url, options.Header, options.MaxSize, spool)
+		if err == nil {
+			return digest, nil
+		}
+
+		var statusErr *StatusError
+		var sizeErr *sizeLimitError
+		if attempt >= options.Retries || ctx.Err() != nil || (errors.As(err, &statusErr) && !statusErr.Retryable()) || errors.As(err, &sizeErr) {
+			return "", err
+		}
+
+		if options.OnRetry != nil {
+			options.OnRetry(err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+func download(ctx context.Context, client *http.Client, url string, header http.Header, maxSize int64, w io.Writer) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{URL: url, StatusCode: resp.StatusCode}
+	}
+
+	// one byte more than the limit is read to detect that the file exceeds it
+	var body io.Reader = resp.Body
+	if maxSize > 0 {
+		body = io.LimitReader(resp.Body, maxSize+1)
+	}
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, hash), body)
+	if err != nil {
+		return "", err
+	}
+
+	if maxSize > 0 && n > maxSize {
+		return "", &sizeLimitError{url: url, limit: maxSize}
+	}
+
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// decompress returns the gzip decompressed stream if the stream starts with
+// the gzip header, and the stream as-is otherwise
+func decompress(r *bufio.Reader) (io.Reader, error) {
+	header, err := r.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(header) == 2 && header[0] == 0x1f && header[1] == 0x8b {
+		return gzip.NewReader(r)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,236 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	containerreg "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	. "github.com/shipwright-io/build/pkg/bundle"
+)
+
+var _ = Describe("Archive", func() {
+	Context("pulling OCI artifacts", func() {
+		var registryHost string
+
+		BeforeEach(func() {
+			server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			DeferCleanup(server.Close)
+
+			registryHost = strings.TrimPrefix(server.URL, "http://")
+		})
+
+		layer := func(mediaType types.MediaType, content *bytes.Buffer) containerreg.Layer {
+			data := content.Bytes()
+			layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			}, tarball.WithMediaType(mediaType))
+			Expect(err).ToNot(HaveOccurred())
+			return layer
+		}
+
+		pushArtifact := func(layers ...containerreg.Layer) name.Reference {
+			artifact, err := mutate.AppendLayers(empty.Image, layers...)
+			Expect(err).ToNot(HaveOccurred())
+
+			artifact = mutate.MediaType(artifact, types.OCIManifestSchema1)
+			artifact = mutate.ConfigMediaType(artifact, "application/vnd.example.source.config.v1+json")
+
+			ref, err := name.ParseReference(fmt.Sprintf("%s/test/artifact:latest", registryHost))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(remote.Write(ref, artifact)).To(Succeed())
+
+			return ref
+		}
+
+		It("should unpack the tar layers of an OCI artifact in their order", func() {
+			ref := pushArtifact(
+				layer("application/vnd.example.source.layer.v1.tar+gzip", tarStream(
+					file("README.md", 0644, "first"),
+					file("main.go", 0644, "package main"),
+				)),
+				layer(types.OCIUncompressedLayer, tarStream(
+					file("README.md", 0644, "second"),
+				)),
+			)
+
+			tempDir := GinkgoT().TempDir()
+			image, err := PullAndUnpack(ref, tempDir)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(os.ReadFile(filepath.Join(tempDir, "README.md"))).To(BeEquivalentTo("second"))
+			Expect(os.ReadFile(filepath.Join(tempDir, "main.go"))).To(BeEquivalentTo("package main"))
+
+			metadata, err := GetMetadata(image)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata.Files).To(BeEmpty())
+		})
+
+		It("should apply the limits to all layers together", func() {
+			ref := pushArtifact(
+				layer(types.OCILayer, tarStream(file("one", 0644, "1"))),
+				layer(types.OCILayer, tarStream(file("two", 0644, "2"))),
+			)
+
+			_, err := PullAndUnpackWithOptions(ref, GinkgoT().TempDir(), UnpackOptions{MaxFiles: 1})
+			Expect(err).To(MatchError(ContainSubstring("more than 1 files")))
+		})
+
+		It("should fail for an OCI artifact with layers that are no tar streams", func() {
+			ref := pushArtifact(
+				layer("application/vnd.example.sbom.v1+json", bytes.NewBufferString(`{}`)),
+			)
+
+			_, err := PullAndUnpack(ref, GinkgoT().TempDir())
+			Expect(err).To(MatchError(ContainSubstring(`unsupported media type "application/vnd.example.sbom.v1+json"`)))
+		})
+	})
+
+	Context("downloading tarballs", func() {
+		var (
+			tarballData []byte
+			checksum    string
+			serverURL   string
+			requests    int
+		)
+
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			_, err := io.Copy(gw, tarStream(
+				dir("src", 0755),
+				file("src/main.go", 0644, "package main"),
+			))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gw.Close()).To(Succeed())
+
+			tarballData = buf.Bytes()
+			checksum = fmt.Sprintf("sha256:%x", sha256.Sum256(tarballData))
+
+			requests = 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				switch r.URL.Path {
+				case "/source.tar.gz":
+					_, _ = w.Write(tarballData)
+
+				case "/private/source.tar.gz":
+					if r.Header.Get("Authorization") != "Bearer token" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					_, _ = w.Write(tarballData)
+
+				case "/source.tar":
+					_, _ = io.Copy(w, tarStream(file("plain", 0644, "uncompressed")))
+
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			DeferCleanup(server.Close)
+
+			serverURL = server.URL
+		})
+
+		It("should download and unpack a compressed tarball with a matching checksum", func() {
+			tempDir := GinkgoT().TempDir()
+			digest, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/source.tar.gz", checksum, tempDir, DefaultUnpackOptions(), DownloadOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digest).To(Equal(checksum))
+
+			Expect(os.ReadFile(filepath.Join(tempDir, "src", "main.go"))).To(BeEquivalentTo("package main"))
+		})
+
+		It("should download and unpack an uncompressed tarball", func() {
+			var data bytes.Buffer
+			_, err := io.Copy(&data, tarStream(file("plain", 0644, "uncompressed")))
+			Expect(err).ToNot(HaveOccurred())
+
+			tempDir := GinkgoT().TempDir()
+			_, err = DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/source.tar", fmt.Sprintf("sha256:%x", sha256.Sum256(data.Bytes())), tempDir, DefaultUnpackOptions(), DownloadOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(os.ReadFile(filepath.Join(tempDir, "plain"))).To(BeEquivalentTo("uncompressed"))
+		})
+
+		It("should not unpack a tarball that does not match the checksum", func() {
+			tempDir := GinkgoT().TempDir()
+			_, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/source.tar.gz", "sha256:"+strings.Repeat("0", 64), tempDir, DefaultUnpackOptions(), DownloadOptions{})
+			Expect(err).To(MatchError(ContainSubstring("does not match the checksum")))
+
+			Expect(filepath.Join(tempDir, "src")).ToNot(BeAnExistingFile())
+		})
+
+		It("should send the header and skip the verification without a checksum", func() {
+			tempDir := GinkgoT().TempDir()
+			digest, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/private/source.tar.gz", "", tempDir, DefaultUnpackOptions(), DownloadOptions{
+				Header: http.Header{"Authorization": []string{"Bearer token"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digest).To(Equal(checksum))
+
+			Expect(os.ReadFile(filepath.Join(tempDir, "src", "main.go"))).To(BeEquivalentTo("package main"))
+		})
+
+		It("should fail for an invalid checksum", func() {
+			_, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/source.tar.gz", "md5:abc", GinkgoT().TempDir(), DefaultUnpackOptions(), DownloadOptions{})
+			Expect(err).To(MatchError(`checksum "md5:abc" is not in the format sha256:<hex>`))
+		})
+
+		It("should fail if the tarball does not exist", func() {
+			_, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/does-not-exist.tar.gz", checksum, GinkgoT().TempDir(), DefaultUnpackOptions(), DownloadOptions{})
+			Expect(err).To(MatchError(ContainSubstring("HTTP status code 404")))
+		})
+
+		It("should download a tarball that has exactly the maximum size", func() {
+			_, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/source.tar.gz", checksum, GinkgoT().TempDir(), DefaultUnpackOptions(), DownloadOptions{
+				MaxSize: int64(len(tarballData)),
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail without a retry if the tarball is larger than the maximum size", func() {
+			tempDir := GinkgoT().TempDir()
+			_, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/source.tar.gz", checksum, tempDir, DefaultUnpackOptions(), DownloadOptions{
+				MaxSize:    int64(len(tarballData)) - 1,
+				Retries:    2,
+				RetryDelay: time.Millisecond,
+			})
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("file is larger than %d bytes", len(tarballData)-1))))
+			Expect(requests).To(Equal(1))
+
+			Expect(filepath.Join(tempDir, "src")).ToNot(BeAnExistingFile())
+		})
+
+		It("should limit the download to the maximum size of the unpack options", func() {
+			_, err := DownloadAndUnpack(context.TODO(), http.DefaultClient, serverURL+"/source.tar", "", GinkgoT().TempDir(), UnpackOptions{MaxSize: 100}, DownloadOptions{})
+			Expect(err).To(MatchError(ContainSubstring("file is larger than 100 bytes")))
+		})
+	})
+})
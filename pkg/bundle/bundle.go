@@ -7,8 +7,10 @@ package bundle
 import (
 	"archive/tar"
 	"bufio"
-	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/fs"
 	"os"
@@ -27,34 +29,130 @@ import (
 
 const shpIgnoreFilename = ".shpignore"
 
+// Layering defines how the content of the directory is split into the layers
+// of the bundle image
+type Layering string
+
+const (
+	// LayeringSingle stores the whole directory in one layer
+	LayeringSingle Layering = "single"
+
+	// LayeringTopLevelDirectory stores every top-level directory in its own layer,
+	// and the files of the top-level in another one
+	LayeringTopLevelDirectory Layering = "top-level-directory"
+)
+
+// maxDirectoryLayers is the maximum number of top-level directory layers, some
+// container registries refuse images with more than about a hundred layers
+const maxDirectoryLayers = 32
+
+// PackOptions define how the bundle image is created
+type PackOptions struct {
+	// Layering defines how the content is split into layers
+	Layering Layering
+}
+
+// DefaultPackOptions returns the options that PackAndPush uses
+func DefaultPackOptions() PackOptions {
+	return PackOptions{
+		Layering: LayeringTopLevelDirectory,
+	}
+}
+
 // PackAndPush a local directory as-is into a container image. See
 // remote.Option for optional options to the image push to the registry, for
 // example to provide the appropriate access credentials.
 func PackAndPush(ref name.Reference, directory string, options ...remote.Option) (name.Digest, error) {
-	r, err := Pack(directory)
+	return PackAndPushWithOptions(ref, directory, DefaultPackOptions(), options...)
+}
+
+// PackAndPushWithOptions is PackAndPush with options for the layers of the
+// image. The layers are reproducible, a layer that did not change since the
+// last push into the same repository is not uploaded again. The image contains
+// the metadata of the directory and the digests of all files, see GetMetadata.
+func PackAndPushWithOptions(ref name.Reference, directory string, packOptions PackOptions, options ...remote.Option) (name.Digest, error) {
+	patterns, err := ignorePatterns(directory)
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	matcher := ignoreMatcher(directory, patterns)
+
+	absDirectory, err := filepath.Abs(directory)
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	// only the name of the directory is stored, the parent path is specific to
+	// the machine that packed it and would disclose its local file system layout
+	metadata := Metadata{
+		Directory:      filepath.Base(absDirectory),
+		CommitSha:      commitSha(directory),
+		IgnorePatterns: patterns,
+		Files:          map[string]string{},
+	}
+
+	layers, err := layerContents(directory, matcher, packOptions.Layering)
 	if err != nil {
 		return name.Digest{}, err
 	}
 
-	// nolint:staticcheck
-	// tarball.LayerFromReader is deprecated, but we need to continue to use it because
-	// Pack do some custom work for us like ignoring files, dereferencing all symlinks and
-	// there is no support for doing this in the new recommended method.
-	bundleLayer, err := tarball.LayerFromReader(r)
+	// The layers are read several times, to calculate their digests and to upload
+	// them, therefore the compressed tar streams are spooled to temporary files
+	// instead of keeping them in memory, so that the memory usage does not depend
+	// on the size of the directory.
+	spoolDir, err := os.MkdirTemp("", "bundle-")
 	if err != nil {
 		return name.Digest{}, err
 	}
 
-	image, err := mutate.AppendLayers(empty.Image, bundleLayer)
+	defer os.RemoveAll(spoolDir)
+
+	var addendums []mutate.Addendum
+	for i, include := range layers {
+		spool := filepath.Join(spoolDir, fmt.Sprintf("layer-%d.tar.gz", i))
+
+		// the file times are set to the Unix epoch, so that the same directory
+		// content always results in the same layer
+		if err := packCompressed(spool, directory, matcher, include, metadata.Files, time.Unix(0, 0)); err != nil {
+			return name.Digest{}, err
+		}
+
+		layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return os.Open(spool)
+		})
+		if err != nil {
+			return name.Digest{}, err
+		}
+
+		addendums = append(addendums, mutate.Addendum{
+			Layer:   layer,
+			History: containerreg.History{Created: containerreg.Time{Time: time.Unix(0, 0)}},
+		})
+	}
+
+	image, err := mutate.Append(empty.Image, addendums...)
 	if err != nil {
 		return name.Digest{}, err
 	}
 
-	image, err = mutate.Time(image, time.Unix(0, 0))
+	image, err = mutate.CreatedAt(image, containerreg.Time{Time: time.Unix(0, 0)})
 	if err != nil {
 		return name.Digest{}, err
 	}
 
+	labels, err := metadata.labels()
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	image, err = mutate.Config(image, containerreg.Config{Labels: labels})
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	image = mutate.Annotations(image, metadata.annotations()).(containerreg.Image)
+
 	hash, err := image.Digest()
 	if err != nil {
 		return name.Digest{}, err
@@ -70,10 +168,87 @@ func PackAndPush(ref name.Reference, directory string, options ...remote.Option)
 	))
 }
 
-// PullAndUnpack a container image layer content into a local directory. Analog
+// layerContents returns the top-level entries of the directory for every layer,
+// a nil set contains the whole directory. The first layer contains the directory
+// itself and its files, and the top-level directories are distributed across the
+// other layers by the hash of their name when there are too many of them, so that
+// a new directory does not change the content of the existing layers.
+func layerContents(directory string, matcher gitignore.Matcher, layering Layering) ([]map[string]bool, error) {
+	switch layering {
+	case LayeringSingle:
+		return []map[string]bool{nil}, nil
+
+	case LayeringTopLevelDirectory:
+		break
+
+	default:
+		return nil, fmt.Errorf("unsupported layering %q", layering)
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files = map[string]bool{".": true}
+		dirs  []string
+	)
+
+	for _, entry := range entries {
+		switch {
+		case !entry.IsDir():
+			files[entry.Name()] = true
+
+		case !matcher.Match(splitPath(filepath.Join(directory, entry.Name())), true):
+			dirs = append(dirs, entry.Name())
+		}
+	}
+
+	var layers = []map[string]bool{files}
+	if len(dirs) <= maxDirectoryLayers {
+		for _, dir := range dirs {
+			layers = append(layers, map[string]bool{dir: true})
+		}
+
+		return layers, nil
+	}
+
+	var buckets = make([]map[string]bool, maxDirectoryLayers)
+	for _, dir := range dirs {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(dir))
+
+		bucket := hash.Sum32() % maxDirectoryLayers
+		if buckets[bucket] == nil {
+			buckets[bucket] = map[string]bool{}
+		}
+
+		buckets[bucket][dir] = true
+	}
+
+	for _, bucket := range buckets {
+		if bucket != nil {
+			layers = append(layers, bucket)
+		}
+	}
+
+	return layers, nil
+}
+
+// PullAndUnpack a container image layer content into a local directory. The
+// content of all layers is unpacked, like the file system of a container. The
+// reference can also point at an OCI artifact with tar or compressed tar layers,
+// which are unpacked in the order of the layers. Analog
 // to the bundle.PackAndPush function, optional remote.Option can be used to
 // configure settings for the image pull, i.e. access credentials.
 func PullAndUnpack(ref name.Reference, targetPath string, options ...remote.Option) (containerreg.Image, error) {
+	return PullAndUnpackWithOptions(ref, targetPath, DefaultUnpackOptions(), options...)
+}
+
+// PullAndUnpackWithOptions is PullAndUnpack with options for the permissions,
+// the ownership, and the limits of the unpacked files.
+func PullAndUnpackWithOptions(ref name.Reference, targetPath string, unpackOptions UnpackOptions, options ...remote.Option) (containerreg.Image, error) {
 	desc, err := remote.Get(ref, options...)
 	if err != nil {
 		return nil, err
@@ -84,10 +259,24 @@ func PullAndUnpack(ref name.Reference, targetPath string, options ...remote.Opti
 		return nil, err
 	}
 
+	manifest, err := image.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	// the layers of OCI artifacts are not necessarily file system layers
+	if isArtifact(manifest) {
+		if err := unpackArtifact(image, targetPath, unpackOptions); err != nil {
+			return nil, err
+		}
+
+		return image, nil
+	}
+
 	rc := mutate.Extract(image)
 	defer rc.Close()
 
-	if err = Unpack(rc, targetPath); err != nil {
+	if err = UnpackWithOptions(rc, targetPath, unpackOptions); err != nil {
 		return nil, err
 	}
 
@@ -98,10 +287,97 @@ func PullAndUnpack(ref name.Reference, targetPath string, options ...remote.Opti
 // - storing all directories and regular files as-is,
 // - dereferencing all symlinks and storing the respective target,
 // - ignoring all files configured in .shpignore
-func Pack(directory string) (io.Reader, error) {
-	var split = func(path string) []string { return strings.Split(path, string(filepath.Separator)) }
+//
+// The tar stream is created while it is read, so that the directory is never
+// held in memory. The reader needs to be closed, which stops the packing in
+// case the tar stream is not read entirely.
+func Pack(directory string) (io.ReadCloser, error) {
+	patterns, err := ignorePatterns(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := ignoreMatcher(directory, patterns)
 
-	var write = func(w io.Writer, path string) error {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(pack(w, directory, matcher, nil, nil, time.Time{}))
+	}()
+
+	return r, nil
+}
+
+// packCompressed writes the gzip compressed tar stream of the top-level entries
+// of the directory into the file, with the given modification time for all files
+func packCompressed(path string, directory string, matcher gitignore.Matcher, include map[string]bool, digests map[string]string, modTime time.Time) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	bw := bufio.NewWriter(file)
+	gw := gzip.NewWriter(bw)
+	if err := pack(gw, directory, matcher, include, digests, modTime); err != nil {
+		return err
+	}
+
+	if err := gw.Close(); err != nil {
+		return err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// ignorePatterns returns the patterns configured in .shpignore
+func ignorePatterns(directory string) ([]string, error) {
+	// without a readable .shpignore file, no files are ignored
+	file, err := os.Open(filepath.Join(directory, shpIgnoreFilename))
+	if err != nil {
+		return nil, nil
+	}
+
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) != 0 && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+
+	return patterns, scanner.Err()
+}
+
+// ignoreMatcher returns the matcher for the patterns configured in .shpignore
+func ignoreMatcher(directory string, patterns []string) gitignore.Matcher {
+	var (
+		domain   = splitPath(directory)
+		matchers = make([]gitignore.Pattern, 0, len(patterns))
+	)
+
+	for _, pattern := range patterns {
+		matchers = append(matchers, gitignore.ParsePattern(pattern, domain))
+	}
+
+	return gitignore.NewMatcher(matchers)
+}
+
+func splitPath(path string) []string { return strings.Split(path, string(filepath.Separator)) }
+
+// pack writes the tar stream of the directory, if the set of included top-level
+// entries is not nil, it skips all others, where "." is the directory itself. If
+// the map of digests is not nil, it adds the digests of the written files. If
+// the modification time is not zero, it replaces the times of all files.
+func pack(w io.Writer, directory string, matcher gitignore.Matcher, include map[string]bool, digests map[string]string, modTime time.Time) error {
+	var write = func(w io.Writer, name string, path string) error {
 		file, err := os.Open(path)
 		if err != nil {
 			return err
@@ -109,8 +385,18 @@ func Pack(directory string) (io.Reader, error) {
 
 		defer file.Close()
 
-		_, err = io.Copy(w, file)
-		return err
+		if digests == nil {
+			_, err = io.Copy(w, file)
+			return err
+		}
+
+		hash := sha256.New()
+		if _, err = io.Copy(io.MultiWriter(w, hash), file); err != nil {
+			return err
+		}
+
+		digests[filepath.ToSlash(name)] = fmt.Sprintf("sha256:%x", hash.Sum(nil))
+		return nil
 	}
 
 	var followSymLink = func(path string) (string, os.FileInfo, error) {
@@ -130,30 +416,18 @@ func Pack(directory string) (io.Reader, error) {
 		return deref, info, err
 	}
 
-	var patterns []gitignore.Pattern
-	if file, err := os.Open(filepath.Join(directory, shpIgnoreFilename)); err == nil {
-		defer file.Close()
+	var tw = tar.NewWriter(w)
 
-		domain := split(directory)
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if len(line) != 0 && !strings.HasPrefix(line, "#") {
-				patterns = append(patterns, gitignore.ParsePattern(line, domain))
-			}
+	var writeHeader = func(header *tar.Header) error {
+		if !modTime.IsZero() {
+			header.ModTime = modTime
+			header.AccessTime = time.Time{}
+			header.ChangeTime = time.Time{}
 		}
 
-		if err := scanner.Err(); err != nil {
-			return nil, err
-		}
+		return tw.WriteHeader(header)
 	}
 
-	matcher := gitignore.NewMatcher(patterns)
-
-	var buf bytes.Buffer
-	var tw = tar.NewWriter(&buf)
-	defer tw.Close()
-
 	err := filepath.WalkDir(directory, func(path string, d fs.DirEntry, err error) error {
 		// Bail out on path errors
 		if err != nil {
@@ -161,7 +435,7 @@ func Pack(directory string) (io.Reader, error) {
 		}
 
 		// Skip files on the ignore list
-		if matcher.Match(split(path), d.IsDir()) {
+		if matcher.Match(splitPath(path), d.IsDir()) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
@@ -169,6 +443,22 @@ func Pack(directory string) (io.Reader, error) {
 			return nil
 		}
 
+		// Skip top-level entries that belong to another layer
+		if include != nil {
+			rel, err := filepath.Rel(directory, path)
+			if err != nil {
+				return err
+			}
+
+			if !include[splitPath(rel)[0]] {
+				if d.IsDir() && rel != "." {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+		}
+
 		info, err := d.Info()
 		if err != nil {
 			return err
@@ -186,14 +476,14 @@ func Pack(directory string) (io.Reader, error) {
 
 		switch {
 		case info.Mode().IsDir():
-			return tw.WriteHeader(header)
+			return writeHeader(header)
 
 		case info.Mode().IsRegular():
-			if err := tw.WriteHeader(header); err != nil {
+			if err := writeHeader(header); err != nil {
 				return err
 			}
 
-			return write(tw, path)
+			return write(tw, header.Name, path)
 
 		case info.Mode()&os.ModeSymlink == os.ModeSymlink:
 			deref, info, err := followSymLink(path)
@@ -211,66 +501,20 @@ func Pack(directory string) (io.Reader, error) {
 				return err
 			}
 
-			if err := tw.WriteHeader(header); err != nil {
+			if err := writeHeader(header); err != nil {
 				return err
 			}
 
-			return write(tw, deref)
+			return write(tw, header.Name, deref)
 
 		default:
 			return fmt.Errorf("unsupported file type: %s", path)
 		}
 	})
 
-	return &buf, err
-}
-
-// Unpack reads a tar stream and writes the content into the local file system
-// with all files and directories.
-func Unpack(in io.Reader, targetPath string) error {
-	var tr = tar.NewReader(in)
-	for {
-		header, err := tr.Next()
-		switch {
-		case err == io.EOF:
-			return nil
-
-		case err != nil:
-			return err
-
-		case header == nil:
-			continue
-		}
-
-		var target = filepath.Join(targetPath, header.Name)
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-				return err
-			}
-
-		case tar.TypeReg:
-			// Edge case in which that tarball did not have a directory entry
-			dir, _ := filepath.Split(target)
-			if err := os.MkdirAll(dir, os.FileMode(0755)); err != nil {
-				return err
-			}
-
-			file, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-
-			if _, err := io.Copy(file, tr); err != nil {
-				file.Close()
-				return err
-			}
-
-			file.Close()
-			os.Chtimes(target, header.AccessTime, header.ModTime)
-
-		default:
-			return fmt.Errorf("provided tarball contains unsupported file type, only directories and regular files are supported")
-		}
+	if err != nil {
+		return err
 	}
+
+	return tw.Close()
 }
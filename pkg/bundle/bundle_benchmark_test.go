@@ -0,0 +1,136 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/shipwright-io/build/pkg/bundle"
+)
+
+// syntheticTree creates a directory with the given number of sub-directories and files per
+// sub-directory, the files contain half random and half repeated data to be compressible
+func syntheticTree(b *testing.B, dirs int, files int, fileSize int) (string, int64) {
+	b.Helper()
+
+	var (
+		directory = b.TempDir()
+		random    = rand.New(rand.NewSource(42)) // #nosec G404 not used for security
+		data      = make([]byte, fileSize)
+		total     int64
+	)
+
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(directory, fmt.Sprintf("dir-%03d", d))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+
+		for f := 0; f < files; f++ {
+			random.Read(data[:fileSize/2])
+			for i := fileSize / 2; i < fileSize; i++ {
+				data[i] = byte(i % 7)
+			}
+
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%03d", f)), data, 0644); err != nil {
+				b.Fatal(err)
+			}
+
+			total += int64(fileSize)
+		}
+	}
+
+	return directory, total
+}
+
+func benchmarkPack(b *testing.B, dirs int, files int, fileSize int) {
+	directory, total := syntheticTree(b, dirs, files, fileSize)
+
+	b.SetBytes(total)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r, err := bundle.Pack(directory)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			b.Fatal(err)
+		}
+
+		r.Close()
+	}
+}
+
+// discardingRegistry accepts all uploads without storing them, so that the
+// memory of the registry does not show up in the benchmark results
+func discardingRegistry() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		switch {
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/blobs/uploads/"):
+			w.Header().Set("Location", r.URL.Path+"upload")
+			w.WriteHeader(http.StatusAccepted)
+
+		case r.Method == http.MethodPatch:
+			w.Header().Set("Location", r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+func benchmarkPackAndPush(b *testing.B, dirs int, files int, fileSize int) {
+	directory, total := syntheticTree(b, dirs, files, fileSize)
+
+	server := httptest.NewServer(discardingRegistry())
+	defer server.Close()
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s/benchmark/bundle:latest", strings.TrimPrefix(server.URL, "http://")))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(total)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := bundle.PackAndPush(ref, directory); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The allocated bytes per operation depend on the number of files, but not on their
+// size, because neither the tar stream nor the compressed layer is kept in memory.
+
+func BenchmarkPack_1MiB(b *testing.B)   { benchmarkPack(b, 4, 4, 64<<10) }
+func BenchmarkPack_64MiB(b *testing.B)  { benchmarkPack(b, 16, 16, 256<<10) }
+func BenchmarkPack_256MiB(b *testing.B) { benchmarkPack(b, 16, 16, 1<<20) }
+
+func BenchmarkPackAndPush_1MiB(b *testing.B)  { benchmarkPackAndPush(b, 4, 4, 64<<10) }
+func BenchmarkPackAndPush_64MiB(b *testing.B) { benchmarkPackAndPush(b, 16, 16, 256<<10) }
@@ -5,12 +5,22 @@
 package bundle_test
 
 import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
 	. "github.com/shipwright-io/build/pkg/bundle"
 )
 
@@ -20,6 +30,7 @@ var _ = Describe("Bundle", func() {
 			r, err := Pack("../../test/bundle")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(r).ToNot(BeNil())
+			defer r.Close()
 
 			tempDir, err := os.MkdirTemp("", "bundle")
 			Expect(err).ToNot(HaveOccurred())
@@ -35,5 +46,268 @@ var _ = Describe("Bundle", func() {
 			Expect(filepath.Join(tempDir, "somefile")).To(BeAnExistingFile())
 			Expect(filepath.Join(tempDir, "linktofile")).To(BeAnExistingFile())
 		})
+
+		It("should return the error of the packing when the tar stream is read", func() {
+			r, err := Pack("../../test/does-not-exist")
+			Expect(err).ToNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = io.ReadAll(r)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should stop packing if the tar stream is closed before it is read entirely", func() {
+			r, err := Pack("../../test/bundle")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = r.Read(make([]byte, 16))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Close()).To(Succeed())
+
+			_, err = r.Read(make([]byte, 16))
+			Expect(err).To(MatchError(io.ErrClosedPipe))
+		})
+	})
+
+	Context("pushing and pulling", func() {
+		var registryHost string
+
+		BeforeEach(func() {
+			server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			DeferCleanup(server.Close)
+
+			registryHost = strings.TrimPrefix(server.URL, "http://")
+		})
+
+		It("should push a compressed bundle image and pull it again", func() {
+			ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+			Expect(err).ToNot(HaveOccurred())
+
+			digest, err := PackAndPush(ref, "../../test/bundle")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digest.DigestStr()).To(HavePrefix("sha256:"))
+
+			tempDir := GinkgoT().TempDir()
+			image, err := PullAndUnpack(digest, tempDir)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the directory has no top-level directories besides the ignored one
+			layers, err := image.Layers()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(layers).To(HaveLen(1))
+			Expect(layers[0].MediaType()).To(Equal(types.DockerLayer))
+
+			Expect(filepath.Join(tempDir, "README.md")).To(BeAnExistingFile())
+			Expect(filepath.Join(tempDir, "linktofile")).To(BeAnExistingFile())
+			Expect(filepath.Join(tempDir, ".someToolDir", "config.json")).ToNot(BeAnExistingFile())
+		})
+
+		It("should create the same image for the same directory", func() {
+			ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+			Expect(err).ToNot(HaveOccurred())
+
+			first, err := PackAndPush(ref, "../../test/bundle")
+			Expect(err).ToNot(HaveOccurred())
+
+			second, err := PackAndPush(ref, "../../test/bundle")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(second).To(Equal(first))
+		})
+
+		It("should store the metadata and the file digests in the image", func() {
+			ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+			Expect(err).ToNot(HaveOccurred())
+
+			digest, err := PackAndPush(ref, "../../test/bundle")
+			Expect(err).ToNot(HaveOccurred())
+
+			tempDir := GinkgoT().TempDir()
+			image, err := PullAndUnpack(digest, tempDir)
+			Expect(err).ToNot(HaveOccurred())
+
+			metadata, err := GetMetadata(image)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(metadata.Directory).To(Equal("bundle"))
+
+			// the directory is part of the Git worktree of this repository
+			Expect(metadata.CommitSha).To(MatchRegexp("^[0-9a-f]{40}$"))
+			Expect(metadata.IgnorePatterns).To(Equal([]string{".someToolDir"}))
+			Expect(metadata.Files).To(HaveKey("README.md"))
+			Expect(metadata.Files).To(HaveKey("linktofile"))
+			Expect(metadata.Files).ToNot(HaveKey(".someToolDir/config.json"))
+			Expect(metadata.Files["somefile"]).To(HavePrefix("sha256:"))
+
+			Expect(metadata.VerifyFiles(tempDir)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(tempDir, "somefile"), []byte("changed"), 0644)).To(Succeed())
+			Expect(metadata.VerifyFiles(tempDir)).To(MatchError(ContainSubstring("file somefile does not match the digest")))
+
+			Expect(os.Remove(filepath.Join(tempDir, "README.md"))).To(Succeed())
+			Expect(metadata.VerifyFiles(tempDir)).To(MatchError(ContainSubstring("failed to verify file README.md")))
+		})
+
+		Context("a directory with top-level directories", func() {
+			var directory string
+
+			BeforeEach(func() {
+				directory = GinkgoT().TempDir()
+				for _, dir := range []string{"cmd", "pkg", "docs", ".git"} {
+					Expect(os.MkdirAll(filepath.Join(directory, dir, "sub"), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(directory, dir, "sub", "file"), []byte(dir), 0644)).To(Succeed())
+				}
+
+				Expect(os.WriteFile(filepath.Join(directory, "go.mod"), []byte("module test"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(directory, ".shpignore"), []byte(".git"), 0644)).To(Succeed())
+			})
+
+			It("should push a layer for every top-level directory and the top-level files", func() {
+				ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+				Expect(err).ToNot(HaveOccurred())
+
+				digest, err := PackAndPush(ref, directory)
+				Expect(err).ToNot(HaveOccurred())
+
+				tempDir := GinkgoT().TempDir()
+				image, err := PullAndUnpack(digest, tempDir)
+				Expect(err).ToNot(HaveOccurred())
+
+				layers, err := image.Layers()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(layers).To(HaveLen(4))
+
+				Expect(filepath.Join(tempDir, "go.mod")).To(BeAnExistingFile())
+				for _, dir := range []string{"cmd", "pkg", "docs"} {
+					Expect(os.ReadFile(filepath.Join(tempDir, dir, "sub", "file"))).To(BeEquivalentTo(dir))
+				}
+
+				Expect(filepath.Join(tempDir, ".git")).ToNot(BeAnExistingFile())
+			})
+
+			It("should store the file digests of all layers and no commit outside of a Git worktree", func() {
+				ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+				Expect(err).ToNot(HaveOccurred())
+
+				digest, err := PackAndPush(ref, directory)
+				Expect(err).ToNot(HaveOccurred())
+
+				tempDir := GinkgoT().TempDir()
+				image, err := PullAndUnpack(digest, tempDir)
+				Expect(err).ToNot(HaveOccurred())
+
+				metadata, err := GetMetadata(image)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadata.CommitSha).To(BeEmpty())
+				Expect(metadata.Files).To(HaveLen(5))
+				Expect(metadata.Files).To(HaveKey("pkg/sub/file"))
+				Expect(metadata.VerifyFiles(tempDir)).To(Succeed())
+			})
+
+			It("should push a single layer if requested", func() {
+				ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+				Expect(err).ToNot(HaveOccurred())
+
+				digest, err := PackAndPushWithOptions(ref, directory, PackOptions{Layering: LayeringSingle})
+				Expect(err).ToNot(HaveOccurred())
+
+				tempDir := GinkgoT().TempDir()
+				image, err := PullAndUnpack(digest, tempDir)
+				Expect(err).ToNot(HaveOccurred())
+
+				layers, err := image.Layers()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(layers).To(HaveLen(1))
+
+				Expect(os.ReadFile(filepath.Join(tempDir, "pkg", "sub", "file"))).To(BeEquivalentTo("pkg"))
+			})
+
+			It("should fail for an unsupported layering", func() {
+				ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = PackAndPushWithOptions(ref, directory, PackOptions{Layering: "chunks"})
+				Expect(err).To(MatchError(`unsupported layering "chunks"`))
+			})
+
+			It("should distribute many top-level directories across a limited number of layers", func() {
+				for i := 0; i < 100; i++ {
+					Expect(os.Mkdir(filepath.Join(directory, fmt.Sprintf("dir-%03d", i)), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(directory, fmt.Sprintf("dir-%03d", i), "file"), []byte("content"), 0644)).To(Succeed())
+				}
+
+				ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+				Expect(err).ToNot(HaveOccurred())
+
+				digest, err := PackAndPush(ref, directory)
+				Expect(err).ToNot(HaveOccurred())
+
+				tempDir := GinkgoT().TempDir()
+				image, err := PullAndUnpack(digest, tempDir)
+				Expect(err).ToNot(HaveOccurred())
+
+				layers, err := image.Layers()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(layers)).To(BeNumerically("<=", 33))
+
+				for i := 0; i < 100; i++ {
+					Expect(filepath.Join(tempDir, fmt.Sprintf("dir-%03d", i), "file")).To(BeAnExistingFile())
+				}
+			})
+		})
+	})
+
+	Context("pushing changes", func() {
+		var (
+			registryHost string
+			uploads      int
+		)
+
+		BeforeEach(func() {
+			handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/blobs/uploads/") {
+					uploads++
+				}
+
+				handler.ServeHTTP(w, r)
+			}))
+			DeferCleanup(server.Close)
+
+			registryHost = strings.TrimPrefix(server.URL, "http://")
+			uploads = 0
+		})
+
+		It("should only upload the layers that changed", func() {
+			directory := GinkgoT().TempDir()
+			for _, dir := range []string{"cmd", "pkg", "docs"} {
+				Expect(os.Mkdir(filepath.Join(directory, dir), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(directory, dir, "file"), []byte(dir), 0644)).To(Succeed())
+			}
+
+			ref, err := name.ParseReference(fmt.Sprintf("%s/test/bundle:latest", registryHost))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = PackAndPush(ref, directory)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the top-level files layer, three directory layers, and the config
+			Expect(uploads).To(Equal(5))
+
+			Expect(os.WriteFile(filepath.Join(directory, "pkg", "file"), []byte("changed"), 0644)).To(Succeed())
+			uploads = 0
+
+			digest, err := PackAndPush(ref, directory)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the changed directory layer, and the config
+			Expect(uploads).To(Equal(2))
+
+			tempDir := GinkgoT().TempDir()
+			_, err = PullAndUnpack(digest, tempDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.ReadFile(filepath.Join(tempDir, "pkg", "file"))).To(BeEquivalentTo("changed"))
+			Expect(os.ReadFile(filepath.Join(tempDir, "cmd", "file"))).To(BeEquivalentTo("cmd"))
+		})
 	})
 })
@@ -0,0 +1,164 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	containerreg "github.com/google/go-containerregistry/pkg/v1"
+)
+
+const (
+	// the annotations of the image manifest
+	annotationDirectory      = "shipwright.io/bundle.directory"
+	annotationCommitSha      = "shipwright.io/bundle.commit-sha"
+	annotationIgnorePatterns = "shipwright.io/bundle.ignore-patterns"
+
+	// the label of the image config, the file index can be too large for the manifest
+	labelFiles = "shipwright.io/bundle.files"
+)
+
+// Metadata describes the origin and the content of a bundle image
+type Metadata struct {
+	// Directory is the name of the directory that was packed, without its parent path
+	Directory string
+
+	// CommitSha is the commit that is checked out if the directory is in a Git worktree
+	CommitSha string
+
+	// IgnorePatterns are the patterns of the .shpignore file that were applied
+	IgnorePatterns []string
+
+	// Files contains the digests of all regular files by their slash-separated path
+	Files map[string]string
+}
+
+// GetMetadata returns the metadata that PackAndPush stored in the bundle image,
+// the fields are empty for bundle images that were created without metadata
+func GetMetadata(image containerreg.Image) (Metadata, error) {
+	var metadata Metadata
+
+	manifest, err := image.Manifest()
+	if err != nil {
+		return metadata, err
+	}
+
+	metadata.Directory = manifest.Annotations[annotationDirectory]
+	metadata.CommitSha = manifest.Annotations[annotationCommitSha]
+	if value := manifest.Annotations[annotationIgnorePatterns]; value != "" {
+		metadata.IgnorePatterns = strings.Split(value, "\n")
+	}
+
+	// the configuration of OCI artifacts is not an image configuration
+	if isArtifact(manifest) {
+		return metadata, nil
+	}
+
+	config, err := image.ConfigFile()
+	if err != nil {
+		return metadata, err
+	}
+
+	if value := config.Config.Labels[labelFiles]; value != "" {
+		if err := json.Unmarshal([]byte(value), &metadata.Files); err != nil {
+			return metadata, fmt.Errorf("failed to parse the file index of the bundle image: %w", err)
+		}
+	}
+
+	return metadata, nil
+}
+
+// VerifyFiles checks that all files of the index exist in the target directory
+// with the same content, files that are not in the index are not checked
+func (m Metadata) VerifyFiles(targetPath string) error {
+	var paths []string
+	for path := range m.Files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+	for _, path := range paths {
+		target, err := joinInside(targetPath, path)
+		if err != nil {
+			return err
+		}
+
+		digest, err := fileDigest(target)
+		if err != nil {
+			return fmt.Errorf("failed to verify file %s: %w", path, err)
+		}
+
+		if digest != m.Files[path] {
+			return fmt.Errorf("file %s does not match the digest in the bundle image, expected %s, got %s", path, m.Files[path], digest)
+		}
+	}
+
+	return nil
+}
+
+func (m Metadata) annotations() map[string]string {
+	var annotations = map[string]string{
+		annotationDirectory: m.Directory,
+	}
+
+	if m.CommitSha != "" {
+		annotations[annotationCommitSha] = m.CommitSha
+	}
+
+	if len(m.IgnorePatterns) > 0 {
+		annotations[annotationIgnorePatterns] = strings.Join(m.IgnorePatterns, "\n")
+	}
+
+	return annotations
+}
+
+func (m Metadata) labels() (map[string]string, error) {
+	// the keys of the map are sorted, so that the label is reproducible
+	files, err := json.Marshal(m.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{labelFiles: string(files)}, nil
+}
+
+// commitSha returns the commit that is checked out in the Git worktree that
+// contains the directory, or an empty string if there is none
+func commitSha(directory string) string {
+	repository, err := git.PlainOpenWithOptions(directory, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return ""
+	}
+
+	return head.Hash().String()
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
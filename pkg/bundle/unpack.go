@@ -0,0 +1,372 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ownership is the policy for the owner of the unpacked files and directories
+type Ownership string
+
+const (
+	// OwnershipCurrentUser keeps the files owned by the user who unpacks them
+	OwnershipCurrentUser Ownership = "current"
+
+	// OwnershipPreserve applies the user and group IDs of the tar stream, which
+	// usually requires to run as root
+	OwnershipPreserve Ownership = "preserve"
+
+	// OwnershipFixed applies the user and group ID of the unpack options
+	OwnershipFixed Ownership = "fixed"
+)
+
+// UnpackOptions define the permissions, the ownership and the limits of the unpacked files
+type UnpackOptions struct {
+	// Umask contains the permission bits to remove from all files and directories,
+	// the setuid, setgid and sticky bits are never applied
+	Umask os.FileMode
+
+	// Ownership is the policy for the owner of the files and directories
+	Ownership Ownership
+
+	// UID and GID are the owner of the files and directories for OwnershipFixed
+	UID int
+	GID int
+
+	// MaxSize is the maximum total size of all files in bytes, zero means no limit
+	MaxSize int64
+
+	// MaxFiles is the maximum number of files, directories and symlinks, zero means no limit
+	MaxFiles int
+}
+
+// DefaultUnpackOptions returns the options that Unpack uses
+func DefaultUnpackOptions() UnpackOptions {
+	return UnpackOptions{
+		Umask:     0022,
+		Ownership: OwnershipCurrentUser,
+	}
+}
+
+// Unpack reads a tar stream and writes the content into the local file system
+// with all files, directories and symlinks, using the default options.
+func Unpack(in io.Reader, targetPath string) error {
+	return UnpackWithOptions(in, targetPath, DefaultUnpackOptions())
+}
+
+// UnpackWithOptions reads a tar stream and writes the content into the local file system
+// with all files, directories and symlinks. It refuses entries and symlinks that point
+// outside of the target directory, and fails as soon as one of the limits is exceeded.
+func UnpackWithOptions(in io.Reader, targetPath string, options UnpackOptions) error {
+	u, err := newUnpacker(targetPath, options)
+	if err != nil {
+		return err
+	}
+
+	if err := u.unpack(in); err != nil {
+		return err
+	}
+
+	return u.finish()
+}
+
+// unpacker writes the content of one or more tar streams into the target
+// directory, the limits apply to all tar streams together
+type unpacker struct {
+	root     string
+	options  UnpackOptions
+	files    int
+	size     int64
+	dirs     map[string]*tar.Header
+	symlinks map[string]string
+}
+
+func newUnpacker(targetPath string, options UnpackOptions) (*unpacker, error) {
+	if err := os.MkdirAll(targetPath, 0755&^options.Umask); err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.EvalSymlinks(targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &unpacker{
+		root:     root,
+		options:  options,
+		dirs:     map[string]*tar.Header{},
+		symlinks: map[string]string{},
+	}, nil
+}
+
+// unpack writes the entries of the tar stream, later entries replace earlier
+// ones of the same name, also the ones of previous tar streams
+func (u *unpacker) unpack(in io.Reader) error {
+	tr := tar.NewReader(in)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := u.unpackEntry(tr, header); err != nil {
+			return err
+		}
+	}
+}
+
+func (u *unpacker) unpackEntry(tr *tar.Reader, header *tar.Header) error {
+	u.files++
+	if u.options.MaxFiles > 0 && u.files > u.options.MaxFiles {
+		return fmt.Errorf("provided tarball contains more than %d files, which is the limit", u.options.MaxFiles)
+	}
+
+	target, err := joinInside(u.root, header.Name)
+	if err != nil {
+		return err
+	}
+
+	// the target directory itself is owned by the caller
+	if target == u.root {
+		return nil
+	}
+
+	if err := mkdirInside(u.root, filepath.Dir(target), u.options.Umask); err != nil {
+		return err
+	}
+
+	// a later entry replaces an earlier one of the same name
+	delete(u.dirs, target)
+	delete(u.symlinks, target)
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if info, err := os.Lstat(target); err == nil && info.IsDir() {
+			u.dirs[target] = header
+			break
+		}
+
+		if err := removeExisting(target); err != nil {
+			return err
+		}
+
+		// the directory stays writable until all entries are unpacked
+		if err := os.Mkdir(target, 0700); err != nil {
+			return err
+		}
+
+		u.dirs[target] = header
+
+	case tar.TypeReg:
+		u.size += header.Size
+		if u.options.MaxSize > 0 && u.size > u.options.MaxSize {
+			return fmt.Errorf("provided tarball contains more than %d bytes, which is the limit", u.options.MaxSize)
+		}
+
+		if err := removeExisting(target); err != nil {
+			return err
+		}
+
+		if err := writeFile(target, tr); err != nil {
+			return err
+		}
+
+		if err := os.Chmod(target, os.FileMode(header.Mode).Perm()&^u.options.Umask); err != nil {
+			return err
+		}
+
+		if err := os.Chtimes(target, header.AccessTime, header.ModTime); err != nil {
+			return err
+		}
+
+	case tar.TypeSymlink:
+		if err := removeExisting(target); err != nil {
+			return err
+		}
+
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return err
+		}
+
+		u.symlinks[target] = header.Linkname
+
+	default:
+		return fmt.Errorf("provided tarball contains unsupported file type, only directories, regular files and symlinks are supported")
+	}
+
+	return chown(target, header, u.options)
+}
+
+// finish checks the symlinks and applies the permissions of the directories
+// once all tar streams are unpacked
+func (u *unpacker) finish() error {
+	// symlinks can point at other symlinks, therefore they are checked once all exist
+	var outside []string
+	for link, linkname := range u.symlinks {
+		// the link target is not cleaned, parent directories that follow a symlink
+		// have to be resolved like the operating system does
+		if !filepath.IsAbs(linkname) {
+			linkname = filepath.Dir(link) + string(filepath.Separator) + linkname
+		}
+
+		resolved, err := resolvePath(linkname)
+		if err != nil {
+			return err
+		}
+
+		if !isInside(u.root, resolved) {
+			outside = append(outside, link)
+		}
+	}
+
+	// the symlinks that point outside are removed, so that nobody follows them after the failure
+	if len(outside) > 0 {
+		sort.Strings(outside)
+		for _, link := range outside {
+			if err := removeExisting(link); err != nil {
+				return err
+			}
+		}
+
+		return fmt.Errorf("provided tarball contains the symlink %s that points outside of the target directory", strings.TrimPrefix(outside[0], u.root+string(filepath.Separator)))
+	}
+
+	// the permissions of the directories are applied last, starting with the deepest
+	// one, so that also read-only directories could be filled with their entries
+	var paths []string
+	for dir := range u.dirs {
+		paths = append(paths, dir)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	for _, dir := range paths {
+		if err := os.Chmod(dir, os.FileMode(u.dirs[dir].Mode).Perm()&^u.options.Umask); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// joinInside returns the path of the tar entry in the root directory, it refuses
+// absolute names and names that point at a parent directory of the root directory
+func joinInside(root string, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("provided tarball contains the entry %s with an absolute path", name)
+	}
+
+	cleaned := filepath.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("provided tarball contains the entry %s that points outside of the target directory", name)
+	}
+
+	return filepath.Join(root, cleaned), nil
+}
+
+// mkdirInside creates the directory and its parents, the directory must not
+// be reached through a symlink that points outside of the root directory
+func mkdirInside(root string, dir string, umask os.FileMode) error {
+	for i := 0; i < 2; i++ {
+		resolved, err := resolvePath(dir)
+		if err != nil {
+			return err
+		}
+
+		if !isInside(root, resolved) {
+			return fmt.Errorf("provided tarball contains an entry in %s, which points outside of the target directory", strings.TrimPrefix(dir, root+string(filepath.Separator)))
+		}
+
+		// check the created directory again, in case the creation followed a symlink
+		if i == 0 {
+			if err := os.MkdirAll(dir, 0755&^umask); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolvePath follows the symlinks of the path like the operating system does, the components
+// that do not exist yet are appended as they are, which keeps a trailing parent directory
+func resolvePath(path string) (string, error) {
+	var missing []string
+	for current := path; ; {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+
+		// the last component is removed without cleaning the path, which would
+		// remove parent directory components that follow a symlink
+		index := strings.LastIndex(current, string(filepath.Separator))
+		if !os.IsNotExist(err) || index <= 0 {
+			return "", err
+		}
+
+		missing = append([]string{current[index+1:]}, missing...)
+		current = current[:index]
+	}
+}
+
+// isInside returns true if the path is the root directory or one of its entries
+func isInside(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeExisting removes a file or symlink of an earlier entry of the same
+// name, so that the new entry does not follow the symlink
+func removeExisting(target string) error {
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func writeFile(target string, r io.Reader) error {
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func chown(target string, header *tar.Header, options UnpackOptions) error {
+	switch options.Ownership {
+	case OwnershipPreserve:
+		return os.Lchown(target, header.Uid, header.Gid)
+
+	case OwnershipFixed:
+		return os.Lchown(target, options.UID, options.GID)
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shipwright-io/build/pkg/bundle"
+)
+
+type entry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+func file(name string, mode int64, content string) entry {
+	return entry{name: name, typeflag: tar.TypeReg, mode: mode, content: content}
+}
+
+func dir(name string, mode int64) entry {
+	return entry{name: name, typeflag: tar.TypeDir, mode: mode}
+}
+
+func symlink(name string, linkname string) entry {
+	return entry{name: name, typeflag: tar.TypeSymlink, mode: 0777, linkname: linkname}
+}
+
+func tarStream(entries ...entry) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		Expect(tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     e.mode,
+			Size:     int64(len(e.content)),
+			Linkname: e.linkname,
+			Uid:      os.Getuid(),
+			Gid:      os.Getgid(),
+		})).To(Succeed())
+
+		_, err := tw.Write([]byte(e.content))
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Expect(tw.Close()).To(Succeed())
+	return &buf
+}
+
+var _ = Describe("Unpack", func() {
+	var parentDir, targetDir string
+
+	BeforeEach(func() {
+		parentDir = GinkgoT().TempDir()
+		targetDir = filepath.Join(parentDir, "target")
+	})
+
+	Context("entries that point outside of the target directory", func() {
+		It("should refuse entries with a parent directory path", func() {
+			err := Unpack(tarStream(file("../evil", 0644, "evil")), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("outside of the target directory")))
+			Expect(filepath.Join(parentDir, "evil")).ToNot(BeAnExistingFile())
+		})
+
+		It("should refuse entries with a parent directory path in the middle", func() {
+			err := Unpack(tarStream(file("a/../../evil", 0644, "evil")), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("outside of the target directory")))
+			Expect(filepath.Join(parentDir, "evil")).ToNot(BeAnExistingFile())
+		})
+
+		It("should refuse entries with an absolute path", func() {
+			err := Unpack(tarStream(file("/tmp/evil", 0644, "evil")), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("absolute path")))
+		})
+
+		It("should refuse to write through a symlink that points outside", func() {
+			err := Unpack(tarStream(
+				symlink("link", ".."),
+				file("link/evil", 0644, "evil"),
+			), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("outside of the target directory")))
+			Expect(filepath.Join(parentDir, "evil")).ToNot(BeAnExistingFile())
+		})
+
+		It("should replace a symlink instead of writing through it", func() {
+			Expect(os.WriteFile(filepath.Join(parentDir, "victim"), []byte("original"), 0644)).To(Succeed())
+
+			err := Unpack(tarStream(
+				symlink("file", "../victim"),
+				file("file", 0644, "evil"),
+			), targetDir)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(os.ReadFile(filepath.Join(parentDir, "victim"))).To(BeEquivalentTo("original"))
+			Expect(os.ReadFile(filepath.Join(targetDir, "file"))).To(BeEquivalentTo("evil"))
+		})
+
+		It("should refuse symlinks with a relative target outside", func() {
+			err := Unpack(tarStream(symlink("link", "../../etc/passwd")), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("symlink link")))
+		})
+
+		It("should remove the symlinks that point outside when it refuses them", func() {
+			err := Unpack(tarStream(
+				symlink("inside", "."),
+				symlink("link", "/etc/passwd"),
+			), targetDir)
+			Expect(err).To(HaveOccurred())
+
+			_, err = os.Lstat(filepath.Join(targetDir, "link"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+			Expect(os.Readlink(filepath.Join(targetDir, "inside"))).To(Equal("."))
+		})
+
+		It("should refuse symlinks with an absolute target outside", func() {
+			err := Unpack(tarStream(symlink("link", "/etc/passwd")), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("symlink link")))
+		})
+
+		It("should refuse symlinks that point outside through another symlink", func() {
+			err := Unpack(tarStream(
+				symlink("self", "."),
+				symlink("link", "self/.."),
+			), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("symlink link")))
+		})
+
+		It("should refuse dangling symlinks that point outside through another symlink", func() {
+			err := Unpack(tarStream(
+				symlink("self", "."),
+				symlink("link", "self/../does-not-exist"),
+			), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("symlink link")))
+		})
+	})
+
+	Context("symlinks that point inside of the target directory", func() {
+		It("should unpack symlinks to files and directories", func() {
+			Expect(Unpack(tarStream(
+				dir("a", 0755),
+				file("a/file", 0644, "content"),
+				symlink("b/link-to-file", "../a/file"),
+				symlink("link-to-dir", "a"),
+				symlink("dangling", "a/does-not-exist"),
+			), targetDir)).To(Succeed())
+
+			Expect(os.ReadFile(filepath.Join(targetDir, "b", "link-to-file"))).To(BeEquivalentTo("content"))
+			Expect(os.ReadFile(filepath.Join(targetDir, "link-to-dir", "file"))).To(BeEquivalentTo("content"))
+
+			linkname, err := os.Readlink(filepath.Join(targetDir, "dangling"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(linkname).To(Equal("a/does-not-exist"))
+		})
+	})
+
+	Context("permissions and ownership", func() {
+		It("should apply the umask and drop special permission bits", func() {
+			Expect(UnpackWithOptions(tarStream(
+				dir("dir", 0777),
+				file("dir/script", 04777, "#!/bin/sh"),
+			), targetDir, UnpackOptions{Umask: 0027})).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(targetDir, "dir"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0750)))
+
+			info, err = os.Stat(filepath.Join(targetDir, "dir", "script"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.FileMode(0750)))
+		})
+
+		It("should fill read-only directories", func() {
+			Expect(Unpack(tarStream(
+				dir("readonly", 0555),
+				file("readonly/file", 0444, "content"),
+			), targetDir)).To(Succeed())
+			DeferCleanup(os.Chmod, filepath.Join(targetDir, "readonly"), os.FileMode(0755))
+
+			info, err := os.Stat(filepath.Join(targetDir, "readonly"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0555)))
+			Expect(os.ReadFile(filepath.Join(targetDir, "readonly", "file"))).To(BeEquivalentTo("content"))
+		})
+
+		It("should apply a fixed owner", func() {
+			Expect(UnpackWithOptions(tarStream(
+				file("file", 0644, "content"),
+			), targetDir, UnpackOptions{Ownership: OwnershipFixed, UID: os.Getuid(), GID: os.Getgid()})).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(targetDir, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Sys().(*syscall.Stat_t).Uid).To(BeEquivalentTo(os.Getuid()))
+			Expect(info.Sys().(*syscall.Stat_t).Gid).To(BeEquivalentTo(os.Getgid()))
+		})
+
+		It("should preserve the owner of the tar stream", func() {
+			Expect(UnpackWithOptions(tarStream(
+				file("file", 0644, "content"),
+			), targetDir, UnpackOptions{Ownership: OwnershipPreserve})).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(targetDir, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Sys().(*syscall.Stat_t).Uid).To(BeEquivalentTo(os.Getuid()))
+		})
+	})
+
+	Context("limits", func() {
+		It("should fail if the tar stream contains too many files", func() {
+			err := UnpackWithOptions(tarStream(
+				file("one", 0644, "1"),
+				file("two", 0644, "2"),
+				file("three", 0644, "3"),
+			), targetDir, UnpackOptions{MaxFiles: 2})
+			Expect(err).To(MatchError(ContainSubstring("more than 2 files")))
+			Expect(filepath.Join(targetDir, "three")).ToNot(BeAnExistingFile())
+		})
+
+		It("should fail if the files in the tar stream are too large", func() {
+			err := UnpackWithOptions(tarStream(
+				file("one", 0644, "12345678"),
+				file("two", 0644, "12345678"),
+			), targetDir, UnpackOptions{MaxSize: 10})
+			Expect(err).To(MatchError(ContainSubstring("more than 10 bytes")))
+			Expect(filepath.Join(targetDir, "two")).ToNot(BeAnExistingFile())
+		})
+
+		It("should unpack a tar stream within the limits", func() {
+			Expect(UnpackWithOptions(tarStream(
+				file("one", 0644, "12345678"),
+				file("two", 0644, "12"),
+			), targetDir, UnpackOptions{MaxSize: 10, MaxFiles: 2})).To(Succeed())
+		})
+	})
+})
@@ -6,6 +6,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -23,9 +24,6 @@ const (
 	// E.g. if 5 seconds is wanted, the CTX_TIMEOUT=5
 	contextTimeoutEnvVar = "CTX_TIMEOUT"
 
-	remoteArtifactsDefaultImage = "quay.io/quay/busybox:latest"
-	remoteArtifactsEnvVar       = "REMOTE_ARTIFACTS_CONTAINER_IMAGE"
-
 	// the Git image is built using ko which can replace environment variable values in the deployment, so once we decide to move
 	// from environment variables to a ConfigMap, then we should move the container template, but retain the environment variable
 	// (or make it an argument like Tekton)
@@ -42,6 +40,15 @@ const (
 	bundleImageEnvVar             = "BUNDLE_CONTAINER_IMAGE"
 	bundleContainerTemplateEnvVar = "BUNDLE_CONTAINER_TEMPLATE"
 
+	// the HTTP image that downloads the files of HTTP sources is also created by ko
+	httpDefaultImage            = "ghcr.io/shipwright-io/build/http:latest"
+	httpImageEnvVar             = "HTTP_CONTAINER_IMAGE"
+	httpContainerTemplateEnvVar = "HTTP_CONTAINER_TEMPLATE"
+
+	// the deprecated environment variable for the image of the remote artifacts step, it is
+	// ignored because the image does not contain the http command
+	remoteArtifactsEnvVar = "REMOTE_ARTIFACTS_CONTAINER_IMAGE"
+
 	// environment variable to hold waiter's container image, created by ko
 	waiterDefaultImage            = "ghcr.io/shipwright-io/build/waiter:latest"
 	waiterImageEnvVar             = "WAITER_CONTAINER_IMAGE"
@@ -77,6 +84,34 @@ const (
 
 	// environment variable for the Git rewrite setting
 	useGitRewriteRule = "GIT_ENABLE_REWRITE_RULE"
+
+	// environment variable to enable the resolution of step image tags to digests
+	pinStepImageDigests = "PIN_STEP_IMAGE_DIGESTS"
+
+	// environment variables to restrict build strategies that require elevated privileges
+	restrictPrivilegedStrategies   = "RESTRICT_PRIVILEGED_STRATEGIES"
+	privilegedStrategiesNamespaces = "PRIVILEGED_STRATEGIES_NAMESPACES"
+
+	// environment variables to obtain short-lived registry credentials from the ServiceAccount token
+	registryTokenAudience         = "REGISTRY_TOKEN_AUDIENCE"
+	registryTokenExchangeEndpoint = "REGISTRY_TOKEN_EXCHANGE_ENDPOINT"
+	registryTokenExchangeAudience = "REGISTRY_TOKEN_EXCHANGE_AUDIENCE"
+	registryTokenUsername         = "REGISTRY_TOKEN_USERNAME"
+
+	// environment variables for the certificate authority bundle and the proxy settings of the steps that
+	// fetch the source code and push the image
+	caBundleConfigMapKeyDefault = "ca-bundle.crt"
+	caBundleConfigMapName       = "CA_BUNDLE_CONFIGMAP_NAME"
+	caBundleConfigMapKey        = "CA_BUNDLE_CONFIGMAP_KEY"
+	stepHTTPProxy               = "STEP_HTTP_PROXY"
+	stepHTTPSProxy              = "STEP_HTTPS_PROXY"
+	stepNoProxy                 = "STEP_NO_PROXY"
+
+	// environment variables for the limits of the source code that the steps unpack
+	sourceMaxSizeDefault  = 10 * 1024 * 1024 * 1024
+	sourceMaxSizeEnvVar   = "SOURCE_MAX_SIZE"
+	sourceMaxFilesDefault = 1000000
+	sourceMaxFilesEnvVar  = "SOURCE_MAX_FILES"
 )
 
 var (
@@ -96,14 +131,19 @@ type Config struct {
 	GitContainerTemplate             pipeline.Step
 	ImageProcessingContainerTemplate pipeline.Step
 	BundleContainerTemplate          pipeline.Step
+	HTTPContainerTemplate            pipeline.Step
 	WaiterContainerTemplate          pipeline.Step
-	RemoteArtifactsContainerImage    string
 	TerminationLogPath               string
 	Prometheus                       PrometheusConfig
 	ManagerOptions                   ManagerOptions
 	Controllers                      Controllers
 	KubeAPIOptions                   KubeAPIOptions
 	GitRewriteRule                   bool
+	PinStepImageDigests              bool
+	StrategySecurity                 StrategySecurityOptions
+	RegistryToken                    RegistryTokenOptions
+	Network                          NetworkOptions
+	SourceLimits                     SourceLimitsOptions
 }
 
 // PrometheusConfig contains the specific configuration for the
@@ -135,6 +175,49 @@ type ControllerOptions struct {
 	MaxConcurrentReconciles int
 }
 
+// StrategySecurityOptions contains the options to restrict the use of build strategies with steps that
+// run privileged, with added capabilities, or as root
+type StrategySecurityOptions struct {
+	RestrictPrivileged   bool
+	PrivilegedNamespaces []string
+}
+
+// RegistryTokenOptions contains the options to authenticate the bundle and image-processing steps with short-lived
+// registry credentials that are obtained from a projected ServiceAccount token, instead of a static pull or push secret
+type RegistryTokenOptions struct {
+	Audience         string
+	ExchangeEndpoint string
+	ExchangeAudience string
+	Username         string
+}
+
+// IsConfigured returns true if the registry token options are configured
+func (o RegistryTokenOptions) IsConfigured() bool {
+	return o.Audience != ""
+}
+
+// NetworkOptions contains the certificate authority bundle and the proxy settings for the steps that fetch the source
+// code and push the image. The ConfigMap with the bundle must exist in the namespace of the BuildRun.
+type NetworkOptions struct {
+	CABundleConfigMapName string
+	CABundleConfigMapKey  string
+	HTTPProxy             string
+	HTTPSProxy            string
+	NoProxy               string
+}
+
+// HasCABundle returns true if a certificate authority bundle is configured
+func (o NetworkOptions) HasCABundle() bool {
+	return o.CABundleConfigMapName != ""
+}
+
+// SourceLimitsOptions contains the limits of the source code that the steps unpack, which protect the
+// ephemeral storage of the node. Zero means no limit.
+type SourceLimitsOptions struct {
+	MaxSize  int64
+	MaxFiles int
+}
+
 // KubeAPIOptions contains configurable options for the kube API client
 type KubeAPIOptions struct {
 	QPS   int
@@ -144,10 +227,10 @@ type KubeAPIOptions struct {
 // NewDefaultConfig returns a new Config, with context timeout and default Kaniko image.
 func NewDefaultConfig() *Config {
 	return &Config{
-		CtxTimeOut:                    contextTimeout,
-		RemoteArtifactsContainerImage: remoteArtifactsDefaultImage,
-		TerminationLogPath:            terminationLogPathDefault,
-		GitRewriteRule:                false,
+		CtxTimeOut:          contextTimeout,
+		TerminationLogPath:  terminationLogPathDefault,
+		GitRewriteRule:      false,
+		PinStepImageDigests: false,
 
 		GitContainerTemplate: pipeline.Step{
 			Image: gitDefaultImage,
@@ -186,6 +269,25 @@ func NewDefaultConfig() *Config {
 				RunAsGroup: nonRoot,
 			},
 		},
+		HTTPContainerTemplate: pipeline.Step{
+			Image: httpDefaultImage,
+			Command: []string{
+				"/ko-app/http",
+			},
+			// We explicitly define HOME=/tekton/home because this was always set in the
+			// default configuration of Tekton until v0.24.0, see https://github.com/tektoncd/pipeline/pull/3878
+			Env: []corev1.EnvVar{
+				{
+					Name:  "HOME",
+					Value: "/tekton/home",
+				},
+			},
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser:  nonRoot,
+				RunAsGroup: nonRoot,
+			},
+		},
+
 		ImageProcessingContainerTemplate: pipeline.Step{
 			Image: imageProcessingDefaultImage,
 			Command: []string{
@@ -265,9 +367,29 @@ func NewDefaultConfig() *Config {
 			QPS:   0,
 			Burst: 0,
 		},
+
+		Network: NetworkOptions{
+			CABundleConfigMapKey: caBundleConfigMapKeyDefault,
+		},
+
+		SourceLimits: SourceLimitsOptions{
+			MaxSize:  sourceMaxSizeDefault,
+			MaxFiles: sourceMaxFilesDefault,
+		},
 	}
 }
 
+// DeprecationWarnings returns a warning for every environment variable that is set,
+// but no longer supported
+func DeprecationWarnings() []string {
+	var warnings []string
+	if _, ok := os.LookupEnv(remoteArtifactsEnvVar); ok {
+		warnings = append(warnings, fmt.Sprintf("%s is deprecated and ignored, use %s with an image that contains the http command of Shipwright instead", remoteArtifactsEnvVar, httpImageEnvVar))
+	}
+
+	return warnings
+}
+
 // SetConfigFromEnv updates the configuration managed by environment variables.
 func (c *Config) SetConfigFromEnv() error {
 	if timeout := os.Getenv(contextTimeoutEnvVar); timeout != "" {
@@ -314,6 +436,11 @@ func (c *Config) SetConfigFromEnv() error {
 		c.GitRewriteRule = strings.ToLower(useGitRewriteRule) == "true"
 	}
 
+	// Mark that the step images are suppose to be pinned to their digests
+	if pinStepImageDigests := os.Getenv(pinStepImageDigests); pinStepImageDigests != "" {
+		c.PinStepImageDigests = strings.ToLower(pinStepImageDigests) == "true"
+	}
+
 	if bundleContainerTemplate := os.Getenv(bundleContainerTemplateEnvVar); bundleContainerTemplate != "" {
 		c.BundleContainerTemplate = pipeline.Step{}
 		if err := json.Unmarshal([]byte(bundleContainerTemplate), &c.BundleContainerTemplate); err != nil {
@@ -329,6 +456,21 @@ func (c *Config) SetConfigFromEnv() error {
 		c.BundleContainerTemplate.Image = bundleImage
 	}
 
+	if httpContainerTemplate := os.Getenv(httpContainerTemplateEnvVar); httpContainerTemplate != "" {
+		c.HTTPContainerTemplate = pipeline.Step{}
+		if err := json.Unmarshal([]byte(httpContainerTemplate), &c.HTTPContainerTemplate); err != nil {
+			return err
+		}
+		if c.HTTPContainerTemplate.Image == "" {
+			c.HTTPContainerTemplate.Image = httpDefaultImage
+		}
+	}
+
+	// the dedicated environment variable for the image overwrites what is defined in the HTTP container template
+	if httpImage := os.Getenv(httpImageEnvVar); httpImage != "" {
+		c.HTTPContainerTemplate.Image = httpImage
+	}
+
 	if waiterContainerTemplate := os.Getenv(waiterContainerTemplateEnvVar); waiterContainerTemplate != "" {
 		c.WaiterContainerTemplate = pipeline.Step{}
 		if err := json.Unmarshal([]byte(waiterContainerTemplate), &c.WaiterContainerTemplate); err != nil {
@@ -343,10 +485,6 @@ func (c *Config) SetConfigFromEnv() error {
 		c.WaiterContainerTemplate.Image = waiterImage
 	}
 
-	if remoteArtifactsImage := os.Getenv(remoteArtifactsEnvVar); remoteArtifactsImage != "" {
-		c.RemoteArtifactsContainerImage = remoteArtifactsImage
-	}
-
 	if err := updateBucketsConfig(&c.Prometheus.BuildRunCompletionDurationBuckets, metricBuildRunCompletionDurationBucketsEnvVar); err != nil {
 		return err
 	}
@@ -399,6 +537,40 @@ func (c *Config) SetConfigFromEnv() error {
 		return err
 	}
 
+	// strategy security settings
+	if restrictPrivilegedStrategies := os.Getenv(restrictPrivilegedStrategies); restrictPrivilegedStrategies != "" {
+		c.StrategySecurity.RestrictPrivileged = strings.ToLower(restrictPrivilegedStrategies) == "true"
+	}
+	if privilegedStrategiesNamespaces := os.Getenv(privilegedStrategiesNamespaces); privilegedStrategiesNamespaces != "" {
+		c.StrategySecurity.PrivilegedNamespaces = strings.Split(privilegedStrategiesNamespaces, ",")
+	}
+
+	// registry token settings
+	c.RegistryToken.Audience = os.Getenv(registryTokenAudience)
+	c.RegistryToken.ExchangeEndpoint = os.Getenv(registryTokenExchangeEndpoint)
+	c.RegistryToken.ExchangeAudience = os.Getenv(registryTokenExchangeAudience)
+	c.RegistryToken.Username = os.Getenv(registryTokenUsername)
+
+	// certificate authority bundle and proxy settings
+	c.Network.CABundleConfigMapName = os.Getenv(caBundleConfigMapName)
+	if caBundleConfigMapKey := os.Getenv(caBundleConfigMapKey); caBundleConfigMapKey != "" {
+		c.Network.CABundleConfigMapKey = caBundleConfigMapKey
+	}
+	c.Network.HTTPProxy = os.Getenv(stepHTTPProxy)
+	c.Network.HTTPSProxy = os.Getenv(stepHTTPSProxy)
+	c.Network.NoProxy = os.Getenv(stepNoProxy)
+
+	// source limits
+	if err := updateInt64Option(&c.SourceLimits.MaxSize, sourceMaxSizeEnvVar); err != nil {
+		return err
+	}
+	if err := updateIntOption(&c.SourceLimits.MaxFiles, sourceMaxFilesEnvVar); err != nil {
+		return err
+	}
+	if c.SourceLimits.MaxSize < 0 || c.SourceLimits.MaxFiles < 0 {
+		return fmt.Errorf("%s and %s must not be negative", sourceMaxSizeEnvVar, sourceMaxFilesEnvVar)
+	}
+
 	if terminationLogPath := os.Getenv(terminationLogPathEnvVar); terminationLogPath != "" {
 		c.TerminationLogPath = terminationLogPath
 	}
@@ -457,3 +629,15 @@ func updateIntOption(i *int, envVarName string) error {
 
 	return nil
 }
+
+func updateInt64Option(i *int64, envVarName string) error {
+	if value := os.Getenv(envVarName); value != "" {
+		intValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*i = intValue
+	}
+
+	return nil
+}
@@ -102,6 +102,81 @@ var _ = Describe("Config", func() {
 			})
 		})
 
+		It("should allow to restrict privileged strategies", func() {
+			var overrides = map[string]string{
+				"RESTRICT_PRIVILEGED_STRATEGIES":   "true",
+				"PRIVILEGED_STRATEGIES_NAMESPACES": "team-a,team-b",
+			}
+
+			configWithEnvVariableOverrides(overrides, func(config *Config) {
+				Expect(config.StrategySecurity.RestrictPrivileged).To(BeTrue())
+				Expect(config.StrategySecurity.PrivilegedNamespaces).To(Equal([]string{"team-a", "team-b"}))
+			})
+		})
+
+		It("should allow to configure short-lived registry credentials", func() {
+			var overrides = map[string]string{
+				"REGISTRY_TOKEN_AUDIENCE":          "sts.example.com",
+				"REGISTRY_TOKEN_EXCHANGE_ENDPOINT": "https://sts.example.com/token",
+				"REGISTRY_TOKEN_EXCHANGE_AUDIENCE": "registry.example.com",
+				"REGISTRY_TOKEN_USERNAME":          "oauth2accesstoken",
+			}
+
+			configWithEnvVariableOverrides(overrides, func(config *Config) {
+				Expect(config.RegistryToken.IsConfigured()).To(BeTrue())
+				Expect(config.RegistryToken).To(Equal(RegistryTokenOptions{
+					Audience:         "sts.example.com",
+					ExchangeEndpoint: "https://sts.example.com/token",
+					ExchangeAudience: "registry.example.com",
+					Username:         "oauth2accesstoken",
+				}))
+			})
+		})
+
+		It("should allow to configure a certificate authority bundle and proxy settings for the steps", func() {
+			var overrides = map[string]string{
+				"CA_BUNDLE_CONFIGMAP_NAME": "trusted-ca",
+				"STEP_HTTP_PROXY":          "http://proxy.example.com:3128",
+				"STEP_HTTPS_PROXY":         "http://proxy.example.com:3128",
+				"STEP_NO_PROXY":            ".svc,.cluster.local",
+			}
+
+			configWithEnvVariableOverrides(overrides, func(config *Config) {
+				Expect(config.Network.HasCABundle()).To(BeTrue())
+				Expect(config.Network).To(Equal(NetworkOptions{
+					CABundleConfigMapName: "trusted-ca",
+					CABundleConfigMapKey:  "ca-bundle.crt",
+					HTTPProxy:             "http://proxy.example.com:3128",
+					HTTPSProxy:            "http://proxy.example.com:3128",
+					NoProxy:               ".svc,.cluster.local",
+				}))
+			})
+		})
+
+		It("should limit the unpacked source code by default", func() {
+			config := NewDefaultConfig()
+			Expect(config.SourceLimits.MaxSize).To(BeNumerically(">", 0))
+			Expect(config.SourceLimits.MaxFiles).To(BeNumerically(">", 0))
+		})
+
+		It("should allow for an override of the source limits", func() {
+			var overrides = map[string]string{
+				"SOURCE_MAX_SIZE":  "1048576",
+				"SOURCE_MAX_FILES": "0",
+			}
+
+			configWithEnvVariableOverrides(overrides, func(config *Config) {
+				Expect(config.SourceLimits).To(Equal(SourceLimitsOptions{MaxSize: 1048576, MaxFiles: 0}))
+			})
+		})
+
+		It("should allow to enable pinning of step image digests", func() {
+			var overrides = map[string]string{"PIN_STEP_IMAGE_DIGESTS": "true"}
+			configWithEnvVariableOverrides(overrides, func(config *Config) {
+				Expect(config.PinStepImageDigests).To(BeTrue())
+			})
+		})
+
 		It("should allow for an override of the Git container template", func() {
 			var overrides = map[string]string{
 				"GIT_CONTAINER_TEMPLATE": "{\"image\":\"myregistry/custom/git-image\",\"resources\":{\"requests\":{\"cpu\":\"0.5\",\"memory\":\"128Mi\"}}}",
@@ -178,6 +253,37 @@ var _ = Describe("Config", func() {
 			})
 		})
 
+		It("should allow for an override of the HTTP container template and image", func() {
+			var overrides = map[string]string{
+				"HTTP_CONTAINER_TEMPLATE": `{"image":"myregistry/custom/http","command":["/ko-app/http"]}`,
+				"HTTP_CONTAINER_IMAGE":    "myregistry/custom/http:override",
+			}
+
+			configWithEnvVariableOverrides(overrides, func(config *Config) {
+				Expect(config.HTTPContainerTemplate).To(Equal(pipeline.Step{
+					Image:   "myregistry/custom/http:override",
+					Command: []string{"/ko-app/http"},
+				}))
+			})
+		})
+
+		It("should ignore the deprecated remote artifacts image and warn about it", func() {
+			var overrides = map[string]string{
+				"REMOTE_ARTIFACTS_CONTAINER_IMAGE": "quay.io/quay/busybox:latest",
+			}
+
+			configWithEnvVariableOverrides(overrides, func(config *Config) {
+				Expect(config.HTTPContainerTemplate.Image).To(Equal("ghcr.io/shipwright-io/build/http:latest"))
+				Expect(DeprecationWarnings()).To(ConsistOf(ContainSubstring("REMOTE_ARTIFACTS_CONTAINER_IMAGE is deprecated and ignored")))
+			})
+		})
+
+		It("should not warn if no deprecated environment variable is set", func() {
+			configWithEnvVariableOverrides(map[string]string{}, func(config *Config) {
+				Expect(DeprecationWarnings()).To(BeEmpty())
+			})
+		})
+
 		It("should allow for an override of the Waiter container template", func() {
 			var overrides = map[string]string{
 				"WAITER_CONTAINER_TEMPLATE": `{"image":"myregistry/custom/image","resources":{"requests":{"cpu":"0.5","memory":"128Mi"}}}`,
@@ -49,8 +49,38 @@ const (
 	RepositoryNotFound
 	// AuthPrompted is caused when a repo is not found, is private and authentication is insufficient
 	AuthPrompted
+	// AuthGitHubAppIncomplete expresses that either app ID, installation ID or private key is missing in GitHub App credentials
+	AuthGitHubAppIncomplete
+	// AuthGitHubAppFailed expresses that no installation token could be requested for the GitHub App
+	AuthGitHubAppFailed
+	// SignatureInvalid expresses that the fetched commit or tag is not signed by one of the trusted keys
+	SignatureInvalid
+	// ConnectionFailed expresses that the host name could not be resolved, or that the connection to the Git server
+	// could not be established or was interrupted.
+	ConnectionFailed
+	// TLSCertificateInvalid expresses that the TLS certificate of the Git server could not be verified
+	TLSCertificateInvalid
+	// Timeout expresses that the connection to the Git server, or the transfer of data, timed out
+	Timeout
+	// LFSFetchFailed expresses that Git Large File Storage (LFS) objects could not be downloaded
+	LFSFetchFailed
+	// RateLimited expresses that the Git server rejected requests because too many were sent
+	RateLimited
+	// DiskFull expresses that there is no space left to write the repository
+	DiskFull
 )
 
+// retryableErrorClasses are the classes of errors that are likely transient, so that
+// the same Git operation can succeed when it is repeated later
+var retryableErrorClasses = []ErrorClass{ConnectionFailed, Timeout, RateLimited}
+
+// infrastructureErrorClasses are the classes of errors of the network, the Git server or
+// the local disk in the order of their precedence. They are more specific than the
+// messages that Git prints afterwards, for example that it could not read from remote.
+var infrastructureErrorClasses = []ErrorClass{DiskFull, TLSCertificateInvalid, RateLimited, Timeout, ConnectionFailed, LFSFetchFailed}
+
+var rpcFailedRegEx = regexp.MustCompile(`rpc failed; curl (18|56|92) `)
+
 type rawToken struct {
 	raw string
 }
@@ -100,6 +130,24 @@ func (class ErrorClass) String() string {
 		return "GitSSHAuthExpected"
 	case AuthUnexpectedHTTP:
 		return "AuthUnexpectedHTTP"
+	case AuthGitHubAppIncomplete:
+		return "GitGitHubAppAuthIncomplete"
+	case AuthGitHubAppFailed:
+		return "GitGitHubAppAuthFailed"
+	case SignatureInvalid:
+		return "GitSignatureInvalid"
+	case ConnectionFailed:
+		return "GitConnectionFailed"
+	case TLSCertificateInvalid:
+		return "GitTLSCertificateInvalid"
+	case Timeout:
+		return "GitTimeout"
+	case LFSFetchFailed:
+		return "GitLFSFetchFailed"
+	case RateLimited:
+		return "GitRateLimited"
+	case DiskFull:
+		return "GitDiskFull"
 	}
 
 	return "GitError"
@@ -125,12 +173,42 @@ func (class ErrorClass) ToMessage() string {
 	case AuthBasicIncomplete:
 		return "Basic Auth incomplete: Both username and password need to be configured."
 	case AuthUnexpectedHTTP:
-		return "Refusing to continue with basic authentication (username and password) over insecure HTTP connection"
+		return "Refusing to continue with basic authentication (username and password) or token credentials over insecure HTTP connection"
+	case AuthGitHubAppIncomplete:
+		return "GitHub App credentials incomplete: The app ID, installation ID and private key need to be configured."
+	case AuthGitHubAppFailed:
+		return "GitHub App authentication has failed. Check the app ID, installation ID and private key, and that the app is installed for the repository."
+	case SignatureInvalid:
+		return "Signature verification failed: The commit or tag is not signed, or not signed by one of the trusted keys."
+	case ConnectionFailed:
+		return "The connection to the Git server failed. Check that the host name is correct and can be resolved, and that the server is reachable from the cluster, if needed through a proxy."
+	case TLSCertificateInvalid:
+		return "The TLS certificate of the Git server could not be verified. If the server uses a certificate of a private certificate authority, configure a CA bundle."
+	case Timeout:
+		return "The connection to the Git server timed out. The server may be overloaded or unreachable from the cluster."
+	case LFSFetchFailed:
+		return "Git LFS files could not be downloaded. Check that the objects exist on the server, and that the LFS storage or bandwidth quota of the repository is not exceeded."
+	case RateLimited:
+		return "The Git server rejected the request because too many requests were sent. Retry later, or authenticate to get a higher rate limit."
+	case DiskFull:
+		return "There is not enough disk space to fetch the repository. Increase the ephemeral storage of the build, or fetch less data, for example with a sparse checkout."
 	}
 
 	return "Git encountered an unknown error."
 }
 
+// IsRetryableReason returns true if the reason, as reported in the failure details
+// of a BuildRun, belongs to an error that is likely transient
+func IsRetryableReason(reason string) bool {
+	for _, retryable := range retryableErrorClasses {
+		if reason == retryable.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (token errorToken) String() string {
 	return token.prefixToken.String() + ": " + token.classToken.String()
 }
@@ -211,7 +289,55 @@ func isRepositoryNotFound(raw string) bool {
 }
 
 func isBranchNotFound(raw string) bool {
-	return strings.Contains(raw, "remote branch") && strings.Contains(raw, "not found")
+	return strings.Contains(raw, "remote branch") && strings.Contains(raw, "not found") ||
+		strings.Contains(raw, "couldn't find remote ref")
+}
+
+func isConnectionFailed(raw string) bool {
+	return strings.Contains(raw, "could not resolve host") ||
+		strings.Contains(raw, "could not resolve proxy") ||
+		strings.Contains(raw, "name or service not known") ||
+		strings.Contains(raw, "temporary failure in name resolution") ||
+		strings.Contains(raw, "failed to connect to") ||
+		strings.Contains(raw, "connection refused") ||
+		strings.Contains(raw, "connection reset by peer") ||
+		strings.Contains(raw, "network is unreachable") ||
+		strings.Contains(raw, "no route to host") ||
+		strings.Contains(raw, "unexpected disconnect while reading sideband packet") ||
+		rpcFailedRegEx.MatchString(raw)
+}
+
+func isTLSCertificateInvalid(raw string) bool {
+	return strings.Contains(raw, "ssl certificate problem") ||
+		strings.Contains(raw, "server certificate verification failed") ||
+		strings.Contains(raw, "no alternative certificate subject name matches") ||
+		strings.Contains(raw, "x509: certificate")
+}
+
+func isTimeout(raw string) bool {
+	return strings.Contains(raw, "connection timed out") ||
+		strings.Contains(raw, "operation timed out") ||
+		strings.Contains(raw, "operation too slow") ||
+		strings.Contains(raw, "i/o timeout")
+}
+
+func isLFSFetchFailed(raw string) bool {
+	return strings.Contains(raw, "smudge error") ||
+		strings.Contains(raw, "smudge filter lfs failed") ||
+		strings.Contains(raw, "error downloading object") ||
+		strings.Contains(raw, "git-lfs filter-process' failed") ||
+		strings.Contains(raw, "failed to fetch some objects from")
+}
+
+func isRateLimited(raw string) bool {
+	return strings.Contains(raw, "rate limit") ||
+		strings.Contains(raw, "too many requests") ||
+		strings.Contains(raw, "returned error: 429")
+}
+
+func isDiskFull(raw string) bool {
+	return strings.Contains(raw, "no space left on device") ||
+		strings.Contains(raw, "disk quota exceeded")
 }
 
 func parseErrorMessage(raw string) errorClassToken {
@@ -229,6 +355,18 @@ func parseErrorMessage(raw string) errorClassToken {
 		errorClass = RepositoryNotFound
 	case isBranchNotFound(toCheck):
 		errorClass = RevisionNotFound
+	case isDiskFull(toCheck):
+		errorClass = DiskFull
+	case isTLSCertificateInvalid(toCheck):
+		errorClass = TLSCertificateInvalid
+	case isRateLimited(toCheck):
+		errorClass = RateLimited
+	case isTimeout(toCheck):
+		errorClass = Timeout
+	case isConnectionFailed(toCheck):
+		errorClass = ConnectionFailed
+	case isLFSFetchFailed(toCheck):
+		errorClass = LFSFetchFailed
 	}
 
 	return errorClassToken{errorClass, rawToken{
@@ -276,7 +414,25 @@ func classifyTokensWithFatalPrefix(tokens []errorToken) ErrorClass {
 	return Unknown
 }
 
+// classifyInfrastructureTokens looks at all tokens independent of their prefix, because tools
+// like ssh and git-lfs print their errors with their own prefix
+func classifyInfrastructureTokens(tokens []errorToken) ErrorClass {
+	for _, errorClass := range infrastructureErrorClasses {
+		for _, token := range tokens {
+			if token.classToken.class == errorClass {
+				return errorClass
+			}
+		}
+	}
+
+	return Unknown
+}
+
 func classifyErrorFromTokens(tokens []errorToken) ErrorClass {
+	if errorClass := classifyInfrastructureTokens(tokens); errorClass != Unknown {
+		return errorClass
+	}
+
 	classifierMap := map[Prefix][]errorToken{}
 	for _, token := range tokens {
 		classifierMap[token.prefixToken.scope] = append(classifierMap[token.prefixToken.scope], token)
@@ -35,6 +35,10 @@ var _ = Describe("Parsing Git Error Messages", func() {
 			parsed := parseErrorMessage("Remote branch not found")
 			Expect(parsed.class).To(Equal(RevisionNotFound))
 		})
+		It("should recognize and parse unknown reference", func() {
+			parsed := parseErrorMessage("couldn't find remote ref refs/pull/123/head")
+			Expect(parsed.class).To(Equal(RevisionNotFound))
+		})
 		It("should recognize and parse invalid auth key", func() {
 			parsed := parseErrorMessage("could not read from remote.")
 			Expect(parsed.class).To(Equal(AuthInvalidKey))
@@ -63,4 +67,81 @@ var _ = Describe("Parsing Git Error Messages", func() {
 			Expect(errorResult.Reason.String()).To(Equal(RepositoryNotFound.String()))
 		})
 	})
+
+	DescribeTable("classifying the output of failed Git operations",
+		func(output string, expected ErrorClass) {
+			Expect(NewErrorResultFromMessage(output).Reason).To(Equal(expected))
+		},
+		Entry("unknown host over HTTPS",
+			"Cloning into '/workspace/source'...\nfatal: unable to access 'https://github.invalid/shipwright-io/sample-go/': Could not resolve host: github.invalid",
+			ConnectionFailed),
+		Entry("unknown host over SSH",
+			"ssh: Could not resolve hostname gitlab.invalid: Name or service not known\nfatal: Could not read from remote repository.\n\nPlease make sure you have the correct access rights\nand the repository exists.",
+			ConnectionFailed),
+		Entry("refused connection to a Gitea server",
+			"fatal: unable to access 'https://gitea.example.com/org/repo.git/': Failed to connect to gitea.example.com port 443 after 3 ms: Couldn't connect to server",
+			ConnectionFailed),
+		Entry("interrupted transfer",
+			"error: RPC failed; curl 56 OpenSSL SSL_read: Connection reset by peer, errno 104\nerror: 3958 bytes of body are still expected\nfetch-pack: unexpected disconnect while reading sideband packet\nfatal: early EOF\nfatal: fetch-pack: invalid index-pack output",
+			ConnectionFailed),
+		Entry("unknown certificate authority",
+			"fatal: unable to access 'https://gitlab.example.com/group/project.git/': SSL certificate problem: unable to get local issuer certificate",
+			TLSCertificateInvalid),
+		Entry("self-signed certificate",
+			"fatal: unable to access 'https://gitea.example.com/org/repo.git/': SSL certificate problem: self-signed certificate",
+			TLSCertificateInvalid),
+		Entry("certificate for another host name",
+			"fatal: unable to access 'https://git.example.com/org/repo.git/': SSL: no alternative certificate subject name matches target host name 'git.example.com'",
+			TLSCertificateInvalid),
+		Entry("connection timeout over HTTPS",
+			"fatal: unable to access 'https://github.com/shipwright-io/sample-go/': Failed to connect to github.com port 443 after 130512 ms: Connection timed out",
+			Timeout),
+		Entry("connection timeout over SSH",
+			"ssh: connect to host github.com port 22: Connection timed out\nfatal: Could not read from remote repository.\n\nPlease make sure you have the correct access rights\nand the repository exists.",
+			Timeout),
+		Entry("transfer timeout over HTTPS",
+			"error: RPC failed; curl 28 Operation timed out after 300000 milliseconds with 0 out of 0 bytes received\nfatal: expected flush after ref listing",
+			Timeout),
+		Entry("slow transfer",
+			"error: RPC failed; curl 28 Operation too slow. Less than 1000 bytes/sec transferred the last 30 seconds\nfatal: expected flush after ref listing",
+			Timeout),
+		Entry("missing Git LFS object",
+			"Downloading assets/logo.psd (12 MB)\nError downloading object: assets/logo.psd (3a4f1c2): Smudge error: Error downloading assets/logo.psd (3a4f1c2d): [3a4f1c2d] Object does not exist on the server: [404] Object does not exist on the server\n\nErrors logged to '/workspace/source/.git/lfs/logs/20230301T101010.log'.\nUse `git lfs logs last` to view the log.\nerror: external filter 'git-lfs filter-process' failed\nfatal: assets/logo.psd: smudge filter lfs failed",
+			LFSFetchFailed),
+		Entry("exceeded Git LFS bandwidth quota on GitHub",
+			"Error downloading object: model.bin (9f2c): Smudge error: Error downloading model.bin (9f2c1b): batch response: This repository is over its data quota. Account responsible for LFS bandwidth should purchase more data packs to restore access.\nerror: external filter 'git-lfs filter-process' failed\nfatal: model.bin: smudge filter lfs failed",
+			LFSFetchFailed),
+		Entry("missing Git LFS object of included files",
+			"batch response: Repository or object not found: https://github.com/org/repo.git/info/lfs/objects/batch\nCheck that it exists and that you have proper access to it\nerror: failed to fetch some objects from 'https://github.com/org/repo.git/info/lfs'",
+			LFSFetchFailed),
+		Entry("rate limited Git LFS batch request on GitLab",
+			"Error downloading object: model.bin (9f2c): Smudge error: Error downloading model.bin (9f2c1b): batch response: Rate limit exceeded: https://gitlab.com/group/project.git/info/lfs/objects/batch\nerror: external filter 'git-lfs filter-process' failed",
+			RateLimited),
+		Entry("rate limited clone on GitLab",
+			"remote: Retry later\nfatal: unable to access 'https://gitlab.com/group/project.git/': The requested URL returned error: 429",
+			RateLimited),
+		Entry("rate limited clone on Gitea",
+			"fatal: unable to access 'https://gitea.com/org/repo.git/': The requested URL returned error: 429 Too Many Requests",
+			RateLimited),
+		Entry("no space left for the checkout",
+			"error: unable to write file vendor/large.bin\nfatal: cannot create directory at 'vendor/deps': No space left on device\nwarning: Clone succeeded, but checkout failed.",
+			DiskFull),
+		Entry("no space left for the pack",
+			"fatal: write error: No space left on device\nfatal: fetch-pack: invalid index-pack output",
+			DiskFull),
+	)
+
+	Context("marking errors as retryable", func() {
+		It("should mark transient errors as retryable", func() {
+			for _, class := range []ErrorClass{ConnectionFailed, Timeout, RateLimited} {
+				Expect(IsRetryableReason(class.String())).To(BeTrue(), class.String())
+			}
+		})
+
+		It("should not mark permanent errors as retryable", func() {
+			for _, class := range []ErrorClass{Unknown, AuthInvalidUserOrPass, RepositoryNotFound, RevisionNotFound, TLSCertificateInvalid, LFSFetchFailed, DiskFull} {
+				Expect(IsRetryableReason(class.String())).To(BeFalse(), class.String())
+			}
+		})
+	})
 })
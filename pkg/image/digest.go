@@ -0,0 +1,33 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// ResolveDigest returns the digest of the image or image index that the image reference points to. References
+// that already contain a digest are returned without contacting the registry.
+func ResolveDigest(imageName name.Reference, options []remote.Option) (string, error) {
+	if digest, ok := imageName.(name.Digest); ok {
+		return digest.DigestStr(), nil
+	}
+
+	descriptor, err := remote.Head(imageName, options...)
+	if err != nil {
+		// not all registries support HEAD requests for manifests, fall back to GET
+		getDescriptor, getErr := remote.Get(imageName, options...)
+		if getErr != nil {
+			return "", fmt.Errorf("%w (HEAD request failed: %v)", getErr, err)
+		}
+
+		return getDescriptor.Digest.String(), nil
+	}
+
+	return descriptor.Digest.String(), nil
+}
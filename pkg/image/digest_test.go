@@ -0,0 +1,86 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package image_test
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/shipwright-io/build/pkg/image"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResolveDigest", func() {
+
+	var registryHost string
+
+	BeforeEach(func() {
+		logger := log.New(io.Discard, "", 0)
+		reg := registry.New(registry.Logger(logger))
+		server := httptest.NewServer(reg)
+		DeferCleanup(func() {
+			server.Close()
+		})
+		registryHost = strings.ReplaceAll(server.URL, "http://", "")
+	})
+
+	It("resolves the tag of an image", func() {
+		img, err := random.Image(1024, 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		imageName, err := name.ParseReference(fmt.Sprintf("%s/%s/%s:%s", registryHost, "test-namespace", "test-image", "v1"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.Write(imageName, img)).To(Succeed())
+
+		expectedDigest, err := img.Digest()
+		Expect(err).ToNot(HaveOccurred())
+
+		digest, err := image.ResolveDigest(imageName, []remote.Option{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(digest).To(Equal(expectedDigest.String()))
+	})
+
+	It("resolves the tag of an image index", func() {
+		index, err := random.Index(1024, 1, 2)
+		Expect(err).ToNot(HaveOccurred())
+
+		imageName, err := name.ParseReference(fmt.Sprintf("%s/%s/%s:%s", registryHost, "test-namespace", "test-index", "v1"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.WriteIndex(imageName, index)).To(Succeed())
+
+		expectedDigest, err := index.Digest()
+		Expect(err).ToNot(HaveOccurred())
+
+		digest, err := image.ResolveDigest(imageName, []remote.Option{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(digest).To(Equal(expectedDigest.String()))
+	})
+
+	It("returns the digest of a digest reference without contacting the registry", func() {
+		imageName, err := name.ParseReference("registry.invalid/test-namespace/test-image@sha256:b5f4b2cd3ea2fe2fb2e0f2e2e8ac0e68f2ff4ab2e2f3d3c5f3f2a6f3d1c4c2b1")
+		Expect(err).ToNot(HaveOccurred())
+
+		digest, err := image.ResolveDigest(imageName, []remote.Option{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(digest).To(Equal("sha256:b5f4b2cd3ea2fe2fb2e0f2e2e8ac0e68f2ff4ab2e2f3d3c5f3f2a6f3d1c4c2b1"))
+	})
+
+	It("fails for a tag that does not exist", func() {
+		imageName, err := name.ParseReference(fmt.Sprintf("%s/%s/%s:%s", registryHost, "test-namespace", "test-image", "missing"))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = image.ResolveDigest(imageName, []remote.Option{})
+		Expect(err).To(HaveOccurred())
+	})
+})
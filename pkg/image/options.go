@@ -7,6 +7,7 @@ package image
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net/http"
@@ -45,8 +46,9 @@ func (ort *optionsRoundTripper) RoundTrip(in *http.Request) (*http.Response, err
 	return ort.inner.RoundTrip(in)
 }
 
-// GetOptions constructs go-containerregistry options to access the remote registry, in addition, it returns the authentication separately which can be an empty object
-func GetOptions(ctx context.Context, imageName name.Reference, insecure bool, dockerConfigJSONPath string, userAgent string) ([]remote.Option, *authn.AuthConfig, error) {
+// GetOptions constructs go-containerregistry options to access the remote registry, in addition, it returns the authentication separately which can be an empty object.
+// The certificates of the optional CA bundle are trusted in addition to the system certificate authorities.
+func GetOptions(ctx context.Context, imageName name.Reference, insecure bool, dockerConfigJSONPath string, caBundlePath string, userAgent string) ([]remote.Option, *authn.AuthConfig, error) {
 	var options []remote.Option
 
 	options = append(options, remote.WithContext(ctx))
@@ -63,6 +65,15 @@ func GetOptions(ctx context.Context, imageName name.Reference, insecure bool, do
 			InsecureSkipVerify: false,
 			MinVersion:         tls.VersionTLS12,
 		}
+
+		if caBundlePath != "" {
+			rootCAs, err := CertPool(caBundlePath)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			transport.TLSClientConfig.RootCAs = rootCAs
+		}
 	}
 
 	// find a Docker config.json
@@ -143,3 +154,22 @@ func GetOptions(ctx context.Context, imageName name.Reference, insecure bool, do
 
 	return options, &auth, nil
 }
+
+// CertPool returns the system certificate pool with the certificates of the CA bundle added to it
+func CertPool(caBundlePath string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	data, err := os.ReadFile(caBundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA bundle: %w", err)
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("failed to find a PEM-encoded certificate in the CA bundle %s", caBundlePath)
+	}
+
+	return pool, nil
+}
@@ -6,11 +6,20 @@ package image_test
 
 import (
 	"context"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/shipwright-io/build/pkg/image"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -42,7 +51,7 @@ var _ = Describe("GetOptions", func() {
 	Context("without a dockerconfigjson", func() {
 
 		It("constructs options and empty auth", func() {
-			options, auth, err := image.GetOptions(context.TODO(), imageName, true, "", "test-agent")
+			options, auth, err := image.GetOptions(context.TODO(), imageName, true, "", "", "test-agent")
 			Expect(err).ToNot(HaveOccurred())
 
 			// there is no way to further check what is in because the options are functions
@@ -60,7 +69,7 @@ var _ = Describe("GetOptions", func() {
 		It("constructs options and auth with the matching user", func() {
 			withDockerConfigJSON(authn.DefaultAuthKey, "aUser", "aPassword", func(dockerConfigJSONPath string) {
 
-				options, auth, err := image.GetOptions(context.TODO(), imageName, true, dockerConfigJSONPath, "test-agent")
+				options, auth, err := image.GetOptions(context.TODO(), imageName, true, dockerConfigJSONPath, "", "test-agent")
 				Expect(err).ToNot(HaveOccurred())
 
 				// there is no way to further check what is in because the options are functions
@@ -78,9 +87,53 @@ var _ = Describe("GetOptions", func() {
 
 		It("fails with an error", func() {
 			withDockerConfigJSON("ghcr.io", "aUser", "aPassword", func(dockerConfigJSONPath string) {
-				_, _, err := image.GetOptions(context.TODO(), imageName, true, dockerConfigJSONPath, "test-agent")
+				_, _, err := image.GetOptions(context.TODO(), imageName, true, dockerConfigJSONPath, "", "test-agent")
 				Expect(err).To(HaveOccurred())
 			})
 		})
 	})
+
+	Context("with a CA bundle", func() {
+		var registryImageName name.Reference
+		var caBundlePath string
+
+		BeforeEach(func() {
+			logger := log.New(io.Discard, "", 0)
+			server := httptest.NewUnstartedServer(registry.New(registry.Logger(logger)))
+			server.Config.ErrorLog = logger
+			server.StartTLS()
+			DeferCleanup(server.Close)
+
+			registryImageName, err = name.ParseReference(fmt.Sprintf("%s/test-namespace/test-image:v1", strings.TrimPrefix(server.URL, "https://")))
+			Expect(err).ToNot(HaveOccurred())
+
+			caBundlePath = filepath.Join(GinkgoT().TempDir(), "ca-bundle.crt")
+			Expect(os.WriteFile(caBundlePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)).To(Succeed())
+		})
+
+		It("trusts the certificate authorities of the bundle", func() {
+			img, err := random.Image(1024, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			options, _, err := image.GetOptions(context.TODO(), registryImageName, false, "", caBundlePath, "test-agent")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(remote.Write(registryImageName, img, options...)).To(Succeed())
+		})
+
+		It("does not trust the registry without the bundle", func() {
+			img, err := random.Image(1024, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			options, _, err := image.GetOptions(context.TODO(), registryImageName, false, "", "", "test-agent")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(remote.Write(registryImageName, img, options...)).ToNot(Succeed())
+		})
+
+		It("fails for a bundle without certificates", func() {
+			Expect(os.WriteFile(caBundlePath, []byte("no certificate"), 0644)).To(Succeed())
+
+			_, _, err := image.GetOptions(context.TODO(), registryImageName, false, "", caBundlePath, "test-agent")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
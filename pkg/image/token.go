@@ -0,0 +1,151 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+)
+
+const (
+	grantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeJWT               = "urn:ietf:params:oauth:token-type:jwt"
+	tokenTypeAccessToken       = "urn:ietf:params:oauth:token-type:access_token"
+	tokenExchangeClientTimeout = 30 * time.Second
+)
+
+// TokenCredentials configures short-lived registry credentials that are obtained from a token file,
+// for example a projected ServiceAccount token, instead of a static Docker config.json
+type TokenCredentials struct {
+	// TokenFile is the file that contains the token
+	TokenFile string
+
+	// ExchangeEndpoint is the URL of an OAuth 2.0 token exchange endpoint (RFC 8693) that exchanges the
+	// token from the token file for a registry token. The token is used as is in case it is empty.
+	ExchangeEndpoint string
+
+	// Audience is the audience requested from the token exchange endpoint (optional)
+	Audience string
+
+	// Username is used together with the token as password. The token is sent to the registry
+	// as bearer token in case it is empty.
+	Username string
+
+	// Client is the HTTP client used for the token exchange, a default client is used if nil
+	Client *http.Client
+}
+
+// IsConfigured returns true if a token file is configured
+func (c TokenCredentials) IsConfigured() bool {
+	return c.TokenFile != ""
+}
+
+// AuthConfig reads the token from the token file, exchanges it if a token exchange endpoint
+// is configured, and returns the authentication to use for the container registry
+func (c TokenCredentials) AuthConfig(ctx context.Context) (*authn.AuthConfig, error) {
+	data, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, fmt.Errorf("the token file %s is empty", c.TokenFile)
+	}
+
+	if c.ExchangeEndpoint != "" {
+		if token, err = c.exchange(ctx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Username != "" {
+		return &authn.AuthConfig{Username: c.Username, Password: token}, nil
+	}
+
+	return &authn.AuthConfig{RegistryToken: token}, nil
+}
+
+// exchange performs an OAuth 2.0 token exchange and returns the issued access token
+func (c TokenCredentials) exchange(ctx context.Context, subjectToken string) (string, error) {
+	endpoint, err := url.Parse(c.ExchangeEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the token exchange endpoint: %w", err)
+	}
+
+	// the subject token must never be sent over an unencrypted connection
+	if endpoint.Scheme != "https" {
+		return "", errors.New("the token exchange endpoint must use the https protocol")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", grantTypeTokenExchange)
+	form.Set("subject_token", subjectToken)
+	form.Set("subject_token_type", tokenTypeJWT)
+	form.Set("requested_token_type", tokenTypeAccessToken)
+	if c.Audience != "" {
+		form.Set("audience", c.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: tokenExchangeClientTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange the token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	type tokenExchangeResponse struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	var response tokenExchangeResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to exchange the token: unexpected response (HTTP status code %d)", resp.StatusCode)
+	}
+
+	switch {
+	case resp.StatusCode != http.StatusOK && response.Error != "":
+		return "", fmt.Errorf("failed to exchange the token: %s %s (HTTP status code %d)", response.Error, response.ErrorDescription, resp.StatusCode)
+
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("failed to exchange the token (HTTP status code %d)", resp.StatusCode)
+
+	case response.AccessToken == "":
+		return "", errors.New("failed to exchange the token: the response does not contain an access token")
+	}
+
+	return response.AccessToken, nil
+}
@@ -0,0 +1,109 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package image_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/shipwright-io/build/pkg/image"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TokenCredentials", func() {
+
+	var tokenFile string
+
+	BeforeEach(func() {
+		tokenFile = filepath.Join(GinkgoT().TempDir(), "token")
+		Expect(os.WriteFile(tokenFile, []byte("service-account-token\n"), 0644)).To(Succeed())
+	})
+
+	// tokenServer is a stand-in for an OAuth 2.0 token exchange endpoint
+	tokenServer := func() *httptest.Server {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+
+			w.Header().Set("Content-Type", "application/json")
+
+			if r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:token-exchange" ||
+				r.PostForm.Get("subject_token") != "service-account-token" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "unknown subject token"})
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "registry-token-for-" + r.PostForm.Get("audience"),
+				"token_type":   "Bearer",
+				"expires_in":   300,
+			})
+		}))
+		DeferCleanup(server.Close)
+
+		return server
+	}
+
+	It("is not configured without a token file", func() {
+		Expect(image.TokenCredentials{}.IsConfigured()).To(BeFalse())
+	})
+
+	It("uses the token from the token file as registry token", func() {
+		auth, err := image.TokenCredentials{TokenFile: tokenFile}.AuthConfig(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*auth).To(Equal(authn.AuthConfig{RegistryToken: "service-account-token"}))
+	})
+
+	It("uses the token as password if a username is configured", func() {
+		auth, err := image.TokenCredentials{TokenFile: tokenFile, Username: "oauth2accesstoken"}.AuthConfig(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*auth).To(Equal(authn.AuthConfig{Username: "oauth2accesstoken", Password: "service-account-token"}))
+	})
+
+	It("exchanges the token at the token exchange endpoint", func() {
+		server := tokenServer()
+
+		auth, err := image.TokenCredentials{
+			TokenFile:        tokenFile,
+			ExchangeEndpoint: server.URL,
+			Audience:         "registry.example.com",
+			Client:           server.Client(),
+		}.AuthConfig(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*auth).To(Equal(authn.AuthConfig{RegistryToken: "registry-token-for-registry.example.com"}))
+	})
+
+	It("fails with the error of the token exchange endpoint", func() {
+		server := tokenServer()
+		Expect(os.WriteFile(tokenFile, []byte("another-token"), 0644)).To(Succeed())
+
+		_, err := image.TokenCredentials{
+			TokenFile:        tokenFile,
+			ExchangeEndpoint: server.URL,
+			Client:           server.Client(),
+		}.AuthConfig(context.TODO())
+		Expect(err).To(MatchError("failed to exchange the token: invalid_grant unknown subject token (HTTP status code 400)"))
+	})
+
+	It("refuses to send the token over plain http", func() {
+		_, err := image.TokenCredentials{
+			TokenFile:        tokenFile,
+			ExchangeEndpoint: "http://localhost/token",
+		}.AuthConfig(context.TODO())
+		Expect(err).To(MatchError("the token exchange endpoint must use the https protocol"))
+	})
+
+	It("fails if the token file does not exist", func() {
+		_, err := image.TokenCredentials{TokenFile: filepath.Join(filepath.Dir(tokenFile), "missing")}.AuthConfig(context.TODO())
+		Expect(err).To(HaveOccurred())
+	})
+})
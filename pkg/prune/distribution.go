@@ -0,0 +1,72 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// Distribution deletes images with the delete API of the OCI distribution
+// specification, which all registries implement that support deletion
+type Distribution struct{}
+
+// Name implements Provider
+func (d *Distribution) Name() string {
+	return "OCI distribution"
+}
+
+// Detect implements Provider, the API is available for all registries
+func (d *Distribution) Detect(_ context.Context, _ Config, _ name.Registry) bool {
+	return true
+}
+
+// Delete implements Provider, it deletes the manifest by its digest, and falls back
+// to delete it by its tag for registries that only support the deletion of tags
+func (d *Distribution) Delete(_ context.Context, config Config, image Image) error {
+	err := remote.Delete(image.digestReference(), config.Options...)
+	if !isUnsupportedResponse(err) || image.Tag == "" {
+		return d.unsupported(err)
+	}
+
+	return d.unsupported(remote.Delete(image.tagReference(), config.Options...))
+}
+
+func (d *Distribution) unsupported(err error) error {
+	if isUnsupportedResponse(err) {
+		return &UnsupportedError{
+			Provider: d.Name(),
+			Reason:   "the registry does not allow the deletion of manifests: " + err.Error(),
+		}
+	}
+
+	return err
+}
+
+// isUnsupportedResponse returns true if the registry responded that the
+// operation is not supported or not allowed
+func isUnsupportedResponse(err error) bool {
+	var transportError *transport.Error
+	if !errors.As(err, &transportError) {
+		return false
+	}
+
+	if transportError.StatusCode == http.StatusMethodNotAllowed {
+		return true
+	}
+
+	for _, diagnostic := range transportError.Errors {
+		if diagnostic.Code == transport.UnsupportedErrorCode {
+			return true
+		}
+	}
+
+	return false
+}
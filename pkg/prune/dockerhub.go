@@ -0,0 +1,132 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const dockerHubEndpoint = "https://hub.docker.com"
+
+// DockerHub deletes images on Docker Hub, which restricts deleting a single tag
+// to the business tier, but provides an API call to delete the whole repository.
+//
+//   - In case the repository only has one tag, the repository is deleted.
+//   - If there are multiple tags, the tag to be deleted is overwritten
+//     with an empty image (to remove the content, and save quota).
+//   - Edge case would be no tags in the repository, which is ignored.
+type DockerHub struct {
+	// Endpoint is the URL of the Docker Hub API, defaults to https://hub.docker.com
+	Endpoint string
+}
+
+// Name implements Provider
+func (d *DockerHub) Name() string {
+	return "Docker Hub"
+}
+
+// Detect implements Provider
+func (d *DockerHub) Detect(_ context.Context, _ Config, registry name.Registry) bool {
+	return strings.Contains(registry.RegistryStr(), "docker.io")
+}
+
+// Delete implements Provider
+func (d *DockerHub) Delete(ctx context.Context, config Config, image Image) error {
+	list, err := remote.List(image.Repository, config.Options...)
+	if err != nil {
+		return err
+	}
+
+	switch len(list) {
+	case 0:
+		return nil
+
+	case 1:
+		token, err := d.login(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		_, err = call(ctx, config.Client, http.MethodDelete,
+			fmt.Sprintf("%s/v2/repositories/%s/", d.endpoint(), image.Repository.RepositoryStr()),
+			http.Header{"Authorization": []string{token}},
+			nil,
+			http.StatusAccepted,
+		)
+
+		return err
+
+	default:
+		// the tag is overwritten, because removing a specific image tag is not supported
+		return remote.Write(image.tagReference(), empty.Image, config.Options...)
+	}
+}
+
+func (d *DockerHub) endpoint() string {
+	if d.Endpoint != "" {
+		return d.Endpoint
+	}
+
+	return dockerHubEndpoint
+}
+
+func (d *DockerHub) login(ctx context.Context, config Config) (string, error) {
+	type LoginData struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	loginData, err := json.Marshal(LoginData{Username: config.Auth.Username, Password: config.Auth.Password})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint()+"/v2/users/login/", bytes.NewReader(loginData))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := config.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	bodyData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		type LoginToken struct {
+			Token string `json:"token"`
+		}
+
+		var loginToken LoginToken
+		if err := json.Unmarshal(bodyData, &loginToken); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("JWT %s", loginToken.Token), nil
+
+	default:
+		return "", fmt.Errorf("failed to log in to Docker Hub: %s (HTTP status code %d)", string(bodyData), resp.StatusCode)
+	}
+}
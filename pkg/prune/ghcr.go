@@ -0,0 +1,100 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+const gitHubAPIEndpoint = "https://api.github.com"
+
+// GHCR deletes package versions with the API of GitHub, which is authorized with
+// a token with the delete:packages scope. GitHub does not allow to delete the last
+// version of a package, the whole package is deleted instead.
+type GHCR struct {
+	// APIEndpoint is the URL of the GitHub API, defaults to https://api.github.com
+	APIEndpoint string
+}
+
+// Name implements Provider
+func (g *GHCR) Name() string {
+	return "GitHub Container Registry"
+}
+
+// Detect implements Provider
+func (g *GHCR) Detect(_ context.Context, _ Config, registry name.Registry) bool {
+	return registry.RegistryStr() == "ghcr.io"
+}
+
+// Delete implements Provider
+func (g *GHCR) Delete(ctx context.Context, config Config, image Image) error {
+	owner, packageName, found := strings.Cut(image.Repository.RepositoryStr(), "/")
+	if !found {
+		return fmt.Errorf("the repository %s does not contain an owner and a package name", image.Repository.RepositoryStr())
+	}
+
+	header := http.Header{
+		"Accept":        []string{"application/vnd.github+json"},
+		"Authorization": []string{"Bearer " + config.Auth.Password},
+	}
+
+	// packages of organizations and users have different endpoints
+	var account struct {
+		Type string `json:"type"`
+	}
+
+	if _, err := call(ctx, config.Client, http.MethodGet, fmt.Sprintf("%s/users/%s", g.endpoint(), owner), header, &account, http.StatusOK); err != nil {
+		return err
+	}
+
+	packageURL := fmt.Sprintf("%s/users/%s/packages/container/%s", g.endpoint(), owner, url.PathEscape(packageName))
+	if account.Type == "Organization" {
+		packageURL = fmt.Sprintf("%s/orgs/%s/packages/container/%s", g.endpoint(), owner, url.PathEscape(packageName))
+	}
+
+	var versions []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	statusCode, err := call(ctx, config.Client, http.MethodGet, packageURL+"/versions?per_page=100", header, &versions, http.StatusOK)
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &UnsupportedError{Provider: g.Name(), Reason: "the credentials are not authorized to use the API, a token with the delete:packages scope is required"}
+
+	case err != nil:
+		return err
+	}
+
+	for _, version := range versions {
+		if version.Name != image.Digest {
+			continue
+		}
+
+		deleteURL := fmt.Sprintf("%s/versions/%d", packageURL, version.ID)
+		if len(versions) == 1 {
+			deleteURL = packageURL
+		}
+
+		_, err := call(ctx, config.Client, http.MethodDelete, deleteURL, header, nil, http.StatusNoContent)
+		return err
+	}
+
+	return fmt.Errorf("failed to find the version %s of the package %s", image.Digest, packageName)
+}
+
+func (g *GHCR) endpoint() string {
+	if g.APIEndpoint != "" {
+		return g.APIEndpoint
+	}
+
+	return gitHubAPIEndpoint
+}
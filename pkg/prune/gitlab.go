@@ -0,0 +1,136 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// gitLabRealm matches the token endpoint of the GitLab container registry
+var gitLabRealm = regexp.MustCompile(`realm="(https://[^"/]+)(/[^"]*)?/jwt/auth"`)
+
+// GitLab deletes tags with the API of GitLab, which is authorized with a personal,
+// group or project access token with the api scope. Deploy tokens only grant
+// access to the registry.
+type GitLab struct {
+	// APIEndpoint is the URL of the GitLab instance, defaults to the URL
+	// of the token endpoint that the registry announces if it uses HTTPS
+	// and the host of the registry
+	APIEndpoint string
+}
+
+// Name implements Provider
+func (g *GitLab) Name() string {
+	return "GitLab"
+}
+
+// Detect implements Provider
+func (g *GitLab) Detect(ctx context.Context, config Config, registry name.Registry) bool {
+	return g.endpoint(ctx, config, registry) != ""
+}
+
+// Delete implements Provider
+func (g *GitLab) Delete(ctx context.Context, config Config, image Image) error {
+	if image.Tag == "" {
+		return &UnsupportedError{Provider: g.Name(), Reason: "the API deletes tags, but the image is referenced by its digest"}
+	}
+
+	endpoint := g.endpoint(ctx, config, image.Repository.Registry)
+	header := http.Header{"Private-Token": []string{config.Auth.Password}}
+
+	type containerRepository struct {
+		ID   int64  `json:"id"`
+		Path string `json:"path"`
+	}
+
+	// the image path starts with the path of the project, followed by an optional image name
+	path := image.Repository.RepositoryStr()
+	segments := strings.Split(path, "/")
+	for i := len(segments); i >= 2; i-- {
+		project := url.PathEscape(strings.Join(segments[:i], "/"))
+
+		var repositories []containerRepository
+		statusCode, err := call(ctx, config.Client, http.MethodGet,
+			fmt.Sprintf("%s/api/v4/projects/%s/registry/repositories?per_page=100", endpoint, project),
+			header,
+			&repositories,
+			http.StatusOK,
+		)
+
+		switch {
+		case statusCode == http.StatusNotFound:
+			continue
+
+		case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+			return &UnsupportedError{Provider: g.Name(), Reason: "the credentials are not authorized to use the API, an access token with the api scope is required"}
+
+		case err != nil:
+			return err
+		}
+
+		for _, repository := range repositories {
+			if repository.Path != path {
+				continue
+			}
+
+			_, err := call(ctx, config.Client, http.MethodDelete,
+				fmt.Sprintf("%s/api/v4/projects/%s/registry/repositories/%d/tags/%s", endpoint, project, repository.ID, url.PathEscape(image.Tag)),
+				header,
+				nil,
+				http.StatusOK,
+			)
+
+			return err
+		}
+	}
+
+	return fmt.Errorf("failed to find the GitLab project of the container repository %s", path)
+}
+
+// endpoint returns the URL of the GitLab instance, or an empty string if the
+// registry is no GitLab container registry
+func (g *GitLab) endpoint(ctx context.Context, config Config, registry name.Registry) string {
+	switch {
+	case g.APIEndpoint != "":
+		return g.APIEndpoint
+
+	case registry.RegistryStr() == "registry.gitlab.com":
+		return "https://gitlab.com"
+	}
+
+	// the registry announces the token endpoint of the GitLab instance
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL(registry)+"/v2/", nil)
+	if err != nil {
+		return ""
+	}
+
+	resp, err := config.Client.Do(req)
+	if err != nil {
+		return ""
+	}
+
+	defer resp.Body.Close()
+
+	match := gitLabRealm.FindStringSubmatch(resp.Header.Get("Www-Authenticate"))
+	if match == nil {
+		return ""
+	}
+
+	// the access token is sent to the announced endpoint, which therefore
+	// must be on the host of the registry, the port may differ
+	realm, err := url.Parse(match[1])
+	if err != nil || realm.Hostname() != (&url.URL{Host: registry.RegistryStr()}).Hostname() {
+		return ""
+	}
+
+	return match[1] + match[2]
+}
@@ -0,0 +1,65 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Harbor deletes artifacts with the API of Harbor, which is always self-hosted
+// and therefore detected by its system information endpoint
+type Harbor struct{}
+
+// Name implements Provider
+func (h *Harbor) Name() string {
+	return "Harbor"
+}
+
+// Detect implements Provider
+func (h *Harbor) Detect(ctx context.Context, config Config, registry name.Registry) bool {
+	var systemInfo struct {
+		HarborVersion string `json:"harbor_version"`
+	}
+
+	_, err := call(ctx, config.Client, http.MethodGet, baseURL(registry)+"/api/v2.0/systeminfo", nil, &systemInfo, http.StatusOK)
+	return err == nil && systemInfo.HarborVersion != ""
+}
+
+// Delete implements Provider, the artifact is deleted with all its tags like
+// with the delete API of the OCI distribution specification
+func (h *Harbor) Delete(ctx context.Context, config Config, image Image) error {
+	project, repository, found := strings.Cut(image.Repository.RepositoryStr(), "/")
+	if !found {
+		return fmt.Errorf("the repository %s does not belong to a Harbor project", image.Repository.RepositoryStr())
+	}
+
+	header := http.Header{}
+	if config.Auth.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(config.Auth.Username + ":" + config.Auth.Password))
+		header.Set("Authorization", "Basic "+credentials)
+	}
+
+	// the API requires the slashes of the repository name to be encoded twice
+	_, err := call(ctx, config.Client, http.MethodDelete,
+		fmt.Sprintf("%s/api/v2.0/projects/%s/repositories/%s/artifacts/%s",
+			baseURL(image.Repository.Registry),
+			url.PathEscape(project),
+			url.PathEscape(url.PathEscape(repository)),
+			image.Digest,
+		),
+		header,
+		nil,
+		http.StatusOK,
+	)
+
+	return err
+}
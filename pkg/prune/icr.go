@@ -0,0 +1,163 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// ICR deletes images in the IBM Container Registry, which does not support the
+// default registry API for deletions. The credentials need to have an IBM API
+// key, which is used to obtain an identity token that contains the authorization
+// token for requests as well as an account identifier to select the IBM account
+// in which the registry namespace and image is located.
+type ICR struct {
+	// IAMEndpoint is the URL of the IAM token endpoint, defaults to the
+	// production or staging endpoint depending on the registry
+	IAMEndpoint string
+}
+
+// Name implements Provider
+func (i *ICR) Name() string {
+	return "IBM Container Registry"
+}
+
+// Detect implements Provider
+func (i *ICR) Detect(_ context.Context, _ Config, registry name.Registry) bool {
+	return strings.Contains(registry.RegistryStr(), "icr.io")
+}
+
+// Delete implements Provider
+func (i *ICR) Delete(ctx context.Context, config Config, image Image) error {
+	// IBM Container Registry API calls will only work in case an API key is available
+	if config.Auth.Username != "iamapikey" {
+		return &UnsupportedError{
+			Provider: i.Name(),
+			Reason:   fmt.Sprintf("the access credentials for %q do not contain an IBM API key", image.Repository.RegistryStr()),
+		}
+	}
+
+	token, accountID, err := i.login(ctx, config, image.Repository.Registry)
+	if err != nil {
+		return err
+	}
+
+	_, err = call(ctx, config.Client, http.MethodDelete,
+		fmt.Sprintf("%s/api/v1/images/%s", baseURL(image.Repository.Registry), url.QueryEscape(image.String())),
+		http.Header{
+			"Account":       []string{accountID},
+			"Authorization": []string{token},
+		},
+		nil,
+		http.StatusOK,
+	)
+
+	return err
+}
+
+func (i *ICR) iamEndpoint(registry name.Registry) string {
+	switch {
+	case i.IAMEndpoint != "":
+		return i.IAMEndpoint
+
+	case strings.Contains(registry.RegistryStr(), "stg.icr.io"):
+		return "https://iam.test.cloud.ibm.com/identity/token"
+
+	default:
+		return "https://iam.cloud.ibm.com/identity/token"
+	}
+}
+
+func (i *ICR) login(ctx context.Context, config Config, registry name.Registry) (string, string, error) {
+	data := fmt.Sprintf("grant_type=%s&apikey=%s",
+		url.QueryEscape("urn:ibm:params:oauth:grant-type:apikey"),
+		config.Auth.Password,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.iamEndpoint(registry), strings.NewReader(data))
+	if err != nil {
+		return "", "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := config.Client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		type ibmCloudIdentityToken struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+			TokenType    string `json:"token_type"`
+			Scope        string `json:"scope"`
+			ExpiresIn    int64  `json:"expires_in"`
+			Expiration   int64  `json:"expiration"`
+		}
+
+		var identityToken ibmCloudIdentityToken
+		if err := json.Unmarshal(body, &identityToken); err != nil {
+			return "", "", err
+		}
+
+		var token = fmt.Sprintf("%s %s", identityToken.TokenType, identityToken.AccessToken)
+
+		var accountID string
+		_, _ = jwt.Parse(identityToken.AccessToken, func(t *jwt.Token) (interface{}, error) {
+			switch obj := t.Claims.(type) {
+			case jwt.MapClaims:
+				if account, ok := obj["account"]; ok {
+					switch accountMap := account.(type) {
+					case map[string]interface{}:
+						switch tmp := accountMap["bss"].(type) {
+						case string:
+							accountID = tmp
+						}
+					}
+				}
+			}
+
+			return nil, nil
+		})
+
+		if accountID == "" {
+			return "", "", fmt.Errorf("failed to obtain account ID from identity token")
+		}
+
+		return token, accountID, nil
+
+	default:
+		var responseMsg map[string]interface{}
+		if err := json.Unmarshal(body, &responseMsg); err != nil {
+			return "", "", err
+		}
+
+		errorCode, errorCodeFound := responseMsg["errorCode"]
+		errorMessage, errorMessageFound := responseMsg["errorMessage"]
+		if errorCodeFound && errorMessageFound {
+			return "", "", fmt.Errorf("failed to obtain identity token from IAM: %v (%v)", errorMessage, errorCode)
+		}
+
+		return "", "", fmt.Errorf("failed to obtain identity token from IAM: %s", string(body))
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Config contains the settings to access the registry and the API of its provider
+type Config struct {
+	// Client is used for the requests to the API of the provider
+	Client *http.Client
+
+	// Auth contains the credentials for the registry
+	Auth authn.AuthConfig
+
+	// Options are used for the requests to the registry
+	Options []remote.Option
+}
+
+// Image identifies the image to delete, the tag is empty if the image
+// was referenced by its digest
+type Image struct {
+	Repository name.Repository
+	Tag        string
+	Digest     string
+}
+
+// String returns the reference of the image with the tag and the digest
+func (i Image) String() string {
+	if i.Tag == "" {
+		return fmt.Sprintf("%s@%s", i.Repository.Name(), i.Digest)
+	}
+
+	return fmt.Sprintf("%s:%s@%s", i.Repository.Name(), i.Tag, i.Digest)
+}
+
+func (i Image) digestReference() name.Digest {
+	return i.Repository.Digest(i.Digest)
+}
+
+func (i Image) tagReference() name.Tag {
+	if i.Tag == "" {
+		return i.Repository.Tag(name.DefaultTag)
+	}
+
+	return i.Repository.Tag(i.Tag)
+}
+
+// Provider deletes images with the API of a specific registry product
+type Provider interface {
+	// Name returns the name of the registry product
+	Name() string
+
+	// Detect returns whether the registry is an instance of the registry product,
+	// which can require requests to the registry
+	Detect(ctx context.Context, config Config, registry name.Registry) bool
+
+	// Delete deletes the image, and returns an UnsupportedError if the API of
+	// the registry product cannot delete it
+	Delete(ctx context.Context, config Config, image Image) error
+}
+
+// UnsupportedError indicates that a provider cannot delete an image
+type UnsupportedError struct {
+	Provider string
+	Reason   string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("deleting the image with the %s API is not supported: %s", e.Provider, e.Reason)
+}
+
+// IsUnsupported returns true if the error is or wraps an UnsupportedError
+func IsUnsupported(err error) bool {
+	var unsupportedError *UnsupportedError
+	return errors.As(err, &unsupportedError)
+}
+
+// DefaultProviders returns the providers of all supported registry products, the
+// providers that detect the registry by its name come before the ones that probe it
+func DefaultProviders() []Provider {
+	return []Provider{
+		&DockerHub{},
+		&ICR{},
+		&GHCR{},
+		&Quay{},
+		&GitLab{},
+		&Harbor{},
+	}
+}
+
+// Prune deletes the image with the API of the first provider that detects the
+// registry. If no provider detects the registry, or if the provider does not
+// support deleting the image, the delete API of the OCI distribution specification
+// is used. It returns the name of the provider that deleted the image.
+func Prune(ctx context.Context, config Config, image Image, providers ...Provider) (string, error) {
+	fallback := &Distribution{}
+
+	for _, provider := range providers {
+		if !provider.Detect(ctx, config, image.Repository.Registry) {
+			continue
+		}
+
+		err := provider.Delete(ctx, config, image)
+		if !IsUnsupported(err) {
+			return provider.Name(), err
+		}
+
+		if fallbackErr := fallback.Delete(ctx, config, image); fallbackErr != nil {
+			return fallback.Name(), fmt.Errorf("%v, and the fallback failed: %w", err, fallbackErr)
+		}
+
+		return fallback.Name(), nil
+	}
+
+	return fallback.Name(), fallback.Delete(ctx, config, image)
+}
+
+// baseURL returns the URL of the registry with the scheme that it uses
+func baseURL(registry name.Registry) string {
+	return fmt.Sprintf("%s://%s", registry.Scheme(), registry.RegistryStr())
+}
+
+// call sends a request to the API of a provider and decodes the JSON response into the
+// result if it is not nil. A response with an unexpected status code is returned as error,
+// the status code is returned in all cases where a response was received.
+func call(ctx context.Context, client *http.Client, method string, url string, header http.Header, result interface{}, expected ...int) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	for _, code := range expected {
+		if resp.StatusCode != code {
+			continue
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(body, result); err != nil {
+				return resp.StatusCode, fmt.Errorf("failed to parse the response of %s %s: %w", method, url, err)
+			}
+		}
+
+		return resp.StatusCode, nil
+	}
+
+	return resp.StatusCode, fmt.Errorf("request %s %s failed: %s (HTTP status code %d)",
+		method,
+		url,
+		strings.TrimSpace(string(body)),
+		resp.StatusCode,
+	)
+}
@@ -0,0 +1,314 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	. "github.com/shipwright-io/build/pkg/prune"
+)
+
+const testDigest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+// stub is a local HTTP server that records the requests it receives, it routes
+// requests by their escaped path, patterns that end with a slash match all subpaths
+type stub struct {
+	handlers map[string]http.Handler
+	host     string
+	requests []string
+}
+
+func newStub() *stub {
+	s := &stub{handlers: map[string]http.Handler{}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.EscapedPath()
+		s.requests = append(s.requests, fmt.Sprintf("%s %s", r.Method, path))
+
+		if handler, ok := s.handlers[path]; ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		// the longest matching pattern wins, like with http.ServeMux
+		var match string
+		for pattern := range s.handlers {
+			if strings.HasSuffix(pattern, "/") && strings.HasPrefix(path, pattern) && len(pattern) > len(match) {
+				match = pattern
+			}
+		}
+
+		if match == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		s.handlers[match].ServeHTTP(w, r)
+	}))
+	DeferCleanup(server.Close)
+
+	s.host = strings.TrimPrefix(server.URL, "http://")
+	return s
+}
+
+func (s *stub) Handle(pattern string, handler http.Handler) {
+	s.handlers[pattern] = handler
+}
+
+func (s *stub) HandleFunc(pattern string, handler http.HandlerFunc) {
+	s.handlers[pattern] = handler
+}
+
+func (s *stub) image(repository string, tag string) Image {
+	repo, err := name.NewRepository(fmt.Sprintf("%s/%s", s.host, repository))
+	Expect(err).ToNot(HaveOccurred())
+
+	return Image{Repository: repo, Tag: tag, Digest: testDigest}
+}
+
+// respond returns a handler that responds with the status code and body
+func respond(statusCode int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+var _ = Describe("Prune", func() {
+	var config Config
+
+	BeforeEach(func() {
+		config = Config{Client: http.DefaultClient}
+	})
+
+	Context("using the OCI distribution delete API", func() {
+		It("should delete an image from a registry", func() {
+			server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			DeferCleanup(server.Close)
+
+			tag, err := name.NewTag(fmt.Sprintf("%s/test/image:latest", strings.TrimPrefix(server.URL, "http://")))
+			Expect(err).ToNot(HaveOccurred())
+
+			img, err := random.Image(128, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(remote.Write(tag, img)).To(Succeed())
+
+			digest, err := img.Digest()
+			Expect(err).ToNot(HaveOccurred())
+
+			provider, err := Prune(context.TODO(), config, Image{Repository: tag.Context(), Tag: tag.TagStr(), Digest: digest.String()}, DefaultProviders()...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provider).To(Equal("OCI distribution"))
+
+			_, err = remote.Head(tag.Context().Digest(digest.String()))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fall back to delete the tag if the registry does not allow to delete by digest", func() {
+			s := newStub()
+			s.Handle("/v2/", respond(http.StatusOK, ""))
+			s.Handle("/v2/test/image/manifests/"+testDigest, respond(http.StatusMethodNotAllowed, `{"errors":[{"code":"UNSUPPORTED"}]}`))
+			s.Handle("/v2/test/image/manifests/latest", respond(http.StatusAccepted, ""))
+
+			Expect((&Distribution{}).Delete(context.TODO(), config, s.image("test/image", "latest"))).To(Succeed())
+			Expect(s.requests).To(ContainElement("DELETE /v2/test/image/manifests/latest"))
+		})
+
+		It("should report that the registry does not support deletion", func() {
+			s := newStub()
+			s.Handle("/v2/", respond(http.StatusOK, ""))
+			s.Handle("/v2/test/image/manifests/", respond(http.StatusMethodNotAllowed, `{"errors":[{"code":"UNSUPPORTED"}]}`))
+
+			err := (&Distribution{}).Delete(context.TODO(), config, s.image("test/image", "latest"))
+			Expect(IsUnsupported(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("deleting the image with the OCI distribution API is not supported")))
+		})
+	})
+
+	Context("using the Quay API", func() {
+		var s *stub
+
+		BeforeEach(func() {
+			s = newStub()
+			s.Handle("/v2/", respond(http.StatusOK, ""))
+			s.Handle("/api/v1/discovery", respond(http.StatusOK, `{}`))
+		})
+
+		It("should detect the registry and delete the tag", func() {
+			s.HandleFunc("/api/v1/repository/team/app/tag/latest", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal(http.MethodDelete))
+				Expect(r.Header.Get("Authorization")).To(Equal("Bearer oauth-token"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			config.Auth = authn.AuthConfig{Username: "$oauthtoken", Password: "oauth-token"}
+			provider, err := Prune(context.TODO(), config, s.image("team/app", "latest"), DefaultProviders()...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provider).To(Equal("Quay"))
+		})
+
+		It("should fall back to the OCI distribution delete API for robot account credentials", func() {
+			s.Handle("/v2/team/app/manifests/"+testDigest, respond(http.StatusAccepted, ""))
+
+			config.Auth = authn.AuthConfig{Username: "team+robot", Password: "secret"}
+			provider, err := Prune(context.TODO(), config, s.image("team/app", "latest"), DefaultProviders()...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provider).To(Equal("OCI distribution"))
+		})
+
+		It("should report both errors if the fallback is not supported either", func() {
+			s.Handle("/v2/team/app/manifests/", respond(http.StatusMethodNotAllowed, ""))
+
+			config.Auth = authn.AuthConfig{Username: "team+robot", Password: "secret"}
+			_, err := Prune(context.TODO(), config, s.image("team/app", "latest"), DefaultProviders()...)
+			Expect(IsUnsupported(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("the API requires an OAuth access token")))
+			Expect(err).To(MatchError(ContainSubstring("the registry does not allow the deletion of manifests")))
+		})
+	})
+
+	Context("using the Harbor API", func() {
+		It("should detect the registry and delete the artifact", func() {
+			s := newStub()
+			s.Handle("/api/v2.0/systeminfo", respond(http.StatusOK, `{"harbor_version":"v2.7.0"}`))
+			s.HandleFunc("/api/v2.0/projects/", func(w http.ResponseWriter, r *http.Request) {
+				username, password, ok := r.BasicAuth()
+				Expect(ok).To(BeTrue())
+				Expect(username).To(Equal("robot$ci"))
+				Expect(password).To(Equal("secret"))
+				w.WriteHeader(http.StatusOK)
+			})
+
+			config.Auth = authn.AuthConfig{Username: "robot$ci", Password: "secret"}
+			provider, err := Prune(context.TODO(), config, s.image("library/team/app", "latest"), DefaultProviders()...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provider).To(Equal("Harbor"))
+			Expect(s.requests).To(ContainElement("DELETE /api/v2.0/projects/library/repositories/team%252Fapp/artifacts/" + testDigest))
+		})
+	})
+
+	Context("using the GitLab API", func() {
+		var s *stub
+
+		announce := func(realm string) {
+			s.HandleFunc("/v2/", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/jwt/auth",service="container_registry"`, realm))
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+		}
+
+		BeforeEach(func() {
+			s = newStub()
+			s.Handle("/api/v4/projects/group%2Fproject%2Fapp/registry/repositories", respond(http.StatusNotFound, `{"message":"404 Project Not Found"}`))
+		})
+
+		It("should detect the registry by its token endpoint on the host of the registry", func() {
+			announce("https://127.0.0.1:8443")
+
+			registry := s.image("group/project/app", "v1").Repository.Registry
+			Expect((&GitLab{}).Detect(context.TODO(), config, registry)).To(BeTrue())
+		})
+
+		It("should not send the access token to a token endpoint without HTTPS", func() {
+			announce("http://" + s.host)
+
+			registry := s.image("group/project/app", "v1").Repository.Registry
+			Expect((&GitLab{}).Detect(context.TODO(), config, registry)).To(BeFalse())
+		})
+
+		It("should not send the access token to a token endpoint on another host", func() {
+			announce("https://gitlab.example.com")
+
+			registry := s.image("group/project/app", "v1").Repository.Registry
+			Expect((&GitLab{}).Detect(context.TODO(), config, registry)).To(BeFalse())
+		})
+
+		It("should delete the tag with the API of the configured GitLab instance", func() {
+			s.HandleFunc("/api/v4/projects/group%2Fproject/registry/repositories", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Private-Token")).To(Equal("access-token"))
+				_, _ = w.Write([]byte(`[{"id":1,"path":"group/project"},{"id":2,"path":"group/project/app"}]`))
+			})
+			s.Handle("/api/v4/projects/group%2Fproject/registry/repositories/2/tags/v1", respond(http.StatusOK, ""))
+
+			config.Auth = authn.AuthConfig{Username: "user", Password: "access-token"}
+			provider, err := Prune(context.TODO(), config, s.image("group/project/app", "v1"), &GitLab{APIEndpoint: "http://" + s.host})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provider).To(Equal("GitLab"))
+			Expect(s.requests).To(ContainElement("DELETE /api/v4/projects/group%2Fproject/registry/repositories/2/tags/v1"))
+		})
+
+		It("should report that deploy tokens cannot use the API", func() {
+			s.Handle("/api/v4/projects/group%2Fproject/registry/repositories", respond(http.StatusUnauthorized, `{"message":"401 Unauthorized"}`))
+
+			err := (&GitLab{APIEndpoint: "http://" + s.host}).Delete(context.TODO(), config, s.image("group/project/app", "v1"))
+			Expect(IsUnsupported(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("an access token with the api scope is required")))
+		})
+	})
+
+	Context("using the GitHub API", func() {
+		var (
+			s     *stub
+			image Image
+			ghcr  *GHCR
+		)
+
+		BeforeEach(func() {
+			s = newStub()
+			s.Handle("/users/shipwright-io", respond(http.StatusOK, `{"type":"Organization"}`))
+
+			repository, err := name.NewRepository("ghcr.io/shipwright-io/sample-go/source")
+			Expect(err).ToNot(HaveOccurred())
+
+			image = Image{Repository: repository, Tag: "latest", Digest: testDigest}
+			ghcr = &GHCR{APIEndpoint: "http://" + s.host}
+			config.Auth = authn.AuthConfig{Username: "user", Password: "github-token"}
+		})
+
+		It("should delete the package version with the digest", func() {
+			s.Handle("/orgs/shipwright-io/packages/container/sample-go%2Fsource/versions", respond(http.StatusOK, fmt.Sprintf(`[{"id":1,"name":"sha256:other"},{"id":2,"name":%q}]`, testDigest)))
+			s.HandleFunc("/orgs/shipwright-io/packages/container/sample-go%2Fsource/versions/2", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Authorization")).To(Equal("Bearer github-token"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			provider, err := Prune(context.TODO(), config, image, ghcr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provider).To(Equal("GitHub Container Registry"))
+			Expect(s.requests).To(ContainElement("DELETE /orgs/shipwright-io/packages/container/sample-go%2Fsource/versions/2"))
+		})
+
+		It("should delete the package if the digest is its last version", func() {
+			s.Handle("/orgs/shipwright-io/packages/container/sample-go%2Fsource/versions", respond(http.StatusOK, fmt.Sprintf(`[{"id":2,"name":%q}]`, testDigest)))
+			s.Handle("/orgs/shipwright-io/packages/container/sample-go%2Fsource", respond(http.StatusNoContent, ""))
+
+			Expect(ghcr.Delete(context.TODO(), config, image)).To(Succeed())
+			Expect(s.requests).To(ContainElement("DELETE /orgs/shipwright-io/packages/container/sample-go%2Fsource"))
+		})
+
+		It("should report that the token is not authorized to delete packages", func() {
+			s.Handle("/orgs/shipwright-io/packages/container/sample-go%2Fsource/versions", respond(http.StatusForbidden, `{"message":"Resource not accessible by integration"}`))
+
+			err := ghcr.Delete(context.TODO(), config, image)
+			Expect(IsUnsupported(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("a token with the delete:packages scope is required")))
+		})
+	})
+})
@@ -0,0 +1,56 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// quayOAuthUsername is the username of the credentials of an OAuth access token
+const quayOAuthUsername = "$oauthtoken"
+
+// Quay deletes tags with the API of Quay, which is authorized with an OAuth access
+// token. The credentials of robot accounts only grant access to the registry.
+type Quay struct{}
+
+// Name implements Provider
+func (q *Quay) Name() string {
+	return "Quay"
+}
+
+// Detect implements Provider, self-hosted instances are detected by their discovery endpoint
+func (q *Quay) Detect(ctx context.Context, config Config, registry name.Registry) bool {
+	if registry.RegistryStr() == "quay.io" {
+		return true
+	}
+
+	statusCode, err := call(ctx, config.Client, http.MethodGet, baseURL(registry)+"/api/v1/discovery", nil, nil, http.StatusOK)
+	return err == nil && statusCode == http.StatusOK
+}
+
+// Delete implements Provider
+func (q *Quay) Delete(ctx context.Context, config Config, image Image) error {
+	if image.Tag == "" {
+		return &UnsupportedError{Provider: q.Name(), Reason: "the API deletes tags, but the image is referenced by its digest"}
+	}
+
+	if config.Auth.Username != quayOAuthUsername {
+		return &UnsupportedError{Provider: q.Name(), Reason: fmt.Sprintf("the API requires an OAuth access token with the username %s", quayOAuthUsername)}
+	}
+
+	_, err := call(ctx, config.Client, http.MethodDelete,
+		fmt.Sprintf("%s/api/v1/repository/%s/tag/%s", baseURL(image.Repository.Registry), image.Repository.RepositoryStr(), url.PathEscape(image.Tag)),
+		http.Header{"Authorization": []string{"Bearer " + config.Auth.Password}},
+		nil,
+		http.StatusNoContent,
+	)
+
+	return err
+}
@@ -26,6 +26,7 @@ import (
 var validationTypes = [...]string{
 	validate.OwnerReferences,
 	validate.SourceURL,
+	validate.SourceArchive,
 	validate.Secrets,
 	validate.Strategies,
 	validate.Sources,
@@ -158,6 +158,11 @@ func add(ctx context.Context, mgr manager.Manager, r reconcile.Reconciler, maxCo
 					flagReconcile = true
 				}
 			}
+			if build.Spec.Source.SignatureVerification != nil && build.Spec.Source.SignatureVerification.SecretRef != nil {
+				if build.Spec.Source.SignatureVerification.SecretRef.Name == secret.Name {
+					flagReconcile = true
+				}
+			}
 			if build.Spec.Output.Credentials != nil {
 				if build.Spec.Output.Credentials.Name == secret.Name {
 					flagReconcile = true
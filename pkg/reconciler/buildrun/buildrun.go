@@ -51,6 +51,7 @@ type ReconcileBuildRun struct {
 	client                client.Client
 	scheme                *runtime.Scheme
 	setOwnerReferenceFunc setOwnerReferenceFunc
+	resolveImageDigest    resources.ImageDigestResolver
 }
 
 // NewReconciler returns a new reconcile.Reconciler
@@ -60,6 +61,7 @@ func NewReconciler(c *config.Config, mgr manager.Manager, ownerRef setOwnerRefer
 		client:                mgr.GetClient(),
 		scheme:                mgr.GetScheme(),
 		setOwnerReferenceFunc: ownerRef,
+		resolveImageDigest:    resources.ResolveImageDigest,
 	}
 }
 
@@ -155,6 +157,7 @@ func (r *ReconcileBuildRun) Reconcile(ctx context.Context, request reconcile.Req
 				case buildRun.Spec.BuildSpec != nil:
 					err := validate.All(ctx,
 						validate.NewSourceURL(r.client, build),
+						validate.NewSourceArchive(build),
 						validate.NewCredentials(r.client, build),
 						validate.NewStrategies(r.client, build),
 						validate.NewSourcesRef(build),
@@ -290,6 +293,36 @@ func (r *ReconcileBuildRun) Reconcile(ctx context.Context, request reconcile.Req
 				return reconcile.Result{}, nil
 			}
 
+			// Validate the secret mounts
+			valid, reason, message = validate.BuildRunSecretMounts(strategy.GetSecrets(), build.Spec.Secrets, buildRun.Spec.Secrets)
+			if !valid {
+				if err := resources.UpdateConditionWithFalseStatus(ctx, r.client, buildRun, message, reason); err != nil {
+					return reconcile.Result{}, err
+				}
+				return reconcile.Result{}, nil
+			}
+
+			// Validate that the secrets of the secret mounts exist
+			valid, reason, message, err = validate.BuildRunSecretMountsExist(ctx, r.client, buildRun.Namespace, build.Spec.Secrets, buildRun.Spec.Secrets)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if !valid {
+				if err := resources.UpdateConditionWithFalseStatus(ctx, r.client, buildRun, message, reason); err != nil {
+					return reconcile.Result{}, err
+				}
+				return reconcile.Result{}, nil
+			}
+
+			// Validate that the namespace is allowed to use the strategy
+			valid, reason, message = validate.BuildRunStrategySecurity(r.config.StrategySecurity, strategy, buildRun.Namespace)
+			if !valid {
+				if err := resources.UpdateConditionWithFalseStatus(ctx, r.client, buildRun, message, reason); err != nil {
+					return reconcile.Result{}, err
+				}
+				return reconcile.Result{}, nil
+			}
+
 			// Create the TaskRun, this needs to be the last step in this block to be idempotent
 			generatedTaskRun, err := r.createTaskRun(ctx, svcAccount, strategy, build, buildRun)
 			if err != nil {
@@ -301,6 +334,12 @@ func (r *ReconcileBuildRun) Reconcile(ctx context.Context, request reconcile.Req
 				return reconcile.Result{}, err
 			}
 
+			// Create the Secret with the token that authenticates the upload of local source code
+			if err := r.createUploadTokenSecret(ctx, build, buildRun); err != nil {
+				// system call failure, reconcile again
+				return reconcile.Result{}, err
+			}
+
 			err = resources.CheckTaskRunVolumesExist(ctx, r.client, generatedTaskRun)
 			// if resource is not found, fais the build run
 			if err != nil {
@@ -317,6 +356,27 @@ func (r *ReconcileBuildRun) Reconcile(ctx context.Context, request reconcile.Req
 				return reconcile.Result{}, err
 			}
 
+			// Pin the step images to their digests so that the BuildRun stays reproducible when tags move
+			if r.config.PinStepImageDigests {
+				// the step images are resolved with the registry credentials the TaskRun POD uses to pull them
+				keychain, err := resources.ServiceAccountKeychain(ctx, r.client, svcAccount)
+				if err != nil {
+					return reconcile.Result{}, err
+				}
+
+				stepImages, err := resources.PinStepImages(ctx, generatedTaskRun, r.resolveImageDigest, keychain)
+				if err != nil {
+					if err := resources.UpdateConditionWithFalseStatus(ctx, r.client, buildRun, err.Error(), resources.BuildRunStepImageResolutionFailed); err != nil {
+						return reconcile.Result{}, err
+					}
+
+					// end of reconciliation
+					return reconcile.Result{}, nil
+				}
+
+				buildRun.Status.StepImages = stepImages
+			}
+
 			ctxlog.Info(ctx, "creating TaskRun from BuildRun", namespace, request.Namespace, name, generatedTaskRun.GenerateName, "BuildRun", buildRun.Name)
 			if err = r.client.Create(ctx, generatedTaskRun); err != nil {
 				// system call failure, reconcile again
@@ -391,6 +451,18 @@ func (r *ReconcileBuildRun) Reconcile(ctx context.Context, request reconcile.Req
 			resources.UpdateBuildRunUsingTaskResults(ctx, buildRun, lastTaskRun.Status.TaskRunResults, request)
 		}
 
+		resources.UpdateBuildRunUsingLocalCopyState(buildRun, lastTaskRun)
+
+		// the POD tells whether the strategy steps run without network access
+		if buildRun.Status.BuildSpec.IsHermetic() && buildRun.Status.Hermetic == nil && lastTaskRun.Status.PodName != "" {
+			pod := &corev1.Pod{}
+			if err := r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: lastTaskRun.Status.PodName}, pod); err == nil {
+				buildRun.Status.Hermetic = pointer.Bool(resources.IsHermeticPod(pod))
+			} else if !apierrors.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+		}
+
 		trCondition := lastTaskRun.Status.GetCondition(apis.ConditionSucceeded)
 		if trCondition != nil {
 			if err := resources.UpdateBuildRunUsingTaskRunCondition(ctx, r.client, buildRun, lastTaskRun, trCondition); err != nil {
@@ -576,6 +648,26 @@ func (r *ReconcileBuildRun) createTaskRun(ctx context.Context, serviceAccount *c
 	return generatedTaskRun, nil
 }
 
+// createUploadTokenSecret creates the Secret with the token that authenticates the upload of the
+// local source code, the Secret is owned by the BuildRun and kept if it already exists
+func (r *ReconcileBuildRun) createUploadTokenSecret(ctx context.Context, build *buildv1alpha1.Build, buildRun *buildv1alpha1.BuildRun) error {
+	secret, err := resources.GenerateUploadTokenSecret(build, buildRun)
+	if err != nil || secret == nil {
+		return err
+	}
+
+	if err := r.setOwnerReferenceFunc(buildRun, secret, r.scheme); err != nil {
+		return err
+	}
+
+	ctxlog.Info(ctx, "creating upload token Secret for BuildRun", namespace, buildRun.Namespace, name, buildRun.Name, "Secret", secret.Name)
+	if err := r.client.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
 type patchStringValue struct {
 	Op    string `json:"op"`
 	Path  string `json:"path"`
@@ -1012,6 +1012,45 @@ var _ = Describe("Reconcile BuildRun", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
+			It("creates an upload token Secret owned by the BuildRun for a LocalCopy source", func() {
+				buildRunSample.Spec.Sources = []build.BuildSource{{Name: "local", Type: build.LocalCopy}}
+
+				stub := ctl.StubBuildRunGetWithSAandStrategies(
+					buildSample,
+					buildRunSample,
+					ctl.DefaultServiceAccount(saName),
+					ctl.DefaultClusterBuildStrategy(),
+					ctl.DefaultNamespacedBuildStrategy(),
+				)
+				client.GetCalls(func(ctx context.Context, nn types.NamespacedName, object crc.Object, opts ...crc.GetOption) error {
+					// the upload token Secret exists once it was created
+					if _, ok := object.(*corev1.Secret); ok && nn.Name == buildRunName+"-upload-token" {
+						return nil
+					}
+					return stub(ctx, nn, object, opts...)
+				})
+
+				var secret *corev1.Secret
+				client.CreateCalls(func(_ context.Context, object crc.Object, _ ...crc.CreateOption) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						secret = object
+					case *v1beta1.TaskRun:
+						ctl.DefaultTaskRunWithStatus(taskRunName, buildRunName, ns, corev1.ConditionTrue, "Succeeded").DeepCopyInto(object)
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(context.TODO(), buildRunRequest)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.CreateCallCount()).To(Equal(2))
+				Expect(secret).ToNot(BeNil())
+				Expect(secret.Name).To(Equal(buildRunName + "-upload-token"))
+				Expect(secret.Data).To(HaveKey("token"))
+				Expect(secret.OwnerReferences).To(ContainElement(HaveField("Name", buildRunName)))
+			})
+
 			It("stops creation when a FALSE registered status of the build occurs", func() {
 				// Init the Build with registered status false
 				buildSample = ctl.DefaultBuildWithFalseRegistered(buildName, strategyName, build.ClusterBuildStrategyKind)
@@ -96,6 +96,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler, maxConcurrentReconciles in
 					return true
 				}
 			}
+
+			// Process an update event when a step emitted results, which surfaces the results of the
+			// source steps, like the state of the waiter for a local source, while the TaskRun is running
+			if len(o.Status.TaskRunResults) != len(n.Status.TaskRunResults) {
+				return true
+			}
 			return false
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
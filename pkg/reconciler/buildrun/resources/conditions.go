@@ -44,6 +44,7 @@ const (
 	BuildRunNoRefOrSpec                              string = "BuildRunNoRefOrSpec"
 	BuildRunAmbiguousBuild                           string = "BuildRunAmbiguousBuild"
 	BuildRunBuildFieldOverrideForbidden              string = "BuildRunBuildFieldOverrideForbidden"
+	BuildRunStepImageResolutionFailed                string = "BuildRunStepImageResolutionFailed"
 )
 
 // UpdateBuildRunUsingTaskRunCondition updates the BuildRun Succeeded Condition
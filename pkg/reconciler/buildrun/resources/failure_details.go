@@ -16,6 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/git"
 )
 
 const (
@@ -92,6 +93,7 @@ func extractFailureDetails(ctx context.Context, client client.Client, taskRun *v
 	failure = &buildv1alpha1.FailureDetails{}
 
 	failure.Reason, failure.Message = extractFailureReasonAndMessage(taskRun)
+	failure.Retryable = git.IsRetryableReason(failure.Reason)
 
 	failure.Location = &buildv1alpha1.FailedAt{Pod: taskRun.Status.PodName}
 	pod, container, _ := extractFailedPodAndContainer(ctx, client, taskRun)
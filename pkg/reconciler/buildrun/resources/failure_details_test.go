@@ -17,6 +17,7 @@ import (
 
 	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	buildfakes "github.com/shipwright-io/build/pkg/controller/fakes"
+	"github.com/shipwright-io/build/pkg/git"
 )
 
 var _ = Describe("Surfacing errors", func() {
@@ -51,6 +52,29 @@ var _ = Describe("Surfacing errors", func() {
 
 			Expect(redBuild.Status.FailureDetails.Message).To(Equal(errorMessageValue))
 			Expect(redBuild.Status.FailureDetails.Reason).To(Equal(errorReasonValue))
+			Expect(redBuild.Status.FailureDetails.Retryable).To(BeFalse())
+		})
+
+		It("marks transient Git errors as retryable", func() {
+			redTaskRun := pipelinev1beta1.TaskRun{}
+			redTaskRun.Status.Conditions = append(redTaskRun.Status.Conditions,
+				apis.Condition{Type: apis.ConditionSucceeded, Reason: pipelinev1beta1.TaskRunReasonFailed.String()})
+			failedStep := pipelinev1beta1.StepState{}
+
+			errorReason := pipelinev1beta1.PipelineResourceResult{Key: prefixedResultErrorReason, Value: git.Timeout.String()}
+			errorMessage := pipelinev1beta1.PipelineResourceResult{Key: prefixedResultErrorMessage, Value: git.Timeout.ToMessage()}
+
+			message, _ := json.Marshal([]pipelinev1beta1.PipelineResourceResult{errorReason, errorMessage})
+
+			failedStep.Terminated = &corev1.ContainerStateTerminated{Message: string(message), ExitCode: 1}
+
+			redTaskRun.Status.Steps = append(redTaskRun.Status.Steps, failedStep)
+			redBuild := buildv1alpha1.BuildRun{}
+
+			UpdateBuildRunUsingTaskFailures(ctx, client, &redBuild, &redTaskRun)
+
+			Expect(redBuild.Status.FailureDetails.Reason).To(Equal(git.Timeout.String()))
+			Expect(redBuild.Status.FailureDetails.Retryable).To(BeTrue())
 		})
 
 		It("does not surface unrelated Tekton resources if the TaskRun fails", func() {
@@ -6,6 +6,7 @@ package resources
 
 import (
 	"fmt"
+	"sort"
 
 	build "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/config"
@@ -18,10 +19,15 @@ const (
 	containerNameImageProcessing = "image-processing"
 	outputDirectoryMountPath     = "/workspace/output-image"
 	paramOutputDirectory         = "output-directory"
+
+	annotationImageCreated  = "org.opencontainers.image.created"
+	annotationImageRevision = "org.opencontainers.image.revision"
+	annotationImageSource   = "org.opencontainers.image.source"
+	annotationImageTitle    = "org.opencontainers.image.title"
 )
 
 // SetupImageProcessing appends the image-processing step to a TaskRun if desired
-func SetupImageProcessing(taskRun *pipeline.TaskRun, cfg *config.Config, buildOutput, buildRunOutput build.Image) {
+func SetupImageProcessing(taskRun *pipeline.TaskRun, cfg *config.Config, buildOutput, buildRunOutput build.Image, network *build.BuildNetwork) {
 	stepArgs := []string{}
 
 	// Check if any build step references the output-directory system parameter. If that is the case,
@@ -82,6 +88,13 @@ func SetupImageProcessing(taskRun *pipeline.TaskRun, cfg *config.Config, buildOu
 		stepArgs = append(stepArgs, convertMutateArgs("--label", labels)...)
 	}
 
+	// check if the labels or annotations reference results of the source step, the
+	// image-processing step reads them from the result files of the source step
+	sourceResultFiles := sources.SourceResultFiles(taskRun.Spec.TaskSpec, defaultSourceName, annotations, labels)
+	if len(sourceResultFiles) > 0 {
+		stepArgs = append(stepArgs, convertSourceResultArgs(sourceResultFiles)...)
+	}
+
 	// check if there is anything to do
 	if len(stepArgs) > 0 {
 		// add the image argument
@@ -125,13 +138,61 @@ func SetupImageProcessing(taskRun *pipeline.TaskRun, cfg *config.Config, buildOu
 			imageProcessingStep.Args = append(imageProcessingStep.Args,
 				"--secret-path", secretMountPath,
 			)
+		} else if cfg.RegistryToken.IsConfigured() {
+			// without a push secret, use short-lived credentials based on the ServiceAccount token
+			sources.AppendRegistryTokenVolume(taskRun.Spec.TaskSpec, &imageProcessingStep, cfg.RegistryToken)
 		}
 
+		appendNetworkSettings(taskRun.Spec.TaskSpec, &imageProcessingStep, sources.NetworkOptions(cfg, network))
+
 		// append the mutate step
 		taskRun.Spec.TaskSpec.Steps = append(taskRun.Spec.TaskSpec.Steps, imageProcessingStep)
 	}
 }
 
+// useStandardAnnotations returns whether the standard annotations are applied on the image,
+// the setting of the BuildRun output takes precedence over the one of the Build output
+func useStandardAnnotations(buildOutput, buildRunOutput build.Image) bool {
+	if buildRunOutput.StandardAnnotations != nil {
+		return *buildRunOutput.StandardAnnotations
+	}
+
+	return buildOutput.StandardAnnotations != nil && *buildOutput.StandardAnnotations
+}
+
+// standardAnnotations returns the OCI annotations that describe the source of the image, and the
+// annotations that identify the Build and BuildRun. Values that are only known after the source was
+// obtained reference the results of the source step, which the image-processing step resolves.
+func standardAnnotations(buildObject *build.Build, buildRun *build.BuildRun) map[string]string {
+	annotations := map[string]string{
+		build.LabelBuildRun:  buildRun.Name,
+		annotationImageTitle: buildRun.Name,
+	}
+
+	// embedded builds do not have a name
+	if buildObject.Name != "" {
+		annotations[build.LabelBuild] = buildObject.Name
+		annotations[annotationImageTitle] = buildObject.Name
+	}
+
+	switch {
+	case buildObject.Spec.Source.BundleContainer != nil:
+		annotations[annotationImageSource] = buildObject.Spec.Source.BundleContainer.Image
+		annotations[annotationImageRevision] = "$(source.image-digest)"
+
+	case buildObject.Spec.Source.Archive != nil:
+		annotations[annotationImageSource] = buildObject.Spec.Source.Archive.URL
+		annotations[annotationImageRevision] = "$(source.digest)"
+
+	case buildObject.Spec.Source.URL != nil:
+		annotations[annotationImageSource] = "$(source.url)"
+		annotations[annotationImageRevision] = "$(source.commit-sha)"
+		annotations[annotationImageCreated] = "$(source.commit-timestamp)"
+	}
+
+	return annotations
+}
+
 // convertMutateArgs to convert the argument map to comma seprated values
 func convertMutateArgs(flag string, args map[string]string) []string {
 	var result []string
@@ -143,6 +204,23 @@ func convertMutateArgs(flag string, args map[string]string) []string {
 	return result
 }
 
+// convertSourceResultArgs converts the source result files to arguments in a stable order
+func convertSourceResultArgs(resultFiles map[string]string) []string {
+	names := make([]string, 0, len(resultFiles))
+	for name := range resultFiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var result []string
+	for _, name := range names {
+		result = append(result, "--source-result", fmt.Sprintf("%s=%s", name, resultFiles[name]))
+	}
+
+	return result
+}
+
 // mergeMaps takes 2 maps as input and merge the second into the first
 // values in second would takes precedence if both maps have same keys
 func mergeMaps(first map[string]string, second map[string]string) map[string]string {
@@ -40,7 +40,7 @@ var _ = Describe("Image Processing overrides", func() {
 				processedTaskRun = taskRun.DeepCopy()
 				resources.SetupImageProcessing(processedTaskRun, config, buildv1alpha1.Image{
 					Image: "some-registry/some-namespace/some-image",
-				}, buildv1alpha1.Image{})
+				}, buildv1alpha1.Image{}, nil)
 			})
 
 			It("does not add the image-processing step", func() {
@@ -57,7 +57,7 @@ var _ = Describe("Image Processing overrides", func() {
 					Labels: map[string]string{
 						"aKey": "aLabel",
 					},
-				}, buildv1alpha1.Image{})
+				}, buildv1alpha1.Image{}, nil)
 			})
 
 			It("adds the image-processing step", func() {
@@ -79,6 +79,113 @@ var _ = Describe("Image Processing overrides", func() {
 				Expect(processedTaskRun.Spec.TaskSpec.Steps[1].VolumeMounts).ToNot(utils.ContainNamedElement("shp-output-directory"))
 			})
 		})
+		Context("for a build with a label in the output and short-lived registry credentials", func() {
+			BeforeEach(func() {
+				tokenConfig := *config
+				tokenConfig.RegistryToken.Audience = "sts.example.com"
+				tokenConfig.RegistryToken.ExchangeEndpoint = "https://sts.example.com/token"
+
+				processedTaskRun = taskRun.DeepCopy()
+				resources.SetupImageProcessing(processedTaskRun, &tokenConfig, buildv1alpha1.Image{
+					Image: "some-registry/some-namespace/some-image",
+					Labels: map[string]string{
+						"aKey": "aLabel",
+					},
+				}, buildv1alpha1.Image{}, nil)
+			})
+
+			It("adds a projected ServiceAccount token volume", func() {
+				Expect(processedTaskRun.Spec.TaskSpec.Volumes).To(HaveLen(1))
+				Expect(processedTaskRun.Spec.TaskSpec.Volumes[0].Name).To(Equal("shp-registry-token"))
+				Expect(processedTaskRun.Spec.TaskSpec.Volumes[0].Projected.Sources[0].ServiceAccountToken.Audience).To(Equal("sts.example.com"))
+			})
+
+			It("configures the image-processing step to use the token", func() {
+				step := processedTaskRun.Spec.TaskSpec.Steps[1]
+				Expect(step.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      "shp-registry-token",
+					MountPath: "/var/run/secrets/shp-registry-token",
+					ReadOnly:  true,
+				}))
+				Expect(step.Args).To(ContainElements(
+					"--token-file", "/var/run/secrets/shp-registry-token/token",
+					"--token-exchange-endpoint", "https://sts.example.com/token",
+				))
+				Expect(step.Args).ToNot(ContainElement("--secret-path"))
+			})
+		})
+
+		Context("for a build with a label in the output and network settings", func() {
+			BeforeEach(func() {
+				networkConfig := *config
+				networkConfig.Network.CABundleConfigMapName = "cluster-ca"
+				networkConfig.Network.HTTPSProxy = "http://proxy.example.com:3128"
+
+				processedTaskRun = taskRun.DeepCopy()
+				resources.SetupImageProcessing(processedTaskRun, &networkConfig, buildv1alpha1.Image{
+					Image: "some-registry/some-namespace/some-image",
+					Labels: map[string]string{
+						"aKey": "aLabel",
+					},
+				}, buildv1alpha1.Image{}, &buildv1alpha1.BuildNetwork{
+					CABundle: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "team-ca"},
+						Key:                  "ca.crt",
+					},
+				})
+			})
+
+			It("mounts the certificate authority bundle of the build", func() {
+				Expect(processedTaskRun.Spec.TaskSpec.Volumes).To(HaveLen(1))
+				Expect(processedTaskRun.Spec.TaskSpec.Volumes[0].ConfigMap.Name).To(Equal("team-ca"))
+				Expect(processedTaskRun.Spec.TaskSpec.Volumes[0].ConfigMap.Items).To(Equal([]corev1.KeyToPath{{Key: "ca.crt", Path: "ca-bundle.crt"}}))
+
+				step := processedTaskRun.Spec.TaskSpec.Steps[1]
+				Expect(step.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      "shp-ca-bundle",
+					MountPath: "/workspace/shp-ca-bundle",
+					ReadOnly:  true,
+				}))
+				Expect(step.Args).To(ContainElements("--ca-bundle-path", "/workspace/shp-ca-bundle/ca-bundle.crt"))
+			})
+
+			It("sets the cluster-wide proxy on the image-processing step", func() {
+				Expect(processedTaskRun.Spec.TaskSpec.Steps[1].Env).To(ContainElements(
+					corev1.EnvVar{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"},
+					corev1.EnvVar{Name: "https_proxy", Value: "http://proxy.example.com:3128"},
+				))
+			})
+		})
+
+		Context("for a build with labels and annotations that reference source results", func() {
+			BeforeEach(func() {
+				processedTaskRun = taskRun.DeepCopy()
+				processedTaskRun.Spec.TaskSpec.Results = []pipeline.TaskResult{
+					{Name: "shp-source-default-commit-sha"},
+					{Name: "shp-source-default-url"},
+				}
+
+				resources.SetupImageProcessing(processedTaskRun, config, buildv1alpha1.Image{
+					Image: "some-registry/some-namespace/some-image",
+					Labels: map[string]string{
+						"org.opencontainers.image.revision": "$(source.commit-sha)",
+						"unknown":                           "$(source.unknown)",
+					},
+					Annotations: map[string]string{
+						"org.opencontainers.image.source": "$(source.url)",
+					},
+				}, buildv1alpha1.Image{}, nil)
+			})
+
+			It("passes the result files of the referenced source results to the image-processing step", func() {
+				step := processedTaskRun.Spec.TaskSpec.Steps[1]
+				Expect(step.Args).To(ContainElements(
+					"--source-result", "commit-sha=$(results.shp-source-default-commit-sha.path)",
+					"--source-result", "url=$(results.shp-source-default-url.path)",
+				))
+				Expect(step.Args).ToNot(ContainElement(HavePrefix("unknown=$(results.")))
+			})
+		})
 	})
 
 	Context("for a TaskRun that references the output directory", func() {
@@ -112,7 +219,7 @@ var _ = Describe("Image Processing overrides", func() {
 						Annotations: map[string]string{
 							"an-annotation": "some-value",
 						},
-					})
+					}, nil)
 				})
 
 				It("adds the output-directory parameter", func() {
@@ -153,7 +260,7 @@ var _ = Describe("Image Processing overrides", func() {
 					processedTaskRun = taskRun.DeepCopy()
 					resources.SetupImageProcessing(processedTaskRun, config, buildv1alpha1.Image{
 						Image: "some-registry/some-namespace/some-image",
-					}, buildv1alpha1.Image{})
+					}, buildv1alpha1.Image{}, nil)
 				})
 
 				It("adds the output-directory parameter", func() {
@@ -194,7 +301,7 @@ var _ = Describe("Image Processing overrides", func() {
 					Credentials: &corev1.LocalObjectReference{
 						Name: "some-secret",
 					},
-				}, buildv1alpha1.Image{})
+				}, buildv1alpha1.Image{}, nil)
 			})
 
 			It("adds the output-directory parameter", func() {
@@ -6,6 +6,7 @@ package resources_test
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -75,6 +76,99 @@ var _ = Describe("TaskRun results to BuildRun", func() {
 			Expect(br.Status.Sources[0].Git.CommitAuthor).To(Equal("foo bar"))
 		})
 
+		It("should surface the submodules emitting from default(git) source step", func() {
+			br.Status.BuildSpec.Source.URL = pointer.String("https://github.com/shipwright-io/sample-go")
+
+			tr.Status.TaskRunResults = append(tr.Status.TaskRunResults,
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-commit-sha",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "0e0583421a5e4bf562ffe33f3651e16ba0c78591",
+					},
+				},
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-submodules",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: `[{"path":"vendor/lib","commitSha":"8016b0437a7a09079f961e5003e81e5ad54e6c26"}]`,
+					},
+				})
+
+			resources.UpdateBuildRunUsingTaskResults(ctx, br, tr.Status.TaskRunResults, taskRunRequest)
+
+			Expect(len(br.Status.Sources)).To(Equal(1))
+			Expect(br.Status.Sources[0].Git.Submodules).To(Equal([]build.GitSubmoduleResult{
+				{Path: "vendor/lib", CommitSha: "8016b0437a7a09079f961e5003e81e5ad54e6c26"},
+			}))
+		})
+
+		It("should surface the commit metadata emitting from default(git) source step", func() {
+			br.Status.BuildSpec.Source.URL = pointer.String("https://github.com/shipwright-io/sample-go")
+
+			tr.Status.TaskRunResults = append(tr.Status.TaskRunResults,
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-commit-sha",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "0e0583421a5e4bf562ffe33f3651e16ba0c78591",
+					},
+				},
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-commit-timestamp",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "2023-02-01T10:00:00+01:00",
+					},
+				},
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-committer",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "foo bar",
+					},
+				},
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-commit-message",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "Release v1.0.0",
+					},
+				},
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-tags",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "latest,v1.0.0",
+					},
+				},
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-describe",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "v1.0.0",
+					},
+				},
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-url",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "https://github.com/shipwright-io/sample-go",
+					},
+				})
+
+			resources.UpdateBuildRunUsingTaskResults(ctx, br, tr.Status.TaskRunResults, taskRunRequest)
+
+			Expect(len(br.Status.Sources)).To(Equal(1))
+			Expect(br.Status.Sources[0].Git.CommitTimestamp).ToNot(BeNil())
+			Expect(br.Status.Sources[0].Git.CommitTimestamp.UTC().Format(time.RFC3339)).To(Equal("2023-02-01T09:00:00Z"))
+			Expect(br.Status.Sources[0].Git.Committer).To(Equal("foo bar"))
+			Expect(br.Status.Sources[0].Git.CommitMessage).To(Equal("Release v1.0.0"))
+			Expect(br.Status.Sources[0].Git.Tags).To(Equal([]string{"latest", "v1.0.0"}))
+			Expect(br.Status.Sources[0].Git.Describe).To(Equal("v1.0.0"))
+			Expect(br.Status.Sources[0].Git.URL).To(Equal("https://github.com/shipwright-io/sample-go"))
+		})
+
 		It("should surface the TaskRun results emitting from default(bundle) source step", func() {
 			bundleImageDigest := "sha256:fe1b73cd25ac3f11dec752755e2"
 			br.Status.BuildSpec.Source.BundleContainer = &build.BundleContainer{
@@ -96,6 +190,93 @@ var _ = Describe("TaskRun results to BuildRun", func() {
 			Expect(br.Status.Sources[0].Bundle.Digest).To(Equal(bundleImageDigest))
 		})
 
+		It("should surface the metadata of the bundle image emitting from default(bundle) source step", func() {
+			br.Status.BuildSpec.Source.BundleContainer = &build.BundleContainer{
+				Image: "ghcr.io/shipwright-io/sample-go/source-bundle:latest",
+			}
+
+			for name, value := range map[string]string{
+				"shp-source-default-image-digest":    "sha256:fe1b73cd25ac3f11dec752755e2",
+				"shp-source-default-directory":       "/home/dev/sample-go",
+				"shp-source-default-commit-sha":      "0e0583421a5e4bf562ffe33f3651e16ba0c78591",
+				"shp-source-default-ignore-patterns": `[".git","*.log"]`,
+				"shp-source-default-verified-files":  "42",
+				"shp-source-default-prune-warning":   "failed to delete image",
+			} {
+				tr.Status.TaskRunResults = append(tr.Status.TaskRunResults,
+					pipelinev1beta1.TaskRunResult{
+						Name: name,
+						Value: pipelinev1beta1.ArrayOrString{
+							Type:      pipelinev1beta1.ParamTypeString,
+							StringVal: value,
+						},
+					})
+			}
+
+			resources.UpdateBuildRunUsingTaskResults(ctx, br, tr.Status.TaskRunResults, taskRunRequest)
+
+			Expect(len(br.Status.Sources)).To(Equal(1))
+			Expect(br.Status.Sources[0].Bundle.Digest).To(Equal("sha256:fe1b73cd25ac3f11dec752755e2"))
+			Expect(br.Status.Sources[0].Bundle.Directory).To(Equal("/home/dev/sample-go"))
+			Expect(br.Status.Sources[0].Bundle.CommitSha).To(Equal("0e0583421a5e4bf562ffe33f3651e16ba0c78591"))
+			Expect(br.Status.Sources[0].Bundle.IgnorePatterns).To(Equal([]string{".git", "*.log"}))
+			Expect(br.Status.Sources[0].Bundle.VerifiedFiles).To(Equal(42))
+			Expect(br.Status.Sources[0].Bundle.PruneWarning).To(Equal("failed to delete image"))
+		})
+
+		It("should surface the TaskRun results emitting from the steps of named sources", func() {
+			br.Status.BuildSpec.Source.URL = pointer.String("https://github.com/shipwright-io/sample-go")
+			br.Status.BuildSpec.Sources = []build.BuildSource{
+				{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config"},
+				{Name: "assets", Type: build.Bundle, BundleContainer: &build.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets:latest"}},
+			}
+
+			for name, value := range map[string]string{
+				"shp-source-default-commit-sha":  "0e0583421a5e4bf562ffe33f3651e16ba0c78591",
+				"shp-source-config-commit-sha":   "f25822b85021d02059c9ac8a211ef3804ea8fdde",
+				"shp-source-assets-image-digest": "sha256:fe1b73cd25ac3f11dec752755e2",
+			} {
+				tr.Status.TaskRunResults = append(tr.Status.TaskRunResults,
+					pipelinev1beta1.TaskRunResult{
+						Name: name,
+						Value: pipelinev1beta1.ArrayOrString{
+							Type:      pipelinev1beta1.ParamTypeString,
+							StringVal: value,
+						},
+					})
+			}
+
+			resources.UpdateBuildRunUsingTaskResults(ctx, br, tr.Status.TaskRunResults, taskRunRequest)
+
+			Expect(len(br.Status.Sources)).To(Equal(3))
+			Expect(br.Status.Sources[0].Name).To(Equal("default"))
+			Expect(br.Status.Sources[0].Git.CommitSha).To(Equal("0e0583421a5e4bf562ffe33f3651e16ba0c78591"))
+			Expect(br.Status.Sources[1].Name).To(Equal("config"))
+			Expect(br.Status.Sources[1].Git.CommitSha).To(Equal("f25822b85021d02059c9ac8a211ef3804ea8fdde"))
+			Expect(br.Status.Sources[2].Name).To(Equal("assets"))
+			Expect(br.Status.Sources[2].Bundle.Digest).To(Equal("sha256:fe1b73cd25ac3f11dec752755e2"))
+		})
+
+		It("should surface the TaskRun results emitting from default(archive) source step", func() {
+			br.Status.BuildSpec.Source.Archive = &build.Archive{
+				URL: "oci://ghcr.io/shipwright-io/sample-go/source:latest",
+			}
+
+			tr.Status.TaskRunResults = append(tr.Status.TaskRunResults,
+				pipelinev1beta1.TaskRunResult{
+					Name: "shp-source-default-digest",
+					Value: pipelinev1beta1.ArrayOrString{
+						Type:      pipelinev1beta1.ParamTypeString,
+						StringVal: "sha256:fe1b73cd25ac3f11dec752755e2",
+					},
+				})
+
+			resources.UpdateBuildRunUsingTaskResults(ctx, br, tr.Status.TaskRunResults, taskRunRequest)
+
+			Expect(len(br.Status.Sources)).To(Equal(1))
+			Expect(br.Status.Sources[0].Archive.Digest).To(Equal("sha256:fe1b73cd25ac3f11dec752755e2"))
+		})
+
 		It("should surface the TaskRun results emitting from output step", func() {
 			imageDigest := "sha256:fe1b73cd25ac3f11dec752755e2"
 
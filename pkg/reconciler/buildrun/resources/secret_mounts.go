@@ -0,0 +1,101 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"fmt"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
+)
+
+// secretMountMode allows the non-root users of the build strategy steps to read the mounted secret files
+var secretMountMode = pointer.Int32(0444)
+
+// OverrideSecretMounts merges the secret mounts of the BuildRun into those of the Build, secret
+// mounts of the BuildRun replace those of the Build with the same name
+func OverrideSecretMounts(buildSecrets []buildv1alpha1.BuildSecret, buildRunSecrets []buildv1alpha1.BuildSecret) []buildv1alpha1.BuildSecret {
+	if len(buildRunSecrets) == 0 {
+		return buildSecrets
+	}
+
+	secrets := []buildv1alpha1.BuildSecret{}
+	overrides := make(map[string]buildv1alpha1.BuildSecret, len(buildRunSecrets))
+	for _, secret := range buildRunSecrets {
+		overrides[secret.Name] = secret
+	}
+
+	for _, secret := range buildSecrets {
+		if override, ok := overrides[secret.Name]; ok {
+			secret = override
+			delete(overrides, secret.Name)
+		}
+		secrets = append(secrets, secret)
+	}
+
+	for _, secret := range buildRunSecrets {
+		if _, ok := overrides[secret.Name]; ok {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	return secrets
+}
+
+// amendTaskSpecWithSecretMounts adds a volume for every secret that the Build or BuildRun provides for a secret mount of
+// the strategy, and mounts it read-only into the steps of the strategy at the path that the strategy declares
+func amendTaskSpecWithSecretMounts(taskSpec *v1beta1.TaskSpec, strategy buildv1alpha1.BuilderStrategy, secrets []buildv1alpha1.BuildSecret) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	strategySecrets := map[string]buildv1alpha1.BuildStrategySecret{}
+	for _, strategySecret := range strategy.GetSecrets() {
+		strategySecrets[strategySecret.Name] = strategySecret
+	}
+
+	strategySteps := map[string]struct{}{}
+	for _, buildStep := range strategy.GetBuildSteps() {
+		strategySteps[buildStep.Name] = struct{}{}
+	}
+
+	for _, secret := range secrets {
+		strategySecret, ok := strategySecrets[secret.Name]
+		if !ok {
+			return fmt.Errorf("secret mount %q is not defined in the build strategy", secret.Name)
+		}
+
+		volumeName := sources.SanitizeVolumeNameForSecretName(fmt.Sprintf("secret-mount-%s", secret.Name))
+
+		taskSpec.Volumes = append(taskSpec.Volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  secret.SecretName,
+					Items:       secret.Items,
+					DefaultMode: secretMountMode,
+				},
+			},
+		})
+
+		for i := range taskSpec.Steps {
+			if _, ok := strategySteps[taskSpec.Steps[i].Name]; !ok {
+				continue
+			}
+
+			taskSpec.Steps[i].VolumeMounts = append(taskSpec.Steps[i].VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: strategySecret.MountPath,
+				ReadOnly:  true,
+			})
+		}
+	}
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
 
 	pipeline "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/utils/pointer"
 )
 
 const defaultSourceName = "default"
@@ -41,37 +42,115 @@ func AmendTaskSpecWithSources(
 	build *buildv1alpha1.Build,
 	buildRun *buildv1alpha1.BuildRun,
 ) {
+	network := sources.NetworkOptions(cfg, build.Spec.Network)
+
 	if localCopy := isLocalCopyBuildSource(build, buildRun); localCopy != nil {
-		sources.AppendLocalCopyStep(cfg, taskSpec, localCopy.Timeout)
+		sources.AppendLocalCopyStep(cfg, taskSpec, localCopy.Timeout, sources.UploadTokenSecretName(buildRun.Name))
 	} else {
-		// create the step for spec.source, either Git or Bundle
+		// create the step for spec.source, either Git, Bundle or Archive
 		switch {
 		case build.Spec.Source.BundleContainer != nil:
 			sources.AppendBundleStep(cfg, taskSpec, build.Spec.Source, defaultSourceName)
+			appendNetworkSettings(taskSpec, &taskSpec.Steps[len(taskSpec.Steps)-1], network)
+		case build.Spec.Source.Archive != nil:
+			sources.AppendArchiveStep(cfg, taskSpec, build.Spec.Source, defaultSourceName)
+			appendNetworkSettings(taskSpec, &taskSpec.Steps[len(taskSpec.Steps)-1], network)
 		case build.Spec.Source.URL != nil:
 			sources.AppendGitStep(cfg, taskSpec, build.Spec.Source, defaultSourceName)
+			appendNetworkSettings(taskSpec, &taskSpec.Steps[len(taskSpec.Steps)-1], network)
 		}
 	}
 
-	// inspecting .spec.sources looking for "http" typed sources to generate the TaskSpec items
-	// in order to handle remote artifacts
+	// inspecting .spec.sources to generate the TaskSpec items, "http" typed sources are remote
+	// artifacts, all sources get their own step that writes into their target directory
 	for _, source := range build.Spec.Sources {
-		if source.Type == buildv1alpha1.HTTP {
+		switch source.Type {
+		case buildv1alpha1.HTTP:
 			sources.AppendHTTPStep(cfg, taskSpec, source)
+			appendNetworkSettings(taskSpec, &taskSpec.Steps[len(taskSpec.Steps)-1], network)
+		case buildv1alpha1.Git:
+			sources.AppendGitStep(cfg, taskSpec, namedSource(source), source.Name)
+			sources.SetTargetDirectory(&taskSpec.Steps[len(taskSpec.Steps)-1], targetDirectory(source))
+			appendNetworkSettings(taskSpec, &taskSpec.Steps[len(taskSpec.Steps)-1], network)
+		case buildv1alpha1.Bundle:
+			sources.AppendBundleStep(cfg, taskSpec, namedSource(source), source.Name)
+			sources.SetTargetDirectory(&taskSpec.Steps[len(taskSpec.Steps)-1], targetDirectory(source))
+			appendNetworkSettings(taskSpec, &taskSpec.Steps[len(taskSpec.Steps)-1], network)
 		}
 	}
 }
 
+// namedSource returns the Source that describes a Git or bundle entry of .spec.sources
+func namedSource(source buildv1alpha1.BuildSource) buildv1alpha1.Source {
+	namedSource := buildv1alpha1.Source{
+		Revision:        source.Revision,
+		BundleContainer: source.BundleContainer,
+		Credentials:     source.Credentials,
+	}
+
+	if source.URL != "" {
+		namedSource.URL = pointer.String(source.URL)
+	}
+
+	return namedSource
+}
+
+// targetDirectory returns the directory relative to the source root that a named source is written to
+func targetDirectory(source buildv1alpha1.BuildSource) string {
+	if source.TargetDir != nil {
+		return *source.TargetDir
+	}
+
+	return source.Name
+}
+
+// appendNetworkSettings applies the certificate authority bundle and proxy settings to a step that
+// Shipwright implements, these steps take the path of the bundle as argument
+func appendNetworkSettings(taskSpec *pipeline.TaskSpec, step *pipeline.Step, network config.NetworkOptions) {
+	if caBundlePath := sources.AppendNetworkSettings(taskSpec, step, network); caBundlePath != "" {
+		step.Args = append(step.Args, "--ca-bundle-path", caBundlePath)
+	}
+}
+
 func updateBuildRunStatusWithSourceResult(buildrun *buildv1alpha1.BuildRun, results []pipeline.TaskRunResult) {
 	buildSpec := buildrun.Status.BuildSpec
 
+	// the results of the source steps are surfaced while the TaskRun is running, they are
+	// collected from scratch every time
+	buildrun.Status.Sources = nil
+
 	switch {
 	case buildSpec.Source.BundleContainer != nil:
 		sources.AppendBundleResult(buildrun, defaultSourceName, results)
 
+	case buildSpec.Source.Archive != nil:
+		sources.AppendArchiveResult(buildrun, defaultSourceName, results)
+
 	case buildSpec.Source.URL != nil:
 		sources.AppendGitResult(buildrun, defaultSourceName, results)
 	}
 
-	// no results for HTTP sources yet
+	for _, source := range buildSpec.Sources {
+		switch source.Type {
+		case buildv1alpha1.HTTP:
+			sources.AppendHTTPResult(buildrun, source.Name, results)
+		case buildv1alpha1.Git:
+			sources.AppendGitResult(buildrun, source.Name, results)
+		case buildv1alpha1.Bundle:
+			sources.AppendBundleResult(buildrun, source.Name, results)
+		}
+	}
+}
+
+// UpdateBuildRunUsingLocalCopyState surfaces the state of the waiter for the upload of a local
+// source, while the upload is awaited and after the waiter ended.
+func UpdateBuildRunUsingLocalCopyState(buildRun *buildv1alpha1.BuildRun, taskRun *pipeline.TaskRun) {
+	if buildRun.Status.BuildSpec == nil {
+		return
+	}
+
+	build := &buildv1alpha1.Build{Spec: *buildRun.Status.BuildSpec}
+	if localCopy := isLocalCopyBuildSource(build, buildRun); localCopy != nil {
+		sources.AppendLocalCopyResult(buildRun, localCopy.Name, localCopy.Timeout, taskRun)
+	}
 }
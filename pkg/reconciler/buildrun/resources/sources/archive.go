@@ -0,0 +1,94 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"fmt"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+
+	build "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/config"
+
+	pipeline "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+const (
+	archiveDigestResult = "digest"
+
+	archiveSchemeOCI = "oci://"
+)
+
+// AppendArchiveStep appends the step that obtains an archive source to the TaskSpec, images and
+// OCI artifacts are pulled from their registry, and tarballs are downloaded and verified
+func AppendArchiveStep(
+	cfg *config.Config,
+	taskSpec *pipeline.TaskSpec,
+	source build.Source,
+	name string,
+) {
+	// append the result
+	taskSpec.Results = append(taskSpec.Results, pipeline.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, archiveDigestResult),
+		Description: "The digest of the image, OCI artifact or tarball.",
+	})
+
+	// initialize the step from the template, the bundle command handles all archive types
+	archiveStep := *cfg.BundleContainerTemplate.DeepCopy()
+
+	// add the build-specific details
+	archiveStep.Name = fmt.Sprintf("source-%s", name)
+	if strings.HasPrefix(source.Archive.URL, archiveSchemeOCI) {
+		archiveStep.Args = []string{"--image", strings.TrimPrefix(source.Archive.URL, archiveSchemeOCI)}
+	} else {
+		archiveStep.Args = []string{"--url", source.Archive.URL}
+		if source.Archive.Checksum != nil {
+			archiveStep.Args = append(archiveStep.Args, "--checksum", *source.Archive.Checksum)
+		}
+	}
+
+	archiveStep.Args = append(archiveStep.Args,
+		"--target", fmt.Sprintf("$(params.%s-%s)", prefixParamsResultsVolumes, paramSourceRoot),
+		"--result-file-image-digest", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, archiveDigestResult),
+	)
+
+	// add credentials mount, if provided
+	if source.Credentials != nil {
+		AppendSecretVolume(taskSpec, source.Credentials.Name)
+
+		secretMountPath := fmt.Sprintf("/workspace/%s-pull-secret", prefixParamsResultsVolumes)
+
+		// define the volume mount on the container
+		archiveStep.VolumeMounts = append(archiveStep.VolumeMounts, core.VolumeMount{
+			Name:      SanitizeVolumeNameForSecretName(source.Credentials.Name),
+			MountPath: secretMountPath,
+			ReadOnly:  true,
+		})
+
+		// append the argument
+		archiveStep.Args = append(archiveStep.Args,
+			"--secret-path", secretMountPath,
+		)
+	} else if cfg.RegistryToken.IsConfigured() && strings.HasPrefix(source.Archive.URL, archiveSchemeOCI) {
+		// without a pull secret, use short-lived credentials based on the ServiceAccount token
+		AppendRegistryTokenVolume(taskSpec, &archiveStep, cfg.RegistryToken)
+	}
+
+	taskSpec.Steps = append(taskSpec.Steps, archiveStep)
+}
+
+// AppendArchiveResult append archive source result to build run
+func AppendArchiveResult(buildRun *build.BuildRun, name string, results []pipeline.TaskRunResult) {
+	digest := strings.TrimSpace(findResultValue(results, fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, archiveDigestResult)))
+	if digest == "" {
+		return
+	}
+
+	buildRun.Status.Sources = append(buildRun.Status.Sources, build.SourceResult{
+		Name:    name,
+		Archive: &build.ArchiveSourceResult{Digest: digest},
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/config"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
+
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Archive", func() {
+
+	cfg := config.NewDefaultConfig()
+
+	var taskSpec *tektonv1beta1.TaskSpec
+
+	BeforeEach(func() {
+		taskSpec = &tektonv1beta1.TaskSpec{}
+	})
+
+	Context("when adding an OCI artifact source", func() {
+
+		JustBeforeEach(func() {
+			sources.AppendArchiveStep(cfg, taskSpec, buildv1alpha1.Source{
+				Archive: &buildv1alpha1.Archive{URL: "oci://ghcr.io/shipwright-io/sample-go/source:latest"},
+				Credentials: &corev1.LocalObjectReference{
+					Name: "a.secret",
+				},
+			}, "default")
+		})
+
+		It("adds a result for the digest", func() {
+			Expect(len(taskSpec.Results)).To(Equal(1))
+			Expect(taskSpec.Results[0].Name).To(Equal("shp-source-default-digest"))
+		})
+
+		It("adds a step that pulls the image", func() {
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].Name).To(Equal("source-default"))
+			Expect(taskSpec.Steps[0].Image).To(Equal(cfg.BundleContainerTemplate.Image))
+			Expect(taskSpec.Steps[0].Args).To(Equal([]string{
+				"--image",
+				"ghcr.io/shipwright-io/sample-go/source:latest",
+				"--target",
+				"$(params.shp-source-root)",
+				"--result-file-image-digest",
+				"$(results.shp-source-default-digest.path)",
+				"--secret-path",
+				"/workspace/shp-pull-secret",
+			}))
+			Expect(len(taskSpec.Steps[0].VolumeMounts)).To(Equal(1))
+			Expect(taskSpec.Steps[0].VolumeMounts[0].Name).To(Equal("shp-a-secret"))
+		})
+	})
+
+	Context("when adding a tarball source", func() {
+
+		JustBeforeEach(func() {
+			sources.AppendArchiveStep(cfg, taskSpec, buildv1alpha1.Source{
+				Archive: &buildv1alpha1.Archive{
+					URL:      "https://example.com/source.tar.gz",
+					Checksum: pointer.String("sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"),
+				},
+			}, "default")
+		})
+
+		It("adds a step that downloads and verifies the tarball", func() {
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].Args).To(Equal([]string{
+				"--url",
+				"https://example.com/source.tar.gz",
+				"--checksum",
+				"sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+				"--target",
+				"$(params.shp-source-root)",
+				"--result-file-image-digest",
+				"$(results.shp-source-default-digest.path)",
+			}))
+		})
+	})
+})
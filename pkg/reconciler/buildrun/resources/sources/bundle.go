@@ -5,7 +5,9 @@
 package sources
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	core "k8s.io/api/core/v1"
@@ -16,6 +18,14 @@ import (
 	pipeline "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 )
 
+const (
+	imageDigestResult    = "image-digest"
+	directoryResult      = "directory"
+	ignorePatternsResult = "ignore-patterns"
+	verifiedFilesResult  = "verified-files"
+	pruneWarningResult   = "prune-warning"
+)
+
 // AppendBundleStep appends the bundle step to the TaskSpec
 func AppendBundleStep(
 	cfg *config.Config,
@@ -25,8 +35,20 @@ func AppendBundleStep(
 ) {
 	// append the result
 	taskSpec.Results = append(taskSpec.Results, pipeline.TaskResult{
-		Name:        fmt.Sprintf("%s-source-%s-image-digest", prefixParamsResultsVolumes, name),
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, imageDigestResult),
 		Description: "The digest of the bundle image.",
+	}, pipeline.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, directoryResult),
+		Description: "The directory that was packed into the bundle image.",
+	}, pipeline.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, commitSHAResult),
+		Description: "The commit SHA of the Git worktree that contained the directory of the bundle image.",
+	}, pipeline.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, ignorePatternsResult),
+		Description: "The .shpignore patterns that were applied when the bundle image was created.",
+	}, pipeline.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, verifiedFilesResult),
+		Description: "The number of unpacked files that were verified against the digests in the bundle image.",
 	})
 
 	// initialize the step from the template
@@ -37,7 +59,13 @@ func AppendBundleStep(
 	bundleStep.Args = []string{
 		"--image", source.BundleContainer.Image,
 		"--target", fmt.Sprintf("$(params.%s-%s)", prefixParamsResultsVolumes, paramSourceRoot),
-		"--result-file-image-digest", fmt.Sprintf("$(results.%s-source-%s-image-digest.path)", prefixParamsResultsVolumes, name),
+		"--result-file-image-digest", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, imageDigestResult),
+		"--result-file-directory", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, directoryResult),
+		"--result-file-commit-sha", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, commitSHAResult),
+		"--result-file-ignore-patterns", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, ignorePatternsResult),
+		"--result-file-verified-files", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, verifiedFilesResult),
+		"--max-size", strconv.FormatInt(cfg.SourceLimits.MaxSize, 10),
+		"--max-files", strconv.Itoa(cfg.SourceLimits.MaxFiles),
 	}
 
 	// add credentials mount, if provided
@@ -57,11 +85,22 @@ func AppendBundleStep(
 		bundleStep.Args = append(bundleStep.Args,
 			"--secret-path", secretMountPath,
 		)
+	} else if cfg.RegistryToken.IsConfigured() {
+		// without a pull secret, use short-lived credentials based on the ServiceAccount token
+		AppendRegistryTokenVolume(taskSpec, &bundleStep, cfg.RegistryToken)
 	}
 
-	// add prune flag in when prune after pull is configured
+	// add prune flag in when prune after pull is configured, a failed deletion is reported as warning
 	if source.BundleContainer.Prune != nil && *source.BundleContainer.Prune == build.PruneAfterPull {
-		bundleStep.Args = append(bundleStep.Args, "--prune")
+		taskSpec.Results = append(taskSpec.Results, pipeline.TaskResult{
+			Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, pruneWarningResult),
+			Description: "The reason why the bundle image could not be deleted from the registry.",
+		})
+
+		bundleStep.Args = append(bundleStep.Args,
+			"--prune",
+			"--result-file-prune-warning", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, pruneWarningResult),
+		)
 	}
 
 	taskSpec.Steps = append(taskSpec.Steps, bundleStep)
@@ -69,14 +108,37 @@ func AppendBundleStep(
 
 // AppendBundleResult append bundle source result to build run
 func AppendBundleResult(buildRun *build.BuildRun, name string, results []pipeline.TaskRunResult) {
-	imageDigest := findResultValue(results, fmt.Sprintf("%s-source-%s-image-digest", prefixParamsResultsVolumes, name))
-
-	if strings.TrimSpace(imageDigest) != "" {
-		buildRun.Status.Sources = append(buildRun.Status.Sources, build.SourceResult{
-			Name: name,
-			Bundle: &build.BundleSourceResult{
-				Digest: imageDigest,
-			},
-		})
+	resultValue := func(result string) string {
+		return strings.TrimSpace(findResultValue(results, fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, result)))
+	}
+
+	imageDigest := resultValue(imageDigestResult)
+	if imageDigest == "" {
+		return
 	}
+
+	bundleResult := build.BundleSourceResult{
+		Digest:       imageDigest,
+		Directory:    resultValue(directoryResult),
+		CommitSha:    resultValue(commitSHAResult),
+		PruneWarning: resultValue(pruneWarningResult),
+	}
+
+	// the ignore patterns are reported as a JSON array, an unparseable value is ignored
+	if value := resultValue(ignorePatternsResult); value != "" {
+		if err := json.Unmarshal([]byte(value), &bundleResult.IgnorePatterns); err != nil {
+			bundleResult.IgnorePatterns = nil
+		}
+	}
+
+	if value := resultValue(verifiedFilesResult); value != "" {
+		if verifiedFiles, err := strconv.Atoi(value); err == nil {
+			bundleResult.VerifiedFiles = verifiedFiles
+		}
+	}
+
+	buildRun.Status.Sources = append(buildRun.Status.Sources, build.SourceResult{
+		Name:   name,
+		Bundle: &bundleResult,
+	})
 }
@@ -0,0 +1,67 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/config"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
+
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+var _ = Describe("Bundle", func() {
+
+	cfg := config.NewDefaultConfig()
+
+	var taskSpec *tektonv1beta1.TaskSpec
+
+	BeforeEach(func() {
+		taskSpec = &tektonv1beta1.TaskSpec{}
+	})
+
+	It("does not prune the bundle image by default", func() {
+		sources.AppendBundleStep(cfg, taskSpec, buildv1alpha1.Source{
+			BundleContainer: &buildv1alpha1.BundleContainer{Image: "ghcr.io/shipwright-io/sample-go/source-bundle:latest"},
+		}, "default")
+
+		Expect(len(taskSpec.Results)).To(Equal(5))
+		Expect(len(taskSpec.Steps)).To(Equal(1))
+		Expect(taskSpec.Steps[0].Args).ToNot(ContainElement("--prune"))
+	})
+
+	It("limits the size and the number of the unpacked files", func() {
+		cfg := config.NewDefaultConfig()
+		cfg.SourceLimits = config.SourceLimitsOptions{MaxSize: 1024, MaxFiles: 10}
+
+		sources.AppendBundleStep(cfg, taskSpec, buildv1alpha1.Source{
+			BundleContainer: &buildv1alpha1.BundleContainer{Image: "ghcr.io/shipwright-io/sample-go/source-bundle:latest"},
+		}, "default")
+
+		Expect(taskSpec.Steps[0].Args).To(ContainElements("--max-size", "1024", "--max-files", "10"))
+	})
+
+	It("prunes the bundle image and reports a warning if it cannot be deleted", func() {
+		prune := buildv1alpha1.PruneAfterPull
+		sources.AppendBundleStep(cfg, taskSpec, buildv1alpha1.Source{
+			BundleContainer: &buildv1alpha1.BundleContainer{
+				Image: "ghcr.io/shipwright-io/sample-go/source-bundle:latest",
+				Prune: &prune,
+			},
+		}, "default")
+
+		Expect(len(taskSpec.Results)).To(Equal(6))
+		Expect(taskSpec.Results[5].Name).To(Equal("shp-source-default-prune-warning"))
+
+		Expect(len(taskSpec.Steps)).To(Equal(1))
+		Expect(taskSpec.Steps[0].Args).To(ContainElements(
+			"--prune",
+			"--result-file-prune-warning", "$(results.shp-source-default-prune-warning.path)",
+		))
+	})
+})
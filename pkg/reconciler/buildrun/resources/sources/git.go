@@ -5,19 +5,30 @@
 package sources
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/config"
 	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	commitSHAResult    = "commit-sha"
 	commitAuthorResult = "commit-author"
 	branchName         = "branch-name"
+	submodulesResult   = "submodules"
+
+	commitTimestampResult = "commit-timestamp"
+	committerResult       = "committer"
+	commitMessageResult   = "commit-message"
+	tagsResult            = "tags"
+	describeResult        = "describe"
+	urlResult             = "url"
 )
 
 // AppendGitStep appends the Git step and results and volume if needed to the TaskSpec
@@ -37,6 +48,27 @@ func AppendGitStep(
 	}, tektonv1beta1.TaskResult{
 		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, branchName),
 		Description: "The name of the branch used of the cloned source.",
+	}, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, submodulesResult),
+		Description: "The paths and commit SHAs of the submodules of the cloned source.",
+	}, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, commitTimestampResult),
+		Description: "The committer timestamp of the last commit of the cloned source.",
+	}, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, committerResult),
+		Description: "The committer of the last commit of the cloned source.",
+	}, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, commitMessageResult),
+		Description: "The subject of the message of the last commit of the cloned source.",
+	}, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, tagsResult),
+		Description: "The tags that point at the last commit of the cloned source.",
+	}, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, describeResult),
+		Description: "The output of git describe for the last commit of the cloned source.",
+	}, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, urlResult),
+		Description: "The URL of the cloned source without credentials.",
 	})
 
 	// initialize the step from the template
@@ -55,6 +87,20 @@ func AppendGitStep(
 		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, commitAuthorResult),
 		"--result-file-branch-name",
 		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, branchName),
+		"--result-file-submodules",
+		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, submodulesResult),
+		"--result-file-commit-timestamp",
+		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, commitTimestampResult),
+		"--result-file-committer",
+		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, committerResult),
+		"--result-file-commit-message",
+		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, commitMessageResult),
+		"--result-file-tags",
+		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, tagsResult),
+		"--result-file-describe",
+		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, describeResult),
+		"--result-file-url",
+		fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, name, urlResult),
 		"--result-file-error-message",
 		fmt.Sprintf("$(results.%s-error-message.path)", prefixParamsResultsVolumes),
 		"--result-file-error-reason",
@@ -71,6 +117,47 @@ func AppendGitStep(
 		)
 	}
 
+	// Check if a sparse checkout is requested, it checks out the context directory
+	// if one is defined, and the additional paths. Without any of them the whole
+	// repository is checked out.
+	if source.SparseCheckout != nil {
+		if source.ContextDir != nil {
+			gitStep.Args = append(gitStep.Args, "--sparse-checkout-path", *source.ContextDir)
+		}
+
+		for _, path := range source.SparseCheckout.Paths {
+			gitStep.Args = append(gitStep.Args, "--sparse-checkout-path", path)
+		}
+	}
+
+	if source.Submodules != nil {
+		switch {
+		case source.Submodules.Enabled != nil && !*source.Submodules.Enabled:
+			gitStep.Args = append(gitStep.Args, "--skip-submodules")
+
+		default:
+			if source.Submodules.Recursive != nil && !*source.Submodules.Recursive {
+				gitStep.Args = append(gitStep.Args, "--recurse-submodules=false")
+			}
+
+			for _, path := range source.Submodules.Paths {
+				gitStep.Args = append(gitStep.Args, "--submodule-path", path)
+			}
+		}
+	}
+
+	if source.LFS != nil {
+		switch {
+		case source.LFS.Enabled != nil && !*source.LFS.Enabled:
+			gitStep.Args = append(gitStep.Args, "--skip-lfs")
+
+		default:
+			for _, pattern := range source.LFS.Include {
+				gitStep.Args = append(gitStep.Args, "--lfs-include", pattern)
+			}
+		}
+	}
+
 	// If configure, use Git URL rewrite flag
 	if cfg.GitRewriteRule {
 		gitStep.Args = append(gitStep.Args, "--git-url-rewrite")
@@ -97,24 +184,87 @@ func AppendGitStep(
 		)
 	}
 
+	if source.SignatureVerification != nil {
+		signatureKeysMountPath := fmt.Sprintf("/workspace/%s-source-signature-keys", prefixParamsResultsVolumes)
+
+		switch {
+		case source.SignatureVerification.SecretRef != nil:
+			AppendSecretVolume(taskSpec, source.SignatureVerification.SecretRef.Name)
+
+			gitStep.VolumeMounts = append(gitStep.VolumeMounts, corev1.VolumeMount{
+				Name:      SanitizeVolumeNameForSecretName(source.SignatureVerification.SecretRef.Name),
+				MountPath: signatureKeysMountPath,
+				ReadOnly:  true,
+			})
+
+		case source.SignatureVerification.ConfigMapRef != nil:
+			volumeName := fmt.Sprintf("%s-source-signature-keys", prefixParamsResultsVolumes)
+
+			taskSpec.Volumes = append(taskSpec.Volumes, corev1.Volume{
+				Name: volumeName,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: *source.SignatureVerification.ConfigMapRef,
+					},
+				},
+			})
+
+			gitStep.VolumeMounts = append(gitStep.VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: signatureKeysMountPath,
+				ReadOnly:  true,
+			})
+		}
+
+		gitStep.Args = append(gitStep.Args, "--signature-keys-path", signatureKeysMountPath)
+
+		if source.SignatureVerification.Tag != nil && *source.SignatureVerification.Tag {
+			gitStep.Args = append(gitStep.Args, "--verify-tag-signature")
+		}
+	}
+
 	// append the git step
 	taskSpec.Steps = append(taskSpec.Steps, gitStep)
 }
 
 // AppendGitResult append git source result to build run
 func AppendGitResult(buildRun *buildv1alpha1.BuildRun, name string, results []tektonv1beta1.TaskRunResult) {
-	commitAuthor := findResultValue(results, fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, commitAuthorResult))
-	commitSha := findResultValue(results, fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, commitSHAResult))
-	branchName := findResultValue(results, fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, branchName))
+	resultValue := func(result string) string {
+		return strings.TrimSpace(findResultValue(results, fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, result)))
+	}
+
+	gitResult := buildv1alpha1.GitSourceResult{
+		CommitAuthor:  resultValue(commitAuthorResult),
+		CommitSha:     resultValue(commitSHAResult),
+		BranchName:    resultValue(branchName),
+		Committer:     resultValue(committerResult),
+		CommitMessage: resultValue(commitMessageResult),
+		Describe:      resultValue(describeResult),
+		URL:           resultValue(urlResult),
+	}
+
+	// the commit timestamp is reported in the strict ISO 8601 format, an unparseable value is ignored
+	if value := resultValue(commitTimestampResult); value != "" {
+		if commitTimestamp, err := time.Parse(time.RFC3339, value); err == nil {
+			gitResult.CommitTimestamp = &metav1.Time{Time: commitTimestamp}
+		}
+	}
+
+	if value := resultValue(tagsResult); value != "" {
+		gitResult.Tags = strings.Split(value, ",")
+	}
+
+	// the submodules are reported as a JSON array, an unparseable value is ignored
+	if value := resultValue(submodulesResult); value != "" {
+		if err := json.Unmarshal([]byte(value), &gitResult.Submodules); err != nil {
+			gitResult.Submodules = nil
+		}
+	}
 
-	if strings.TrimSpace(commitAuthor) != "" || strings.TrimSpace(commitSha) != "" || strings.TrimSpace(branchName) != "" {
+	if gitResult.CommitAuthor != "" || gitResult.CommitSha != "" || gitResult.BranchName != "" || gitResult.URL != "" || len(gitResult.Submodules) > 0 {
 		buildRun.Status.Sources = append(buildRun.Status.Sources, buildv1alpha1.SourceResult{
 			Name: name,
-			Git: &buildv1alpha1.GitSourceResult{
-				CommitAuthor: commitAuthor,
-				CommitSha:    commitSha,
-				BranchName:   branchName,
-			},
+			Git:  &gitResult,
 		})
 	}
 }
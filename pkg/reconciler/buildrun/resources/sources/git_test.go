@@ -35,11 +35,18 @@ var _ = Describe("Git", func() {
 			}, "default")
 		})
 
-		It("adds results for the commit sha, commit author and branch name", func() {
-			Expect(len(taskSpec.Results)).To(Equal(3))
+		It("adds results for the commit sha, commit author, branch name, submodules and commit metadata", func() {
+			Expect(len(taskSpec.Results)).To(Equal(10))
 			Expect(taskSpec.Results[0].Name).To(Equal("shp-source-default-commit-sha"))
 			Expect(taskSpec.Results[1].Name).To(Equal("shp-source-default-commit-author"))
 			Expect(taskSpec.Results[2].Name).To(Equal("shp-source-default-branch-name"))
+			Expect(taskSpec.Results[3].Name).To(Equal("shp-source-default-submodules"))
+			Expect(taskSpec.Results[4].Name).To(Equal("shp-source-default-commit-timestamp"))
+			Expect(taskSpec.Results[5].Name).To(Equal("shp-source-default-committer"))
+			Expect(taskSpec.Results[6].Name).To(Equal("shp-source-default-commit-message"))
+			Expect(taskSpec.Results[7].Name).To(Equal("shp-source-default-tags"))
+			Expect(taskSpec.Results[8].Name).To(Equal("shp-source-default-describe"))
+			Expect(taskSpec.Results[9].Name).To(Equal("shp-source-default-url"))
 		})
 
 		It("adds a step", func() {
@@ -57,6 +64,20 @@ var _ = Describe("Git", func() {
 				"$(results.shp-source-default-commit-author.path)",
 				"--result-file-branch-name",
 				"$(results.shp-source-default-branch-name.path)",
+				"--result-file-submodules",
+				"$(results.shp-source-default-submodules.path)",
+				"--result-file-commit-timestamp",
+				"$(results.shp-source-default-commit-timestamp.path)",
+				"--result-file-committer",
+				"$(results.shp-source-default-committer.path)",
+				"--result-file-commit-message",
+				"$(results.shp-source-default-commit-message.path)",
+				"--result-file-tags",
+				"$(results.shp-source-default-tags.path)",
+				"--result-file-describe",
+				"$(results.shp-source-default-describe.path)",
+				"--result-file-url",
+				"$(results.shp-source-default-url.path)",
 				"--result-file-error-message",
 				"$(results.shp-error-message.path)",
 				"--result-file-error-reason",
@@ -82,11 +103,18 @@ var _ = Describe("Git", func() {
 			}, "default")
 		})
 
-		It("adds results for the commit sha, commit author and branch name", func() {
-			Expect(len(taskSpec.Results)).To(Equal(3))
+		It("adds results for the commit sha, commit author, branch name, submodules and commit metadata", func() {
+			Expect(len(taskSpec.Results)).To(Equal(10))
 			Expect(taskSpec.Results[0].Name).To(Equal("shp-source-default-commit-sha"))
 			Expect(taskSpec.Results[1].Name).To(Equal("shp-source-default-commit-author"))
 			Expect(taskSpec.Results[2].Name).To(Equal("shp-source-default-branch-name"))
+			Expect(taskSpec.Results[3].Name).To(Equal("shp-source-default-submodules"))
+			Expect(taskSpec.Results[4].Name).To(Equal("shp-source-default-commit-timestamp"))
+			Expect(taskSpec.Results[5].Name).To(Equal("shp-source-default-committer"))
+			Expect(taskSpec.Results[6].Name).To(Equal("shp-source-default-commit-message"))
+			Expect(taskSpec.Results[7].Name).To(Equal("shp-source-default-tags"))
+			Expect(taskSpec.Results[8].Name).To(Equal("shp-source-default-describe"))
+			Expect(taskSpec.Results[9].Name).To(Equal("shp-source-default-url"))
 		})
 
 		It("adds a volume for the secret", func() {
@@ -111,6 +139,20 @@ var _ = Describe("Git", func() {
 				"$(results.shp-source-default-commit-author.path)",
 				"--result-file-branch-name",
 				"$(results.shp-source-default-branch-name.path)",
+				"--result-file-submodules",
+				"$(results.shp-source-default-submodules.path)",
+				"--result-file-commit-timestamp",
+				"$(results.shp-source-default-commit-timestamp.path)",
+				"--result-file-committer",
+				"$(results.shp-source-default-committer.path)",
+				"--result-file-commit-message",
+				"$(results.shp-source-default-commit-message.path)",
+				"--result-file-tags",
+				"$(results.shp-source-default-tags.path)",
+				"--result-file-describe",
+				"$(results.shp-source-default-describe.path)",
+				"--result-file-url",
+				"$(results.shp-source-default-url.path)",
 				"--result-file-error-message",
 				"$(results.shp-error-message.path)",
 				"--result-file-error-reason",
@@ -124,4 +166,155 @@ var _ = Describe("Git", func() {
 			Expect(taskSpec.Steps[0].VolumeMounts[0].ReadOnly).To(BeTrue())
 		})
 	})
+
+	Context("when adding a Git source with a sparse checkout", func() {
+
+		var taskSpec *tektonv1beta1.TaskSpec
+
+		BeforeEach(func() {
+			taskSpec = &tektonv1beta1.TaskSpec{}
+		})
+
+		It("adds the context directory and the additional paths as sparse checkout paths", func() {
+			sources.AppendGitStep(cfg, taskSpec, buildv1alpha1.Source{
+				URL:        pointer.String("https://github.com/shipwright-io/sample-monorepo"),
+				ContextDir: pointer.String("services/frontend"),
+				SparseCheckout: &buildv1alpha1.SparseCheckout{
+					Paths: []string{"libs/common"},
+				},
+			}, "default")
+
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].Args).To(ContainElements(
+				"--sparse-checkout-path", "services/frontend",
+				"--sparse-checkout-path", "libs/common",
+			))
+		})
+
+		It("adds the additional paths if no context directory is defined", func() {
+			sources.AppendGitStep(cfg, taskSpec, buildv1alpha1.Source{
+				URL: pointer.String("https://github.com/shipwright-io/sample-monorepo"),
+				SparseCheckout: &buildv1alpha1.SparseCheckout{
+					Paths: []string{"libs/common", "services/frontend"},
+				},
+			}, "default")
+
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].Args).To(ContainElements(
+				"--sparse-checkout-path", "libs/common",
+				"--sparse-checkout-path", "services/frontend",
+			))
+		})
+
+		It("checks out the whole repository if neither a context directory nor paths are defined", func() {
+			sources.AppendGitStep(cfg, taskSpec, buildv1alpha1.Source{
+				URL:            pointer.String("https://github.com/shipwright-io/sample-monorepo"),
+				SparseCheckout: &buildv1alpha1.SparseCheckout{},
+			}, "default")
+
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].Args).ToNot(ContainElement("--sparse-checkout-path"))
+		})
+	})
+
+	Context("when adding a Git source with submodule and Git LFS options", func() {
+
+		var taskSpec *tektonv1beta1.TaskSpec
+
+		BeforeEach(func() {
+			taskSpec = &tektonv1beta1.TaskSpec{}
+		})
+
+		It("skips submodules and Git LFS files if they are disabled", func() {
+			sources.AppendGitStep(cfg, taskSpec, buildv1alpha1.Source{
+				URL: pointer.String("https://github.com/shipwright-io/build"),
+				Submodules: &buildv1alpha1.GitSubmodules{
+					Enabled: pointer.Bool(false),
+					Paths:   []string{"vendor/lib"},
+				},
+				LFS: &buildv1alpha1.GitLFS{
+					Enabled: pointer.Bool(false),
+				},
+			}, "default")
+
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].Args).To(ContainElements("--skip-submodules", "--skip-lfs"))
+			Expect(taskSpec.Steps[0].Args).ToNot(ContainElement("--submodule-path"))
+		})
+
+		It("limits the submodules and Git LFS files that are fetched", func() {
+			sources.AppendGitStep(cfg, taskSpec, buildv1alpha1.Source{
+				URL: pointer.String("https://github.com/shipwright-io/build"),
+				Submodules: &buildv1alpha1.GitSubmodules{
+					Recursive: pointer.Bool(false),
+					Paths:     []string{"vendor/lib"},
+				},
+				LFS: &buildv1alpha1.GitLFS{
+					Include: []string{"*.png"},
+				},
+			}, "default")
+
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].Args).To(ContainElements(
+				"--recurse-submodules=false",
+				"--submodule-path", "vendor/lib",
+				"--lfs-include", "*.png",
+			))
+			Expect(taskSpec.Steps[0].Args).ToNot(ContainElements("--skip-submodules", "--skip-lfs"))
+		})
+	})
+
+	Context("when adding a Git source with signature verification", func() {
+
+		var taskSpec *tektonv1beta1.TaskSpec
+
+		BeforeEach(func() {
+			taskSpec = &tektonv1beta1.TaskSpec{}
+		})
+
+		It("mounts the keys of a Secret and verifies the commit signature", func() {
+			sources.AppendGitStep(cfg, taskSpec, buildv1alpha1.Source{
+				URL: pointer.String("https://github.com/shipwright-io/build"),
+				SignatureVerification: &buildv1alpha1.GitSignatureVerification{
+					SecretRef: &corev1.LocalObjectReference{Name: "trusted.keys"},
+				},
+			}, "default")
+
+			Expect(len(taskSpec.Volumes)).To(Equal(1))
+			Expect(taskSpec.Volumes[0].Name).To(Equal("shp-trusted-keys"))
+			Expect(taskSpec.Volumes[0].VolumeSource.Secret.SecretName).To(Equal("trusted.keys"))
+
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].VolumeMounts).To(Equal([]corev1.VolumeMount{{
+				Name:      "shp-trusted-keys",
+				MountPath: "/workspace/shp-source-signature-keys",
+				ReadOnly:  true,
+			}}))
+			Expect(taskSpec.Steps[0].Args).To(ContainElements("--signature-keys-path", "/workspace/shp-source-signature-keys"))
+			Expect(taskSpec.Steps[0].Args).ToNot(ContainElement("--verify-tag-signature"))
+		})
+
+		It("mounts the keys of a ConfigMap and verifies the tag signature", func() {
+			sources.AppendGitStep(cfg, taskSpec, buildv1alpha1.Source{
+				URL:      pointer.String("https://github.com/shipwright-io/build"),
+				Revision: pointer.String("v0.1.0"),
+				SignatureVerification: &buildv1alpha1.GitSignatureVerification{
+					ConfigMapRef: &corev1.LocalObjectReference{Name: "trusted-keys"},
+					Tag:          pointer.Bool(true),
+				},
+			}, "default")
+
+			Expect(len(taskSpec.Volumes)).To(Equal(1))
+			Expect(taskSpec.Volumes[0].Name).To(Equal("shp-source-signature-keys"))
+			Expect(taskSpec.Volumes[0].VolumeSource.ConfigMap.Name).To(Equal("trusted-keys"))
+
+			Expect(len(taskSpec.Steps)).To(Equal(1))
+			Expect(taskSpec.Steps[0].VolumeMounts).To(Equal([]corev1.VolumeMount{{
+				Name:      "shp-source-signature-keys",
+				MountPath: "/workspace/shp-source-signature-keys",
+				ReadOnly:  true,
+			}}))
+			Expect(taskSpec.Steps[0].Args).To(ContainElements("--signature-keys-path", "/workspace/shp-source-signature-keys", "--verify-tag-signature"))
+		})
+	})
 })
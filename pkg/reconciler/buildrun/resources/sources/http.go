@@ -6,51 +6,91 @@ package sources
 
 import (
 	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
 
 	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/config"
 	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 )
 
-// RemoteArtifactsContainerName name for the container dealing with remote artifacts download.
-const RemoteArtifactsContainerName = "sources-http"
+const httpDigestResult = "digest"
 
-// AppendHTTPStep appends the step for a HTTP source to the TaskSpec
+// AppendHTTPStep appends the step for a HTTP source to the TaskSpec, every HTTP source
+// is downloaded by its own step
 func AppendHTTPStep(
 	cfg *config.Config,
 	taskSpec *tektonv1beta1.TaskSpec,
 	source buildv1alpha1.BuildSource,
 ) {
-	// HTTP is done currently all in a single step, see if there is already one
-	httpStep := findExistingHTTPSourcesStep(taskSpec)
-	if httpStep != nil {
-		httpStep.Args[3] = fmt.Sprintf("%s ; wget %q", httpStep.Args[3], source.URL)
-	} else {
-		httpStep := tektonv1beta1.Step{
-			Name:       RemoteArtifactsContainerName,
-			Image:      cfg.RemoteArtifactsContainerImage,
-			WorkingDir: fmt.Sprintf("$(params.%s-%s)", prefixParamsResultsVolumes, paramSourceRoot),
-			Command: []string{
-				"/bin/sh",
-			},
-			Args: []string{
-				"-e",
-				"-x",
-				"-c",
-				fmt.Sprintf("wget %q", source.URL),
-			},
-		}
-
-		// append the git step
-		taskSpec.Steps = append(taskSpec.Steps, httpStep)
+	// append the result
+	taskSpec.Results = append(taskSpec.Results, tektonv1beta1.TaskResult{
+		Name:        fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, source.Name, httpDigestResult),
+		Description: "The sha256 digest of the downloaded file.",
+	})
+
+	// HTTP sources are downloaded into the source root, unless a target directory is defined
+	target := fmt.Sprintf("$(params.%s-%s)", prefixParamsResultsVolumes, paramSourceRoot)
+	if source.TargetDir != nil {
+		target = path.Join(target, *source.TargetDir)
+	}
+
+	// initialize the step from the template
+	httpStep := *cfg.HTTPContainerTemplate.DeepCopy()
+
+	// add the build-specific details
+	httpStep.Name = fmt.Sprintf("source-%s", source.Name)
+	httpStep.Args = []string{
+		"--url", source.URL,
+		"--target", target,
+		"--result-file-digest", fmt.Sprintf("$(results.%s-source-%s-%s.path)", prefixParamsResultsVolumes, source.Name, httpDigestResult),
+	}
+
+	if source.FileName != nil {
+		httpStep.Args = append(httpStep.Args, "--file-name", *source.FileName)
+	}
+
+	if source.Checksum != nil {
+		httpStep.Args = append(httpStep.Args, "--checksum", *source.Checksum)
 	}
+
+	if source.Extract != nil && *source.Extract {
+		httpStep.Args = append(httpStep.Args, "--extract")
+	}
+
+	// add credentials mount, if provided
+	if source.Credentials != nil {
+		AppendSecretVolume(taskSpec, source.Credentials.Name)
+
+		secretMountPath := fmt.Sprintf("/workspace/%s-source-secret", prefixParamsResultsVolumes)
+
+		// define the volume mount on the container
+		httpStep.VolumeMounts = append(httpStep.VolumeMounts, corev1.VolumeMount{
+			Name:      SanitizeVolumeNameForSecretName(source.Credentials.Name),
+			MountPath: secretMountPath,
+			ReadOnly:  true,
+		})
+
+		// append the argument
+		httpStep.Args = append(httpStep.Args, "--secret-path", secretMountPath)
+	}
+
+	taskSpec.Steps = append(taskSpec.Steps, httpStep)
 }
 
-func findExistingHTTPSourcesStep(taskSpec *tektonv1beta1.TaskSpec) *tektonv1beta1.Step {
-	for _, candidateStep := range taskSpec.Steps {
-		if candidateStep.Name == RemoteArtifactsContainerName {
-			return &candidateStep
-		}
+// AppendHTTPResult append HTTP source result to build run
+func AppendHTTPResult(buildRun *buildv1alpha1.BuildRun, name string, results []tektonv1beta1.TaskRunResult) {
+	digest := strings.TrimSpace(findResultValue(results, fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, httpDigestResult)))
+	if digest == "" {
+		return
 	}
-	return nil
+
+	buildRun.Status.Sources = append(buildRun.Status.Sources, buildv1alpha1.SourceResult{
+		Name: name,
+		HTTP: &buildv1alpha1.HTTPSourceResult{
+			Digest: digest,
+		},
+	})
 }
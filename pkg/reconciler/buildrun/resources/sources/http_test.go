@@ -8,6 +8,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
 	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/config"
 	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
@@ -26,82 +29,96 @@ var _ = Describe("HTTP", func() {
 			taskSpec = &tektonv1beta1.TaskSpec{}
 		})
 
-		It("adds the first step", func() {
+		It("adds the step and the result of the source", func() {
 			sources.AppendHTTPStep(cfg, taskSpec, buildv1alpha1.BuildSource{
 				Name: "logo",
 				URL:  "https://shipwright.io/icons/logo.svg",
 			})
 
 			Expect(len(taskSpec.Steps)).To(Equal(1))
-			Expect(taskSpec.Steps[0].Name).To(Equal(sources.RemoteArtifactsContainerName))
-			Expect(taskSpec.Steps[0].Image).To(Equal(cfg.RemoteArtifactsContainerImage))
-			Expect(taskSpec.Steps[0].WorkingDir).To(Equal("$(params.shp-source-root)"))
-			Expect(taskSpec.Steps[0].Args[3]).To(Equal("wget \"https://shipwright.io/icons/logo.svg\""))
-		})
-	})
-
-	Context("when a TaskSpec already contains the http step", func() {
-		var taskSpec *tektonv1beta1.TaskSpec
-
-		BeforeEach(func() {
-			taskSpec = &tektonv1beta1.TaskSpec{
-				Steps: []tektonv1beta1.Step{
-					{
-						Name:       sources.RemoteArtifactsContainerName,
-						Image:      cfg.RemoteArtifactsContainerImage,
-						WorkingDir: "$(params.shp-source-root)",
-						Command: []string{
-							"/bin/sh",
-						},
-						Args: []string{
-							"-e",
-							"-x",
-							"-c",
-							"wget \"https://tekton.dev/images/tekton-horizontal-color.png\"",
-						},
-					},
-				},
-			}
+			Expect(taskSpec.Steps[0].Name).To(Equal("source-logo"))
+			Expect(taskSpec.Steps[0].Image).To(Equal(cfg.HTTPContainerTemplate.Image))
+			Expect(taskSpec.Steps[0].Command).To(Equal(cfg.HTTPContainerTemplate.Command))
+			Expect(taskSpec.Steps[0].Args).To(Equal([]string{
+				"--url", "https://shipwright.io/icons/logo.svg",
+				"--target", "$(params.shp-source-root)",
+				"--result-file-digest", "$(results.shp-source-logo-digest.path)",
+			}))
+
+			Expect(len(taskSpec.Results)).To(Equal(1))
+			Expect(taskSpec.Results[0].Name).To(Equal("shp-source-logo-digest"))
 		})
 
-		It("updates the existing step", func() {
+		It("adds the arguments for the file name, the checksum, the extraction and the credentials", func() {
 			sources.AppendHTTPStep(cfg, taskSpec, buildv1alpha1.BuildSource{
-				Name: "logo",
-				URL:  "https://shipwright.io/icons/logo.svg",
+				Name:        "assets",
+				URL:         "https://shipwright.io/assets.tar.gz",
+				TargetDir:   pointer.String("static"),
+				Checksum:    pointer.String("sha256:fe1b73cd25ac3f11dec752755e2fe1b73cd25ac3f11dec752755e2fe1b73cd"),
+				Extract:     pointer.Bool(true),
+				Credentials: &corev1.LocalObjectReference{Name: "assets-secret"},
 			})
 
 			Expect(len(taskSpec.Steps)).To(Equal(1))
-			Expect(taskSpec.Steps[0].Name).To(Equal(sources.RemoteArtifactsContainerName))
-			Expect(taskSpec.Steps[0].Image).To(Equal(cfg.RemoteArtifactsContainerImage))
-			Expect(taskSpec.Steps[0].WorkingDir).To(Equal("$(params.shp-source-root)"))
-			Expect(taskSpec.Steps[0].Args[3]).To(Equal("wget \"https://tekton.dev/images/tekton-horizontal-color.png\" ; wget \"https://shipwright.io/icons/logo.svg\""))
+			Expect(taskSpec.Steps[0].Args).To(Equal([]string{
+				"--url", "https://shipwright.io/assets.tar.gz",
+				"--target", "$(params.shp-source-root)/static",
+				"--result-file-digest", "$(results.shp-source-assets-digest.path)",
+				"--checksum", "sha256:fe1b73cd25ac3f11dec752755e2fe1b73cd25ac3f11dec752755e2fe1b73cd",
+				"--extract",
+				"--secret-path", "/workspace/shp-source-secret",
+			}))
+			Expect(taskSpec.Steps[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "shp-assets-secret",
+				MountPath: "/workspace/shp-source-secret",
+				ReadOnly:  true,
+			}))
+			Expect(taskSpec.Volumes).To(ContainElement(HaveField("Name", "shp-assets-secret")))
 		})
 	})
 
-	Context("when a TaskSpec already another source step step", func() {
+	Context("when a TaskSpec already contains a HTTP source step", func() {
 		var taskSpec *tektonv1beta1.TaskSpec
 
 		BeforeEach(func() {
-			taskSpec = &tektonv1beta1.TaskSpec{
-				Steps: []tektonv1beta1.Step{
-					{
-						Name: "source-something",
-					},
-				},
-			}
+			taskSpec = &tektonv1beta1.TaskSpec{}
+
+			sources.AppendHTTPStep(cfg, taskSpec, buildv1alpha1.BuildSource{
+				Name: "tekton-logo",
+				URL:  "https://tekton.dev/images/tekton-horizontal-color.png",
+			})
 		})
 
-		It("appends the http step", func() {
+		It("appends a separate step for the next source", func() {
 			sources.AppendHTTPStep(cfg, taskSpec, buildv1alpha1.BuildSource{
-				Name: "logo",
-				URL:  "https://shipwright.io/icons/logo.svg",
+				Name:     "logo",
+				URL:      "https://shipwright.io/icons/logo.svg",
+				FileName: pointer.String("shipwright.svg"),
 			})
 
 			Expect(len(taskSpec.Steps)).To(Equal(2))
-			Expect(taskSpec.Steps[1].Name).To(Equal(sources.RemoteArtifactsContainerName))
-			Expect(taskSpec.Steps[1].Image).To(Equal(cfg.RemoteArtifactsContainerImage))
-			Expect(taskSpec.Steps[1].WorkingDir).To(Equal("$(params.shp-source-root)"))
-			Expect(taskSpec.Steps[1].Args[3]).To(Equal("wget \"https://shipwright.io/icons/logo.svg\""))
+			Expect(taskSpec.Steps[0].Name).To(Equal("source-tekton-logo"))
+			Expect(taskSpec.Steps[1].Name).To(Equal("source-logo"))
+			Expect(taskSpec.Steps[1].Args).To(ContainElements("--file-name", "shipwright.svg"))
+		})
+	})
+
+	Context("when the HTTP source step emitted a digest", func() {
+		It("adds the digest to the BuildRun status", func() {
+			buildRun := &buildv1alpha1.BuildRun{}
+
+			sources.AppendHTTPResult(buildRun, "logo", []tektonv1beta1.TaskRunResult{{
+				Name: "shp-source-logo-digest",
+				Value: tektonv1beta1.ArrayOrString{
+					Type:      tektonv1beta1.ParamTypeString,
+					StringVal: "sha256:fe1b73cd25ac3f11dec752755e2\n",
+				},
+			}})
+
+			Expect(buildRun.Status.Sources).To(Equal([]buildv1alpha1.SourceResult{{
+				Name: "logo",
+				HTTP: &buildv1alpha1.HTTPSourceResult{Digest: "sha256:fe1b73cd25ac3f11dec752755e2"},
+			}}))
 		})
 	})
 })
@@ -5,19 +5,57 @@ package sources
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/config"
 	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 )
 
+const (
+	localCopyStateResult         = "state"
+	localCopyTimeLeftResult      = "time-left"
+	localCopyReceivedBytesResult = "received-bytes"
+)
+
 // WaiterContainerName name given to the container watier container.
 const WaiterContainerName = "source-local"
 
+// WaiterUploadPort is the port of the endpoint in the waiter container that receives the upload
+// of the local source code.
+const WaiterUploadPort = 8080
+
+// UploadTokenKey is the key of the upload token in the Secret.
+const UploadTokenKey = "token"
+
+// UploadTokenSecretName returns the name of the Secret with the token that authenticates the
+// upload of the local source code of a BuildRun.
+func UploadTokenSecretName(buildRunName string) string {
+	const suffix = "-upload-token"
+
+	// ensure maximum length of a Secret name
+	if len(buildRunName) > 253-len(suffix) {
+		buildRunName = buildRunName[:253-len(suffix)]
+	}
+
+	return buildRunName + suffix
+}
+
+// localCopyResultName returns the name of the result of the waiter step.
+func localCopyResultName(result string) string {
+	return fmt.Sprintf("%s-%s-%s", prefixParamsResultsVolumes, WaiterContainerName, result)
+}
+
 // AppendLocalCopyStep defines and append a new task based on the waiter container template, passed
-// by the configuration instance.
-func AppendLocalCopyStep(cfg *config.Config, taskSpec *tektonv1beta1.TaskSpec, timeout *metav1.Duration) {
+// by the configuration instance. The waiter serves an endpoint that receives the upload of the
+// source code, authenticated by the token of the informed Secret, and reports its state to the
+// results.
+func AppendLocalCopyStep(cfg *config.Config, taskSpec *tektonv1beta1.TaskSpec, timeout *metav1.Duration, uploadTokenSecret string) {
 	step := *cfg.WaiterContainerTemplate.DeepCopy()
 	// the data upload mechanism targets a specific POD, and in this POD it aims for a specific
 	// container name, and having a static name, makes this process straight forward.
@@ -26,5 +64,109 @@ func AppendLocalCopyStep(cfg *config.Config, taskSpec *tektonv1beta1.TaskSpec, t
 	if timeout != nil {
 		step.Args = append(step.Args, fmt.Sprintf("--timeout=%s", timeout.Duration.String()))
 	}
+
+	// mount the upload token, the upload endpoint unpacks the source code into the source root
+	AppendSecretVolume(taskSpec, uploadTokenSecret)
+
+	uploadTokenMountPath := fmt.Sprintf("/workspace/%s-upload-token", prefixParamsResultsVolumes)
+	step.VolumeMounts = append(step.VolumeMounts, corev1.VolumeMount{
+		Name:      SanitizeVolumeNameForSecretName(uploadTokenSecret),
+		MountPath: uploadTokenMountPath,
+		ReadOnly:  true,
+	})
+
+	step.Args = append(step.Args,
+		fmt.Sprintf("--upload-port=%d", WaiterUploadPort),
+		fmt.Sprintf("--upload-token-file=%s/%s", uploadTokenMountPath, UploadTokenKey),
+		fmt.Sprintf("--upload-target=$(params.%s-%s)", prefixParamsResultsVolumes, paramSourceRoot),
+		fmt.Sprintf("--upload-max-size=%d", cfg.SourceLimits.MaxSize),
+	)
+
+	// append the results
+	taskSpec.Results = append(taskSpec.Results,
+		tektonv1beta1.TaskResult{
+			Name:        localCopyResultName(localCopyStateResult),
+			Description: "The state of the waiting for the upload of the local source.",
+		},
+		tektonv1beta1.TaskResult{
+			Name:        localCopyResultName(localCopyTimeLeftResult),
+			Description: "The time that was left until the timeout.",
+		},
+		tektonv1beta1.TaskResult{
+			Name:        localCopyResultName(localCopyReceivedBytesResult),
+			Description: "The amount of bytes of the uploaded local source.",
+		},
+	)
+
+	step.Args = append(step.Args,
+		fmt.Sprintf("--result-file-state=$(results.%s.path)", localCopyResultName(localCopyStateResult)),
+		fmt.Sprintf("--result-file-time-left=$(results.%s.path)", localCopyResultName(localCopyTimeLeftResult)),
+		fmt.Sprintf("--result-file-received-bytes=$(results.%s.path)", localCopyResultName(localCopyReceivedBytesResult)),
+	)
+
 	taskSpec.Steps = append(taskSpec.Steps, step)
 }
+
+// AppendLocalCopyResult sets the state of the waiter in the BuildRun status. The TaskRun results
+// contain the state once the waiter ended, while it is running the state is derived from the
+// waiter step of the TaskRun.
+func AppendLocalCopyResult(buildRun *buildv1alpha1.BuildRun, name string, timeout *metav1.Duration, taskRun *tektonv1beta1.TaskRun) {
+	result := &buildv1alpha1.LocalCopySourceResult{}
+
+	results := taskRun.Status.TaskRunResults
+	if state := strings.TrimSpace(findResultValue(results, localCopyResultName(localCopyStateResult))); state != "" {
+		result.State = buildv1alpha1.LocalCopyState(state)
+
+		if timeLeft, err := time.ParseDuration(strings.TrimSpace(findResultValue(results, localCopyResultName(localCopyTimeLeftResult)))); err == nil {
+			result.TimeLeft = &metav1.Duration{Duration: timeLeft}
+		}
+
+		if receivedBytes, err := strconv.ParseInt(strings.TrimSpace(findResultValue(results, localCopyResultName(localCopyReceivedBytesResult))), 10, 64); err == nil {
+			result.ReceivedBytes = receivedBytes
+		}
+	} else {
+		var step *tektonv1beta1.StepState
+		for i := range taskRun.Status.Steps {
+			if taskRun.Status.Steps[i].Name == WaiterContainerName {
+				step = &taskRun.Status.Steps[i]
+				break
+			}
+		}
+
+		switch {
+		case step == nil:
+			return
+
+		case step.Running != nil:
+			result.State = buildv1alpha1.LocalCopyWaiting
+
+			if timeout != nil {
+				timeLeft := timeout.Duration - time.Since(step.Running.StartedAt.Time)
+				if timeLeft < 0 {
+					timeLeft = 0
+				}
+				result.TimeLeft = &metav1.Duration{Duration: timeLeft.Round(time.Second)}
+			}
+
+		case step.Terminated != nil && taskRun.IsCancelled():
+			// the POD of a canceled TaskRun is deleted, the results of the waiter are lost then
+			result.State = buildv1alpha1.LocalCopyCanceled
+
+		default:
+			return
+		}
+	}
+
+	// the state changes while the BuildRun is running, replace a previously reported state
+	for i := range buildRun.Status.Sources {
+		if buildRun.Status.Sources[i].Name == name {
+			buildRun.Status.Sources[i].LocalCopy = result
+			return
+		}
+	}
+
+	buildRun.Status.Sources = append(buildRun.Status.Sources, buildv1alpha1.SourceResult{
+		Name:      name,
+		LocalCopy: result,
+	})
+}
@@ -8,10 +8,12 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/config"
 	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
 )
@@ -24,15 +26,117 @@ var _ = Describe("LocalCopy", func() {
 
 		BeforeEach(func() {
 			taskSpec = &tektonv1beta1.TaskSpec{}
-			sources.AppendLocalCopyStep(cfg, taskSpec, &metav1.Duration{Duration: time.Minute})
+			sources.AppendLocalCopyStep(cfg, taskSpec, &metav1.Duration{Duration: time.Minute}, sources.UploadTokenSecretName("buildrun"))
 		})
 
 		It("produces a local-copy step", func() {
-			Expect(len(taskSpec.Results)).To(Equal(0))
 			Expect(len(taskSpec.Steps)).To(Equal(1))
 			Expect(taskSpec.Steps[0].Name).To(Equal(sources.WaiterContainerName))
 			Expect(taskSpec.Steps[0].Image).To(Equal(cfg.WaiterContainerTemplate.Image))
-			Expect(taskSpec.Steps[0].Args).To(Equal([]string{"start", "--timeout=1m0s"}))
+			Expect(taskSpec.Steps[0].Args).To(Equal([]string{
+				"start",
+				"--timeout=1m0s",
+				"--upload-port=8080",
+				"--upload-token-file=/workspace/shp-upload-token/token",
+				"--upload-target=$(params.shp-source-root)",
+				"--upload-max-size=10737418240",
+				"--result-file-state=$(results.shp-source-local-state.path)",
+				"--result-file-time-left=$(results.shp-source-local-time-left.path)",
+				"--result-file-received-bytes=$(results.shp-source-local-received-bytes.path)",
+			}))
+		})
+
+		It("adds the results of the waiter", func() {
+			Expect(taskSpec.Results).To(HaveLen(3))
+			Expect(taskSpec.Results[0].Name).To(Equal("shp-source-local-state"))
+			Expect(taskSpec.Results[1].Name).To(Equal("shp-source-local-time-left"))
+			Expect(taskSpec.Results[2].Name).To(Equal("shp-source-local-received-bytes"))
+		})
+
+		It("mounts the upload token into the local-copy step", func() {
+			Expect(taskSpec.Volumes).To(ContainElement(And(
+				HaveField("Name", "shp-buildrun-upload-token"),
+				HaveField("VolumeSource.Secret.SecretName", "buildrun-upload-token"),
+			)))
+			Expect(taskSpec.Steps[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "shp-buildrun-upload-token",
+				MountPath: "/workspace/shp-upload-token",
+				ReadOnly:  true,
+			}))
+		})
+	})
+
+	Context("when the state of the waiter is surfaced", func() {
+		var (
+			buildRun *buildv1alpha1.BuildRun
+			taskRun  *tektonv1beta1.TaskRun
+			timeout  = &metav1.Duration{Duration: time.Minute}
+		)
+
+		BeforeEach(func() {
+			buildRun = &buildv1alpha1.BuildRun{}
+			taskRun = &tektonv1beta1.TaskRun{}
+		})
+
+		It("reports the waiting while the waiter step is running", func() {
+			taskRun.Status.Steps = []tektonv1beta1.StepState{{
+				Name: sources.WaiterContainerName,
+				ContainerState: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(time.Now().Add(-20 * time.Second))},
+				},
+			}}
+
+			sources.AppendLocalCopyResult(buildRun, "local", timeout, taskRun)
+
+			Expect(buildRun.Status.Sources).To(HaveLen(1))
+			Expect(buildRun.Status.Sources[0].Name).To(Equal("local"))
+			Expect(buildRun.Status.Sources[0].LocalCopy.State).To(Equal(buildv1alpha1.LocalCopyWaiting))
+			Expect(buildRun.Status.Sources[0].LocalCopy.TimeLeft.Duration).To(BeNumerically("~", 40*time.Second, time.Second))
+		})
+
+		It("replaces the waiting with the results of the waiter", func() {
+			buildRun.Status.Sources = []buildv1alpha1.SourceResult{{
+				Name:      "local",
+				LocalCopy: &buildv1alpha1.LocalCopySourceResult{State: buildv1alpha1.LocalCopyWaiting},
+			}}
+
+			taskRun.Status.TaskRunResults = []tektonv1beta1.TaskRunResult{
+				{Name: "shp-source-local-state", Value: *tektonv1beta1.NewArrayOrString("Done")},
+				{Name: "shp-source-local-time-left", Value: *tektonv1beta1.NewArrayOrString("35s")},
+				{Name: "shp-source-local-received-bytes", Value: *tektonv1beta1.NewArrayOrString("1024")},
+			}
+
+			sources.AppendLocalCopyResult(buildRun, "local", timeout, taskRun)
+
+			Expect(buildRun.Status.Sources).To(Equal([]buildv1alpha1.SourceResult{{
+				Name: "local",
+				LocalCopy: &buildv1alpha1.LocalCopySourceResult{
+					State:         buildv1alpha1.LocalCopyDone,
+					TimeLeft:      &metav1.Duration{Duration: 35 * time.Second},
+					ReceivedBytes: 1024,
+				},
+			}}))
+		})
+
+		It("reports the cancellation when the TaskRun was canceled while waiting", func() {
+			taskRun.Spec.Status = tektonv1beta1.TaskRunSpecStatusCancelled
+			taskRun.Status.Steps = []tektonv1beta1.StepState{{
+				Name: sources.WaiterContainerName,
+				ContainerState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: 3},
+				},
+			}}
+
+			sources.AppendLocalCopyResult(buildRun, "local", timeout, taskRun)
+
+			Expect(buildRun.Status.Sources).To(HaveLen(1))
+			Expect(buildRun.Status.Sources[0].LocalCopy.State).To(Equal(buildv1alpha1.LocalCopyCanceled))
+		})
+
+		It("reports nothing before the waiter step started", func() {
+			sources.AppendLocalCopyResult(buildRun, "local", timeout, taskRun)
+
+			Expect(buildRun.Status.Sources).To(BeEmpty())
 		})
 	})
 })
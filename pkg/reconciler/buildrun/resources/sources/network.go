@@ -0,0 +1,117 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	pipeline "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	core "k8s.io/api/core/v1"
+
+	build "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/config"
+)
+
+const (
+	caBundleFileName = "ca-bundle.crt"
+)
+
+var (
+	caBundleVolumeName = fmt.Sprintf("%s-ca-bundle", prefixParamsResultsVolumes)
+	caBundleMountPath  = fmt.Sprintf("/workspace/%s-ca-bundle", prefixParamsResultsVolumes)
+)
+
+// NetworkOptions returns the certificate authority bundle and proxy settings for the steps of a Build,
+// the settings of the Build take precedence over the cluster-wide settings
+func NetworkOptions(cfg *config.Config, network *build.BuildNetwork) config.NetworkOptions {
+	options := cfg.Network
+
+	if network == nil {
+		return options
+	}
+
+	if network.CABundle != nil {
+		options.CABundleConfigMapName = network.CABundle.Name
+		options.CABundleConfigMapKey = network.CABundle.Key
+	}
+
+	if network.HTTPProxy != nil {
+		options.HTTPProxy = *network.HTTPProxy
+	}
+
+	if network.HTTPSProxy != nil {
+		options.HTTPSProxy = *network.HTTPSProxy
+	}
+
+	if network.NoProxy != nil {
+		options.NoProxy = *network.NoProxy
+	}
+
+	return options
+}
+
+// AppendNetworkSettings mounts the certificate authority bundle into the step and defines the proxy environment
+// variables on it. It returns the path of the mounted bundle, or an empty string if no bundle is configured.
+func AppendNetworkSettings(
+	taskSpec *pipeline.TaskSpec,
+	step *pipeline.Step,
+	options config.NetworkOptions,
+) string {
+	// curl, and therefore Git, only reads the lower case http_proxy, Go and most other tools read both
+	for _, proxy := range []struct{ name, value string }{
+		{"HTTP_PROXY", options.HTTPProxy},
+		{"HTTPS_PROXY", options.HTTPSProxy},
+		{"NO_PROXY", options.NoProxy},
+	} {
+		if proxy.value != "" {
+			step.Env = append(step.Env,
+				core.EnvVar{Name: proxy.name, Value: proxy.value},
+				core.EnvVar{Name: strings.ToLower(proxy.name), Value: proxy.value},
+			)
+		}
+	}
+
+	if !options.HasCABundle() {
+		return ""
+	}
+
+	// ensure we do not add the volume twice
+	volumeExists := false
+	for _, volume := range taskSpec.Volumes {
+		if volume.Name == caBundleVolumeName {
+			volumeExists = true
+			break
+		}
+	}
+
+	if !volumeExists {
+		taskSpec.Volumes = append(taskSpec.Volumes, core.Volume{
+			Name: caBundleVolumeName,
+			VolumeSource: core.VolumeSource{
+				ConfigMap: &core.ConfigMapVolumeSource{
+					LocalObjectReference: core.LocalObjectReference{
+						Name: options.CABundleConfigMapName,
+					},
+					Items: []core.KeyToPath{
+						{
+							Key:  options.CABundleConfigMapKey,
+							Path: caBundleFileName,
+						},
+					},
+				},
+			},
+		})
+	}
+
+	step.VolumeMounts = append(step.VolumeMounts, core.VolumeMount{
+		Name:      caBundleVolumeName,
+		MountPath: caBundleMountPath,
+		ReadOnly:  true,
+	})
+
+	return filepath.Join(caBundleMountPath, caBundleFileName)
+}
@@ -0,0 +1,94 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/config"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
+
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+var _ = Describe("Network", func() {
+
+	cfg := config.NewDefaultConfig()
+	cfg.Network.CABundleConfigMapName = "cluster-ca"
+	cfg.Network.HTTPProxy = "http://proxy.example.com:3128"
+	cfg.Network.NoProxy = ".svc"
+
+	Context("when the network options are determined", func() {
+
+		It("uses the cluster-wide settings if the build does not define any", func() {
+			Expect(sources.NetworkOptions(cfg, nil)).To(Equal(cfg.Network))
+		})
+
+		It("overrides the cluster-wide settings with the ones of the build", func() {
+			Expect(sources.NetworkOptions(cfg, &buildv1alpha1.BuildNetwork{
+				CABundle: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "team-ca"},
+					Key:                  "ca.crt",
+				},
+				HTTPProxy: pointer.String(""),
+			})).To(Equal(config.NetworkOptions{
+				CABundleConfigMapName: "team-ca",
+				CABundleConfigMapKey:  "ca.crt",
+				HTTPProxy:             "",
+				NoProxy:               ".svc",
+			}))
+		})
+	})
+
+	Context("when the network settings are appended to a step", func() {
+		var taskSpec *tektonv1beta1.TaskSpec
+
+		BeforeEach(func() {
+			taskSpec = &tektonv1beta1.TaskSpec{
+				Steps: []tektonv1beta1.Step{{Name: "source-default"}, {Name: "source-other"}},
+			}
+		})
+
+		It("mounts the certificate authority bundle and sets the proxy", func() {
+			caBundlePath := sources.AppendNetworkSettings(taskSpec, &taskSpec.Steps[0], cfg.Network)
+			Expect(caBundlePath).To(Equal("/workspace/shp-ca-bundle/ca-bundle.crt"))
+
+			Expect(taskSpec.Volumes).To(HaveLen(1))
+			Expect(taskSpec.Volumes[0].ConfigMap.Name).To(Equal("cluster-ca"))
+			Expect(taskSpec.Volumes[0].ConfigMap.Items).To(Equal([]corev1.KeyToPath{{Key: "ca-bundle.crt", Path: "ca-bundle.crt"}}))
+
+			Expect(taskSpec.Steps[0].VolumeMounts).To(Equal([]corev1.VolumeMount{{
+				Name:      "shp-ca-bundle",
+				MountPath: "/workspace/shp-ca-bundle",
+				ReadOnly:  true,
+			}}))
+			Expect(taskSpec.Steps[0].Env).To(Equal([]corev1.EnvVar{
+				{Name: "HTTP_PROXY", Value: "http://proxy.example.com:3128"},
+				{Name: "http_proxy", Value: "http://proxy.example.com:3128"},
+				{Name: "NO_PROXY", Value: ".svc"},
+				{Name: "no_proxy", Value: ".svc"},
+			}))
+		})
+
+		It("adds the volume only once", func() {
+			sources.AppendNetworkSettings(taskSpec, &taskSpec.Steps[0], cfg.Network)
+			sources.AppendNetworkSettings(taskSpec, &taskSpec.Steps[1], cfg.Network)
+
+			Expect(taskSpec.Volumes).To(HaveLen(1))
+			Expect(taskSpec.Steps[1].VolumeMounts).To(HaveLen(1))
+		})
+
+		It("does nothing without network settings", func() {
+			Expect(sources.AppendNetworkSettings(taskSpec, &taskSpec.Steps[0], config.NetworkOptions{})).To(BeEmpty())
+			Expect(taskSpec.Volumes).To(BeEmpty())
+			Expect(taskSpec.Steps[0].Env).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,80 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"fmt"
+	"path/filepath"
+
+	pipeline "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	core "k8s.io/api/core/v1"
+
+	"github.com/shipwright-io/build/pkg/config"
+)
+
+const (
+	registryTokenFileName = "token"
+)
+
+var (
+	registryTokenVolumeName = fmt.Sprintf("%s-registry-token", prefixParamsResultsVolumes)
+	registryTokenMountPath  = fmt.Sprintf("/var/run/secrets/%s-registry-token", prefixParamsResultsVolumes)
+)
+
+// AppendRegistryTokenVolume mounts a projected ServiceAccount token into the step and configures the
+// step arguments so that the step uses short-lived credentials to authenticate with the container registry
+func AppendRegistryTokenVolume(
+	taskSpec *pipeline.TaskSpec,
+	step *pipeline.Step,
+	options config.RegistryTokenOptions,
+) {
+	// ensure we do not add the volume twice
+	volumeExists := false
+	for _, volume := range taskSpec.Volumes {
+		if volume.Name == registryTokenVolumeName {
+			volumeExists = true
+			break
+		}
+	}
+
+	if !volumeExists {
+		taskSpec.Volumes = append(taskSpec.Volumes, core.Volume{
+			Name: registryTokenVolumeName,
+			VolumeSource: core.VolumeSource{
+				Projected: &core.ProjectedVolumeSource{
+					Sources: []core.VolumeProjection{
+						{
+							ServiceAccountToken: &core.ServiceAccountTokenProjection{
+								Audience: options.Audience,
+								Path:     registryTokenFileName,
+							},
+						},
+					},
+					DefaultMode: secretMountMode,
+				},
+			},
+		})
+	}
+
+	step.VolumeMounts = append(step.VolumeMounts, core.VolumeMount{
+		Name:      registryTokenVolumeName,
+		MountPath: registryTokenMountPath,
+		ReadOnly:  true,
+	})
+
+	step.Args = append(step.Args, "--token-file", filepath.Join(registryTokenMountPath, registryTokenFileName))
+
+	if options.ExchangeEndpoint != "" {
+		step.Args = append(step.Args, "--token-exchange-endpoint", options.ExchangeEndpoint)
+	}
+
+	if options.ExchangeAudience != "" {
+		step.Args = append(step.Args, "--token-exchange-audience", options.ExchangeAudience)
+	}
+
+	if options.Username != "" {
+		step.Args = append(step.Args, "--token-username", options.Username)
+	}
+}
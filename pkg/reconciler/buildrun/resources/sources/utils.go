@@ -6,6 +6,7 @@ package sources
 
 import (
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 
@@ -23,6 +24,9 @@ const (
 var (
 	dnsLabel1123Forbidden = regexp.MustCompile("[^a-zA-Z0-9-]+")
 
+	// sourceResultReference matches a reference to a result of the source step, such as $(source.commit-sha)
+	sourceResultReference = regexp.MustCompile(`\$\(source\.([a-z-]+)\)`)
+
 	// secrets are volumes and volumes are mounted as root, as we run as non-root, we must use 0444 to allow non-root to read it
 	secretMountMode = pointer.Int32(0444)
 )
@@ -78,3 +82,35 @@ func findResultValue(results []tektonv1beta1.TaskRunResult, name string) string
 
 	return ""
 }
+
+// SourceResultFiles returns the result files of the source step with the given name that are referenced in
+// the values, for example in image labels. Only references to results that the TaskSpec defines are returned.
+func SourceResultFiles(taskSpec *tektonv1beta1.TaskSpec, name string, values ...map[string]string) map[string]string {
+	resultFiles := map[string]string{}
+
+	for _, valueMap := range values {
+		for _, value := range valueMap {
+			for _, match := range sourceResultReference.FindAllStringSubmatch(value, -1) {
+				resultName := fmt.Sprintf("%s-source-%s-%s", prefixParamsResultsVolumes, name, match[1])
+
+				for _, result := range taskSpec.Results {
+					if result.Name == resultName {
+						resultFiles[match[1]] = fmt.Sprintf("$(results.%s.path)", resultName)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return resultFiles
+}
+
+// SetTargetDirectory changes the target of a source step to a directory relative to the source root
+func SetTargetDirectory(step *tektonv1beta1.Step, directory string) {
+	for i := 0; i < len(step.Args)-1; i++ {
+		if step.Args[i] == "--target" {
+			step.Args[i+1] = path.Join(step.Args[i+1], directory)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/google/go-containerregistry/pkg/authn"
+	imagename "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	pipeline "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/ctxlog"
+	"github.com/shipwright-io/build/pkg/image"
+)
+
+const stepImagesUserAgent = "shipwright-build-controller"
+
+// ImageDigestResolver resolves an image reference to the digest of the image or image index it points to,
+// using the registry credentials of the keychain
+type ImageDigestResolver func(ctx context.Context, imageName imagename.Reference, keychain authn.Keychain) (string, error)
+
+// ResolveImageDigest resolves the digest of an image reference, registries for which the keychain has no
+// credentials are accessed anonymously
+func ResolveImageDigest(ctx context.Context, imageName imagename.Reference, keychain authn.Keychain) (string, error) {
+	options, _, err := image.GetOptions(ctx, imageName, false, "", "", stepImagesUserAgent)
+	if err != nil {
+		return "", err
+	}
+
+	// the keychain takes precedence over the anonymous authentication of the options
+	options = append(options, remote.WithAuthFromKeychain(keychain))
+
+	return image.ResolveDigest(imageName, options)
+}
+
+// dockerConfigKeychain resolves registry credentials from the Docker configurations of image pull secrets
+type dockerConfigKeychain []*configfile.ConfigFile
+
+// Resolve implements authn.Keychain, the first configuration with credentials for the registry is used
+func (k dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	registryName := target.RegistryStr()
+	if registryName == imagename.DefaultRegistry {
+		registryName = authn.DefaultAuthKey
+	}
+
+	for _, dockerConfig := range k {
+		authConfig, err := dockerConfig.GetAuthConfig(registryName)
+		if err != nil {
+			return nil, err
+		}
+
+		if authConfig.ServerAddress != registryName {
+			continue
+		}
+
+		return authn.FromConfig(authn.AuthConfig{
+			Username:      authConfig.Username,
+			Password:      authConfig.Password,
+			Auth:          authConfig.Auth,
+			IdentityToken: authConfig.IdentityToken,
+			RegistryToken: authConfig.RegistryToken,
+		}), nil
+	}
+
+	return authn.Anonymous, nil
+}
+
+// ServiceAccountKeychain returns a keychain with the registry credentials of the image pull secrets and the
+// secrets of the service account, so that step images can be resolved that the TaskRun POD is able to pull.
+// Secrets that do not exist or do not contain a Docker configuration are ignored.
+func ServiceAccountKeychain(ctx context.Context, client client.Client, serviceAccount *corev1.ServiceAccount) (authn.Keychain, error) {
+	var secretNames []string
+	for _, secret := range serviceAccount.ImagePullSecrets {
+		secretNames = append(secretNames, secret.Name)
+	}
+	for _, secret := range serviceAccount.Secrets {
+		secretNames = append(secretNames, secret.Name)
+	}
+
+	var keychain dockerConfigKeychain
+	for _, secretName := range secretNames {
+		secret := &corev1.Secret{}
+		if err := client.Get(ctx, types.NamespacedName{Namespace: serviceAccount.Namespace, Name: secretName}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				ctxlog.Debug(ctx, "ignoring missing secret of the service account", namespace, serviceAccount.Namespace, name, secretName)
+				continue
+			}
+
+			return nil, err
+		}
+
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			continue
+		}
+
+		dockerConfig, err := config.LoadFromReader(bytes.NewReader(secret.Data[corev1.DockerConfigJsonKey]))
+		if err != nil {
+			ctxlog.Info(ctx, "ignoring invalid Docker configuration", namespace, serviceAccount.Namespace, name, secretName, "error", err.Error())
+			continue
+		}
+
+		// credential helpers are binaries, the controller must not run what a secret names
+		dockerConfig.CredentialsStore = ""
+		dockerConfig.CredentialHelpers = nil
+
+		keychain = append(keychain, dockerConfig)
+	}
+
+	return keychain, nil
+}
+
+// PinStepImages resolves the images of all steps of the TaskRun to their digests, using the credentials of
+// the keychain, and replaces the step images with the digest references. Parameter references in step
+// images are substituted with the TaskRun parameter values first, images that still contain a variable
+// afterwards are left unchanged.
+func PinStepImages(ctx context.Context, taskRun *pipeline.TaskRun, resolve ImageDigestResolver, keychain authn.Keychain) ([]buildv1alpha1.StepImage, error) {
+	if taskRun.Spec.TaskSpec == nil {
+		return nil, nil
+	}
+
+	paramValues := stringParamValues(taskRun)
+
+	var stepImages []buildv1alpha1.StepImage
+	for i := range taskRun.Spec.TaskSpec.Steps {
+		step := &taskRun.Spec.TaskSpec.Steps[i]
+
+		stepImage := buildv1alpha1.StepImage{
+			Name:  step.Name,
+			Image: substituteParams(step.Image, paramValues),
+		}
+
+		if !strings.Contains(stepImage.Image, "$(") {
+			imageName, err := imagename.ParseReference(stepImage.Image)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse image %q of step %q: %w", stepImage.Image, step.Name, err)
+			}
+
+			digest, err := resolve(ctx, imageName, keychain)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve the digest of image %q of step %q: %w", stepImage.Image, step.Name, err)
+			}
+
+			stepImage.Digest = digest
+			step.Image = fmt.Sprintf("%s@%s", imageName.Context().Name(), digest)
+		}
+
+		stepImages = append(stepImages, stepImage)
+	}
+
+	return stepImages, nil
+}
+
+// stringParamValues returns the values of all string parameters of a TaskRun, parameter
+// defaults from the TaskSpec are used for parameters without a value
+func stringParamValues(taskRun *pipeline.TaskRun) map[string]string {
+	values := map[string]string{}
+
+	for _, param := range taskRun.Spec.TaskSpec.Params {
+		if param.Default != nil && param.Default.Type == pipeline.ParamTypeString {
+			values[param.Name] = param.Default.StringVal
+		}
+	}
+
+	for _, param := range taskRun.Spec.Params {
+		if param.Value.Type == pipeline.ParamTypeString {
+			values[param.Name] = param.Value.StringVal
+		}
+	}
+
+	return values
+}
+
+// substituteParams replaces parameter references in a string with their values
+func substituteParams(value string, paramValues map[string]string) string {
+	if !strings.Contains(value, "$(") {
+		return value
+	}
+
+	for paramName, paramValue := range paramValues {
+		value = strings.ReplaceAll(value, fmt.Sprintf("$(params.%s)", paramName), paramValue)
+		value = strings.ReplaceAll(value, fmt.Sprintf("$(inputs.params.%s)", paramName), paramValue)
+	}
+
+	return value
+}
@@ -0,0 +1,192 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	pipeline "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/controller/fakes"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources"
+)
+
+var _ = Describe("PinStepImages", func() {
+
+	const digest = "sha256:3c1e6f4ce7a2f4d8a2b1c5f6e7d8c9b0a1f2e3d4c5b6a7980f1e2d3c4b5a6978"
+
+	var taskRun *pipeline.TaskRun
+
+	fakeResolver := func(_ context.Context, imageName name.Reference, _ authn.Keychain) (string, error) {
+		if imageName.Context().RepositoryStr() == "shipwright-io/unknown" {
+			return "", errors.New("manifest unknown")
+		}
+
+		return digest, nil
+	}
+
+	BeforeEach(func() {
+		taskRun = &pipeline.TaskRun{
+			Spec: pipeline.TaskRunSpec{
+				Params: []pipeline.Param{
+					{
+						Name: "builder-image",
+						Value: pipeline.ArrayOrString{
+							Type:      pipeline.ParamTypeString,
+							StringVal: "ghcr.io/shipwright-io/builder:v1",
+						},
+					},
+				},
+				TaskSpec: &pipeline.TaskSpec{
+					Params: []pipeline.ParamSpec{
+						{
+							Name: "builder-image",
+						},
+						{
+							Name: "tool-image",
+							Default: &pipeline.ArrayOrString{
+								Type:      pipeline.ParamTypeString,
+								StringVal: "ghcr.io/shipwright-io/tool:latest",
+							},
+						},
+					},
+					Steps: []pipeline.Step{
+						{
+							Name:  "source-default",
+							Image: "ghcr.io/shipwright-io/build/git:latest",
+						},
+						{
+							Name:  "build",
+							Image: "$(params.builder-image)",
+						},
+						{
+							Name:  "tool",
+							Image: "$(inputs.params.tool-image)",
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("replaces all step images with digest references", func() {
+		stepImages, err := resources.PinStepImages(context.TODO(), taskRun, fakeResolver, authn.DefaultKeychain)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(taskRun.Spec.TaskSpec.Steps[0].Image).To(Equal("ghcr.io/shipwright-io/build/git@" + digest))
+		Expect(taskRun.Spec.TaskSpec.Steps[1].Image).To(Equal("ghcr.io/shipwright-io/builder@" + digest))
+		Expect(taskRun.Spec.TaskSpec.Steps[2].Image).To(Equal("ghcr.io/shipwright-io/tool@" + digest))
+
+		Expect(stepImages).To(Equal([]buildv1alpha1.StepImage{
+			{Name: "source-default", Image: "ghcr.io/shipwright-io/build/git:latest", Digest: digest},
+			{Name: "build", Image: "ghcr.io/shipwright-io/builder:v1", Digest: digest},
+			{Name: "tool", Image: "ghcr.io/shipwright-io/tool:latest", Digest: digest},
+		}))
+	})
+
+	It("leaves images unchanged that reference unknown variables", func() {
+		taskRun.Spec.TaskSpec.Steps[1].Image = "$(params.unknown-image)"
+
+		stepImages, err := resources.PinStepImages(context.TODO(), taskRun, fakeResolver, authn.DefaultKeychain)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(taskRun.Spec.TaskSpec.Steps[1].Image).To(Equal("$(params.unknown-image)"))
+		Expect(stepImages[1]).To(Equal(buildv1alpha1.StepImage{Name: "build", Image: "$(params.unknown-image)"}))
+	})
+
+	It("fails when an image cannot be resolved", func() {
+		taskRun.Spec.TaskSpec.Steps[0].Image = "ghcr.io/shipwright-io/unknown:latest"
+
+		_, err := resources.PinStepImages(context.TODO(), taskRun, fakeResolver, authn.DefaultKeychain)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("source-default"))
+		Expect(err.Error()).To(ContainSubstring("manifest unknown"))
+	})
+})
+
+var _ = Describe("ServiceAccountKeychain", func() {
+
+	var (
+		client         *fakes.FakeClient
+		serviceAccount *corev1.ServiceAccount
+		secrets        map[string]*corev1.Secret
+	)
+
+	BeforeEach(func() {
+		serviceAccount = &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pipeline",
+				Namespace: "default",
+			},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "missing"}, {Name: "registry"}},
+			Secrets:          []corev1.ObjectReference{{Name: "git"}},
+		}
+
+		secrets = map[string]*corev1.Secret{
+			"registry": {
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"username":"user","password":"pass"}},"credsStore":"evil"}`),
+				},
+			},
+			"git": {
+				Type: corev1.SecretTypeBasicAuth,
+				Data: map[string][]byte{"username": []byte("git"), "password": []byte("secret")},
+			},
+		}
+
+		client = &fakes.FakeClient{}
+		client.GetCalls(func(_ context.Context, key types.NamespacedName, object crc.Object, _ ...crc.GetOption) error {
+			secret, ok := secrets[key.Name]
+			if !ok {
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, key.Name)
+			}
+
+			secret.DeepCopyInto(object.(*corev1.Secret))
+			return nil
+		})
+	})
+
+	It("resolves the credentials of the image pull secrets", func() {
+		keychain, err := resources.ServiceAccountKeychain(context.TODO(), client, serviceAccount)
+		Expect(err).ToNot(HaveOccurred())
+
+		imageName, err := name.ParseReference("registry.example.com/org/builder:latest")
+		Expect(err).ToNot(HaveOccurred())
+
+		authenticator, err := keychain.Resolve(imageName.Context())
+		Expect(err).ToNot(HaveOccurred())
+
+		authConfig, err := authenticator.Authorization()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authConfig.Username).To(Equal("user"))
+		Expect(authConfig.Password).To(Equal("pass"))
+	})
+
+	It("falls back to anonymous access for other registries", func() {
+		keychain, err := resources.ServiceAccountKeychain(context.TODO(), client, serviceAccount)
+		Expect(err).ToNot(HaveOccurred())
+
+		imageName, err := name.ParseReference("ghcr.io/shipwright-io/build/git:latest")
+		Expect(err).ToNot(HaveOccurred())
+
+		authenticator, err := keychain.Resolve(imageName.Context())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authenticator).To(Equal(authn.Anonymous))
+	})
+})
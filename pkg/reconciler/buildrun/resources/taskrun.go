@@ -34,6 +34,10 @@ const (
 	inputParamBuilder    = "BUILDER_IMAGE"
 	inputParamDockerfile = "DOCKERFILE"
 	inputParamContextDir = "CONTEXT_DIR"
+
+	// envVarTektonHermetic is the environment variable that tells the Tekton entrypoint to run the step command in a
+	// network namespace without any network interfaces
+	envVarTektonHermetic = "TEKTON_HERMETIC"
 )
 
 // getStringTransformations gets us MANDATORY replacements using
@@ -202,6 +206,15 @@ func GenerateTaskSpec(
 			return &generatedTaskSpec, fmt.Errorf("error(s) occurred merging environment variables into BuildStrategy %q steps: %s", build.Spec.StrategyName(), err.Error())
 		}
 
+		// Hermetic builds cut off the network for the strategy steps only, the steps that fetch the source
+		// code and process the image keep their network access
+		if build.Spec.IsHermetic() {
+			stepEnv, err = env.MergeEnvVars([]corev1.EnvVar{{Name: envVarTektonHermetic, Value: "1"}}, stepEnv, true)
+			if err != nil {
+				return &generatedTaskSpec, err
+			}
+		}
+
 		step := v1beta1.Step{
 			Image:           taskImage,
 			ImagePullPolicy: containerValue.ImagePullPolicy,
@@ -273,6 +286,11 @@ func GenerateTaskRun(
 		return nil, err
 	}
 
+	// Mount the secrets of the Build and BuildRun into the strategy steps
+	if err := amendTaskSpecWithSecretMounts(taskSpec, strategy, OverrideSecretMounts(build.Spec.Secrets, buildRun.Spec.Secrets)); err != nil {
+		return nil, err
+	}
+
 	// Add BuildRun name reference to the TaskRun labels
 	taskRunLabels := map[string]string{
 		buildv1alpha1.LabelBuildRun:           buildRun.Name,
@@ -311,6 +329,7 @@ func GenerateTaskRun(
 			taskRunAnnotations[key] = value
 		}
 	}
+
 	if len(taskRunAnnotations) > 0 {
 		expectedTaskRun.Annotations = taskRunAnnotations
 	}
@@ -414,7 +433,12 @@ func GenerateTaskRun(
 	if buildRunOutput == nil {
 		buildRunOutput = &buildv1alpha1.Image{}
 	}
-	SetupImageProcessing(expectedTaskRun, cfg, build.Spec.Output, *buildRunOutput)
+	buildOutput := build.Spec.Output
+	if useStandardAnnotations(build.Spec.Output, *buildRunOutput) {
+		buildOutput.Annotations = mergeMaps(standardAnnotations(build, buildRun), build.Spec.Output.Annotations)
+	}
+
+	SetupImageProcessing(expectedTaskRun, cfg, buildOutput, *buildRunOutput, build.Spec.Network)
 
 	return expectedTaskRun, nil
 }
@@ -447,3 +471,21 @@ func toVolumeMap(strategyVolumes []buildv1alpha1.BuildStrategyVolume) map[string
 	}
 	return res
 }
+
+// IsHermeticPod returns true if the strategy steps of the TaskRun POD run without network access, only they
+// carry the environment variable that tells the Tekton entrypoint to cut off the network
+func IsHermeticPod(pod *corev1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if !strings.HasPrefix(container.Name, "step-") {
+			continue
+		}
+
+		for _, envVar := range container.Env {
+			if envVar.Name == envVarTektonHermetic && envVar.Value == "1" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -17,6 +17,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/config"
@@ -94,6 +95,20 @@ var _ = Describe("GenerateTaskrun", func() {
 					"$(results.shp-source-default-commit-author.path)",
 					"--result-file-branch-name",
 					"$(results.shp-source-default-branch-name.path)",
+					"--result-file-submodules",
+					"$(results.shp-source-default-submodules.path)",
+					"--result-file-commit-timestamp",
+					"$(results.shp-source-default-commit-timestamp.path)",
+					"--result-file-committer",
+					"$(results.shp-source-default-committer.path)",
+					"--result-file-commit-message",
+					"$(results.shp-source-default-commit-message.path)",
+					"--result-file-tags",
+					"$(results.shp-source-default-tags.path)",
+					"--result-file-describe",
+					"$(results.shp-source-default-describe.path)",
+					"--result-file-url",
+					"$(results.shp-source-default-url.path)",
 					"--result-file-error-message",
 					"$(results.shp-error-message.path)",
 					"--result-file-error-reason",
@@ -313,6 +328,289 @@ var _ = Describe("GenerateTaskrun", func() {
 			})
 		})
 
+		Context("when the build is hermetic", func() {
+			BeforeEach(func() {
+				build, err = ctl.LoadBuildYAML([]byte(test.MinimalBuildahBuild))
+				Expect(err).To(BeNil())
+				build.Spec.Hermetic = pointer.Bool(true)
+
+				// labels on the output image add the image-processing step
+				build.Spec.Output.Labels = map[string]string{"org.opencontainers.image.vendor": "shipwright"}
+
+				buildRun, err = ctl.LoadBuildRunFromBytes([]byte(test.MinimalBuildahBuildRun))
+				Expect(err).To(BeNil())
+
+				buildStrategy, err = ctl.LoadBuildStrategyFromBytes([]byte(test.MinimalBuildahBuildStrategy))
+				Expect(err).To(BeNil())
+			})
+
+			JustBeforeEach(func() {
+				taskRun, err := resources.GenerateTaskRun(config.NewDefaultConfig(), build, buildRun, "", buildStrategy)
+				Expect(err).ToNot(HaveOccurred())
+				got = taskRun.Spec.TaskSpec
+			})
+
+			It("should cut off the network of the strategy steps only", func() {
+				strategySteps := got.Steps[1 : len(got.Steps)-1]
+				Expect(strategySteps).ToNot(BeEmpty())
+
+				for _, step := range strategySteps {
+					Expect(step.Env).To(ContainElement(corev1.EnvVar{Name: "TEKTON_HERMETIC", Value: "1"}))
+				}
+			})
+
+			It("should keep the network access of the source and image-processing steps", func() {
+				Expect(got.Steps[0].Name).To(Equal("source-default"))
+				Expect(got.Steps[len(got.Steps)-1].Name).To(Equal("image-processing"))
+
+				for _, step := range []v1beta1.Step{got.Steps[0], got.Steps[len(got.Steps)-1]} {
+					Expect(step.Env).ToNot(ContainElement(HaveField("Name", "TEKTON_HERMETIC")))
+				}
+			})
+
+			It("should not use the hermetic execution mode of Tekton for the whole TaskRun", func() {
+				taskRun, err := resources.GenerateTaskRun(config.NewDefaultConfig(), build, buildRun, "", buildStrategy)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(taskRun.Annotations).ToNot(HaveKey("experimental.tekton.dev/execution-mode"))
+			})
+
+			It("should detect whether Tekton enforced the hermetic execution mode", func() {
+				pod := &corev1.Pod{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "step-source-default"},
+							{Name: "step-build-and-push"},
+						},
+					},
+				}
+				Expect(resources.IsHermeticPod(pod)).To(BeFalse())
+
+				for i := range pod.Spec.Containers {
+					pod.Spec.Containers[i].Env = []corev1.EnvVar{{Name: "TEKTON_HERMETIC", Value: "1"}}
+				}
+				Expect(resources.IsHermeticPod(pod)).To(BeTrue())
+			})
+		})
+
+		Context("when the build defines network settings", func() {
+			BeforeEach(func() {
+				build, err = ctl.LoadBuildYAML([]byte(test.MinimalBuildahBuild))
+				Expect(err).To(BeNil())
+				build.Spec.Sources = []buildv1alpha1.BuildSource{{
+					Name: "logo",
+					Type: buildv1alpha1.HTTP,
+					URL:  "https://shipwright.io/icons/logo.svg",
+				}}
+				build.Spec.Network = &buildv1alpha1.BuildNetwork{
+					CABundle: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "trusted-ca"},
+						Key:                  "ca.crt",
+					},
+					HTTPSProxy: pointer.String("http://proxy.example.com:3128"),
+				}
+
+				buildRun, err = ctl.LoadBuildRunFromBytes([]byte(test.MinimalBuildahBuildRun))
+				Expect(err).To(BeNil())
+
+				buildStrategy, err = ctl.LoadBuildStrategyFromBytes([]byte(test.MinimalBuildahBuildStrategy))
+				Expect(err).To(BeNil())
+			})
+
+			JustBeforeEach(func() {
+				taskRun, err := resources.GenerateTaskRun(config.NewDefaultConfig(), build, buildRun, "", buildStrategy)
+				Expect(err).ToNot(HaveOccurred())
+				got = taskRun.Spec.TaskSpec
+			})
+
+			It("should pass the CA bundle and the proxy to the Git step", func() {
+				Expect(got.Steps[0].Name).To(Equal("source-default"))
+				Expect(got.Steps[0].Args).To(ContainElements("--ca-bundle-path", "/workspace/shp-ca-bundle/ca-bundle.crt"))
+				Expect(got.Steps[0].Env).To(ContainElement(corev1.EnvVar{Name: "https_proxy", Value: "http://proxy.example.com:3128"}))
+			})
+
+			It("should pass the CA bundle and the proxy to the HTTP sources step", func() {
+				Expect(got.Steps[1].Name).To(Equal("source-logo"))
+				Expect(got.Steps[1].Args).To(ContainElements("--ca-bundle-path", "/workspace/shp-ca-bundle/ca-bundle.crt"))
+				Expect(got.Steps[1].Env).To(ContainElement(corev1.EnvVar{Name: "https_proxy", Value: "http://proxy.example.com:3128"}))
+			})
+
+			It("should not change the BuildStrategy steps", func() {
+				for _, step := range got.Steps[2:] {
+					Expect(step.Env).ToNot(ContainElement(HaveField("Name", "HTTPS_PROXY")))
+					Expect(step.VolumeMounts).ToNot(utils.ContainNamedElement("shp-ca-bundle"))
+				}
+			})
+		})
+
+		Context("when the build defines named Git and bundle sources", func() {
+			BeforeEach(func() {
+				build, err = ctl.LoadBuildYAML([]byte(test.MinimalBuildahBuild))
+				Expect(err).To(BeNil())
+				build.Spec.Sources = []buildv1alpha1.BuildSource{{
+					Name:        "config",
+					Type:        buildv1alpha1.Git,
+					URL:         "https://github.com/shipwright-io/sample-config",
+					Revision:    pointer.String("v1"),
+					Credentials: &corev1.LocalObjectReference{Name: "config-secret"},
+				}, {
+					Name:            "assets",
+					Type:            buildv1alpha1.Bundle,
+					BundleContainer: &buildv1alpha1.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets:latest"},
+					TargetDir:       pointer.String("static/assets"),
+				}}
+
+				buildRun, err = ctl.LoadBuildRunFromBytes([]byte(test.MinimalBuildahBuildRun))
+				Expect(err).To(BeNil())
+
+				buildStrategy, err = ctl.LoadBuildStrategyFromBytes([]byte(test.MinimalBuildahBuildStrategy))
+				Expect(err).To(BeNil())
+			})
+
+			JustBeforeEach(func() {
+				taskRun, err := resources.GenerateTaskRun(config.NewDefaultConfig(), build, buildRun, "", buildStrategy)
+				Expect(err).ToNot(HaveOccurred())
+				got = taskRun.Spec.TaskSpec
+			})
+
+			It("should contain a step for every source that writes into its target directory", func() {
+				Expect(got.Steps[0].Name).To(Equal("source-default"))
+				Expect(got.Steps[0].Args).To(ContainElements("--target", "$(params.shp-source-root)"))
+
+				Expect(got.Steps[1].Name).To(Equal("source-config"))
+				Expect(got.Steps[1].Args).To(ContainElements(
+					"--url", "https://github.com/shipwright-io/sample-config",
+					"--target", "$(params.shp-source-root)/config",
+					"--revision", "v1",
+					"--secret-path", "/workspace/shp-source-secret",
+				))
+
+				Expect(got.Steps[2].Name).To(Equal("source-assets"))
+				Expect(got.Steps[2].Args).To(ContainElements(
+					"--image", "ghcr.io/shipwright-io/sample-assets:latest",
+					"--target", "$(params.shp-source-root)/static/assets",
+				))
+			})
+
+			It("should contain the results of every source", func() {
+				Expect(got.Results).To(ContainElements(
+					HaveField("Name", "shp-source-default-commit-sha"),
+					HaveField("Name", "shp-source-config-commit-sha"),
+					HaveField("Name", "shp-source-assets-image-digest"),
+				))
+			})
+		})
+
+		Context("when secrets are mounted", func() {
+			BeforeEach(func() {
+				build, err = ctl.LoadBuildYAML([]byte(test.MinimalBuildahBuild))
+				Expect(err).To(BeNil())
+				build.Spec.Secrets = []buildv1alpha1.BuildSecret{
+					{Name: "npmrc", SecretName: "build-npmrc"},
+				}
+
+				buildRun, err = ctl.LoadBuildRunFromBytes([]byte(test.MinimalBuildahBuildRun))
+				Expect(err).To(BeNil())
+				buildRun.Spec.Secrets = []buildv1alpha1.BuildSecret{
+					{Name: "npmrc", SecretName: "buildrun-npmrc", Items: []corev1.KeyToPath{{Key: "config", Path: ".npmrc"}}},
+				}
+
+				buildStrategy, err = ctl.LoadBuildStrategyFromBytes([]byte(test.MinimalBuildahBuildStrategy))
+				Expect(err).To(BeNil())
+				buildStrategy.Spec.Secrets = []buildv1alpha1.BuildStrategySecret{
+					{Name: "npmrc", MountPath: "/secrets/npm"},
+				}
+			})
+
+			JustBeforeEach(func() {
+				taskRun, err := resources.GenerateTaskRun(config.NewDefaultConfig(), build, buildRun, "", buildStrategy)
+				Expect(err).ToNot(HaveOccurred())
+				got = taskRun.Spec.TaskSpec
+			})
+
+			It("should contain a volume for the secret of the BuildRun", func() {
+				Expect(got.Volumes).To(ContainElement(corev1.Volume{
+					Name: "shp-secret-mount-npmrc",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName:  "buildrun-npmrc",
+							Items:       []corev1.KeyToPath{{Key: "config", Path: ".npmrc"}},
+							DefaultMode: pointer.Int32(0444),
+						},
+					},
+				}))
+			})
+
+			It("should mount the secret into every BuildStrategy step", func() {
+				for _, step := range got.Steps[1:] {
+					Expect(step.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+						Name:      "shp-secret-mount-npmrc",
+						MountPath: "/secrets/npm",
+						ReadOnly:  true,
+					}))
+				}
+			})
+
+			It("should not mount the secret into the source step", func() {
+				Expect(got.Steps[0].Name).To(Equal("source-default"))
+				Expect(got.Steps[0].VolumeMounts).ToNot(ContainElement(HaveField("Name", "shp-secret-mount-npmrc")))
+			})
+		})
+
+		Context("when standard annotations are enabled", func() {
+			BeforeEach(func() {
+				build, err = ctl.LoadBuildYAML([]byte(test.MinimalBuildahBuild))
+				Expect(err).To(BeNil())
+				build.Spec.Output.StandardAnnotations = pointer.Bool(true)
+				build.Spec.Output.Annotations = map[string]string{
+					"org.opencontainers.image.title": "sample-go",
+				}
+
+				buildRun, err = ctl.LoadBuildRunFromBytes([]byte(test.MinimalBuildahBuildRun))
+				Expect(err).To(BeNil())
+
+				buildStrategy, err = ctl.LoadBuildStrategyFromBytes([]byte(test.MinimalBuildahBuildStrategy))
+				Expect(err).To(BeNil())
+			})
+
+			JustBeforeEach(func() {
+				taskRun, err := resources.GenerateTaskRun(config.NewDefaultConfig(), build, buildRun, "", buildStrategy)
+				Expect(err).ToNot(HaveOccurred())
+				got = taskRun.Spec.TaskSpec
+			})
+
+			It("should add the standard annotations with references to the source results", func() {
+				step := got.Steps[len(got.Steps)-1]
+				Expect(step.Name).To(Equal("image-processing"))
+				Expect(step.Args).To(ContainElements(
+					"--annotation", "org.opencontainers.image.source=$(source.url)",
+					"--annotation", "org.opencontainers.image.revision=$(source.commit-sha)",
+					"--annotation", "org.opencontainers.image.created=$(source.commit-timestamp)",
+					"--annotation", "build.shipwright.io/name="+build.Name,
+					"--annotation", "buildrun.shipwright.io/name="+buildRun.Name,
+					"--source-result", "commit-sha=$(results.shp-source-default-commit-sha.path)",
+					"--source-result", "commit-timestamp=$(results.shp-source-default-commit-timestamp.path)",
+					"--source-result", "url=$(results.shp-source-default-url.path)",
+				))
+			})
+
+			It("should let annotations of the Build take precedence", func() {
+				step := got.Steps[len(got.Steps)-1]
+				Expect(step.Args).To(ContainElement("org.opencontainers.image.title=sample-go"))
+				Expect(step.Args).ToNot(ContainElement("org.opencontainers.image.title=" + build.Name))
+			})
+
+			It("should not add the standard annotations if the BuildRun disables them", func() {
+				buildRun.Spec.Output = &buildv1alpha1.Image{StandardAnnotations: pointer.Bool(false)}
+
+				taskRun, err := resources.GenerateTaskRun(config.NewDefaultConfig(), build, buildRun, "", buildStrategy)
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, step := range taskRun.Spec.TaskSpec.Steps {
+					Expect(step.Args).ToNot(ContainElement(HavePrefix("org.opencontainers.image.source=")))
+				}
+			})
+		})
+
 		Context("when only BuildRun has output image labels and annotation defined ", func() {
 			BeforeEach(func() {
 				build, err = ctl.LoadBuildYAML([]byte(test.BuildahBuildWithOutput))
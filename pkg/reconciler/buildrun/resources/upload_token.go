@@ -0,0 +1,43 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources/sources"
+)
+
+// GenerateUploadTokenSecret returns the Secret with a random token that authenticates the upload of
+// the local source code of a BuildRun with a LocalCopy source, or nil if the BuildRun has none
+func GenerateUploadTokenSecret(build *buildv1alpha1.Build, buildRun *buildv1alpha1.BuildRun) (*corev1.Secret, error) {
+	if isLocalCopyBuildSource(build, buildRun) == nil {
+		return nil, nil
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sources.UploadTokenSecretName(buildRun.Name),
+			Namespace: buildRun.Namespace,
+			Labels: map[string]string{
+				buildv1alpha1.LabelBuildRun: buildRun.Name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			sources.UploadTokenKey: []byte(hex.EncodeToString(token)),
+		},
+	}, nil
+}
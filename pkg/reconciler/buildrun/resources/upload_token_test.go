@@ -0,0 +1,50 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources"
+)
+
+var _ = Describe("GenerateUploadTokenSecret", func() {
+	var (
+		build    *buildv1alpha1.Build
+		buildRun *buildv1alpha1.BuildRun
+	)
+
+	BeforeEach(func() {
+		build = &buildv1alpha1.Build{}
+		buildRun = &buildv1alpha1.BuildRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "buildrun", Namespace: "ns"},
+		}
+	})
+
+	It("should not generate a Secret for a BuildRun without a LocalCopy source", func() {
+		secret, err := resources.GenerateUploadTokenSecret(build, buildRun)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret).To(BeNil())
+	})
+
+	It("should generate a Secret with a random token for a BuildRun with a LocalCopy source", func() {
+		buildRun.Spec.Sources = []buildv1alpha1.BuildSource{{Name: "local", Type: buildv1alpha1.LocalCopy}}
+
+		secret, err := resources.GenerateUploadTokenSecret(build, buildRun)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret.Name).To(Equal("buildrun-upload-token"))
+		Expect(secret.Namespace).To(Equal("ns"))
+		Expect(secret.Labels).To(HaveKeyWithValue(buildv1alpha1.LabelBuildRun, "buildrun"))
+		Expect(secret.Data).To(HaveKeyWithValue("token", HaveLen(64)))
+
+		other, err := resources.GenerateUploadTokenSecret(build, buildRun)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(other.Data["token"]).ToNot(Equal(secret.Data["token"]))
+	})
+})
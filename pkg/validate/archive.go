@@ -0,0 +1,84 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	containerregname "github.com/google/go-containerregistry/pkg/name"
+	"k8s.io/utils/pointer"
+
+	build "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+)
+
+// archiveChecksum is the format of the checksum of a tar file
+var archiveChecksum = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// SourceArchiveRef contains all required fields
+// to validate the archive of a Build source
+type SourceArchiveRef struct {
+	Build *build.Build // build instance for analysis
+}
+
+// NewSourceArchive returns a SourceArchiveRef for the Build
+func NewSourceArchive(build *build.Build) *SourceArchiveRef {
+	return &SourceArchiveRef{build}
+}
+
+// ValidatePath implements BuildPath interface and validates
+// the URL and the checksum of the `spec.source.archive`
+func (s *SourceArchiveRef) ValidatePath(_ context.Context) error {
+	archive := s.Build.Spec.Source.Archive
+	if archive == nil {
+		return nil
+	}
+
+	if err := s.validate(archive); err != nil {
+		s.Build.Status.Reason = build.BuildReasonPtr(build.SpecSourceArchiveInvalid)
+		s.Build.Status.Message = pointer.String(err.Error())
+	}
+
+	return nil
+}
+
+func (s *SourceArchiveRef) validate(archive *build.Archive) error {
+	if s.Build.Spec.Source.URL != nil || s.Build.Spec.Source.BundleContainer != nil {
+		return fmt.Errorf("only one of 'spec.source.url', 'spec.source.bundleContainer' and 'spec.source.archive' can be defined")
+	}
+
+	archiveURL, err := url.Parse(archive.URL)
+	if err != nil {
+		return fmt.Errorf("the archive URL %q is invalid: %w", archive.URL, err)
+	}
+
+	switch archiveURL.Scheme {
+	case "oci":
+		if archive.Checksum != nil {
+			return fmt.Errorf("the archive checksum is not supported for the oci:// scheme, use the digest of the image instead")
+		}
+
+		if _, err := containerregname.ParseReference(strings.TrimPrefix(archive.URL, "oci://")); err != nil {
+			return fmt.Errorf("the archive URL %q does not reference an image: %w", archive.URL, err)
+		}
+
+	case "https", "http":
+		if archive.Checksum == nil {
+			return fmt.Errorf("the archive checksum is required for the %s:// scheme", archiveURL.Scheme)
+		}
+
+		if !archiveChecksum.MatchString(*archive.Checksum) {
+			return fmt.Errorf("the archive checksum %q is not in the format sha256:<hex>", *archive.Checksum)
+		}
+
+	default:
+		return fmt.Errorf("the archive URL %q has an unsupported scheme, only oci://, https:// and http:// are supported", archive.URL)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/utils/pointer"
+
+	build "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/validate"
+)
+
+var _ = Describe("SourceArchiveRef", func() {
+	var checksum = "sha256:" + strings.Repeat("a", 64)
+
+	validateArchive := func(archive *build.Archive) *build.Build {
+		b := &build.Build{
+			Spec: build.BuildSpec{
+				Source: build.Source{Archive: archive},
+			},
+		}
+
+		Expect(validate.NewSourceArchive(b).ValidatePath(context.TODO())).To(Succeed())
+		return b
+	}
+
+	Context("ValidatePath", func() {
+		It("should successfully validate a build without an archive", func() {
+			b := validateArchive(nil)
+			Expect(b.Status.Reason).To(BeNil())
+		})
+
+		It("should successfully validate an oci:// URL", func() {
+			b := validateArchive(&build.Archive{URL: "oci://ghcr.io/shipwright-io/sample-go/source:latest"})
+			Expect(b.Status.Reason).To(BeNil())
+		})
+
+		It("should successfully validate an https:// URL with a checksum", func() {
+			b := validateArchive(&build.Archive{URL: "https://example.com/source.tar.gz", Checksum: pointer.String(checksum)})
+			Expect(b.Status.Reason).To(BeNil())
+		})
+
+		It("should fail to validate a checksum for an oci:// URL", func() {
+			b := validateArchive(&build.Archive{URL: "oci://ghcr.io/shipwright-io/sample-go/source:latest", Checksum: pointer.String(checksum)})
+			Expect(*b.Status.Reason).To(Equal(build.SpecSourceArchiveInvalid))
+			Expect(*b.Status.Message).To(ContainSubstring("checksum is not supported"))
+		})
+
+		It("should fail to validate an oci:// URL that is no image reference", func() {
+			b := validateArchive(&build.Archive{URL: "oci://ghcr.io/Invalid Image"})
+			Expect(*b.Status.Reason).To(Equal(build.SpecSourceArchiveInvalid))
+		})
+
+		It("should fail to validate an https:// URL without a checksum", func() {
+			b := validateArchive(&build.Archive{URL: "https://example.com/source.tar.gz"})
+			Expect(*b.Status.Reason).To(Equal(build.SpecSourceArchiveInvalid))
+			Expect(*b.Status.Message).To(ContainSubstring("checksum is required"))
+		})
+
+		It("should fail to validate a checksum in another format", func() {
+			b := validateArchive(&build.Archive{URL: "https://example.com/source.tar.gz", Checksum: pointer.String("md5:abc")})
+			Expect(*b.Status.Reason).To(Equal(build.SpecSourceArchiveInvalid))
+			Expect(*b.Status.Message).To(ContainSubstring("not in the format sha256:<hex>"))
+		})
+
+		It("should fail to validate an unsupported scheme", func() {
+			b := validateArchive(&build.Archive{URL: "ftp://example.com/source.tar.gz"})
+			Expect(*b.Status.Reason).To(Equal(build.SpecSourceArchiveInvalid))
+			Expect(*b.Status.Message).To(ContainSubstring("unsupported scheme"))
+		})
+
+		It("should fail to validate an archive together with a Git URL", func() {
+			b := &build.Build{
+				Spec: build.BuildSpec{
+					Source: build.Source{
+						URL:     pointer.String("https://github.com/shipwright-io/sample-go"),
+						Archive: &build.Archive{URL: "oci://ghcr.io/shipwright-io/sample-go/source:latest"},
+					},
+				},
+			}
+
+			Expect(validate.NewSourceArchive(b).ValidatePath(context.TODO())).To(Succeed())
+			Expect(*b.Status.Reason).To(Equal(build.SpecSourceArchiveInvalid))
+		})
+	})
+})
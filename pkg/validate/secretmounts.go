@@ -0,0 +1,156 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/reconciler/buildrun/resources"
+)
+
+// reservedMountPaths are the directories of the source code and of Tekton, secret mounts must not overlap with them
+var reservedMountPaths = []string{"/workspace", "/tekton"}
+
+// BuildSecretMounts validates that the secret mounts specified in the Build are defined in the BuildStrategy
+func BuildSecretMounts(strategySecrets []buildv1alpha1.BuildStrategySecret, buildSecrets []buildv1alpha1.BuildSecret) (bool, buildv1alpha1.BuildReason, string) {
+	if valid, reason, message := validateUniqueSecretMounts(buildSecrets); !valid {
+		return valid, reason, message
+	}
+
+	// secret mounts that the strategy requires can still be provided by the BuildRun
+	return validateSecretMounts(strategySecrets, buildSecrets, true)
+}
+
+// BuildRunSecretMounts validates that the secret mounts specified in Build and BuildRun are defined in the BuildStrategy,
+// and that all secret mounts that the BuildStrategy requires are provided
+func BuildRunSecretMounts(strategySecrets []buildv1alpha1.BuildStrategySecret, buildSecrets []buildv1alpha1.BuildSecret, buildRunSecrets []buildv1alpha1.BuildSecret) (bool, string, string) {
+	// the BuildRun overrides secret mounts of the Build with the same name, but each of them must
+	// only provide a secret mount once
+	if valid, reason, message := validateUniqueSecretMounts(buildSecrets, buildRunSecrets); !valid {
+		return valid, string(reason), message
+	}
+
+	valid, reason, message := validateSecretMounts(strategySecrets, resources.OverrideSecretMounts(buildSecrets, buildRunSecrets), false)
+	return valid, string(reason), message
+}
+
+// BuildRunSecretMountsExist validates that the secrets that the Build and BuildRun provide for secret mounts exist, a
+// missing secret would otherwise keep the POD from starting
+func BuildRunSecretMountsExist(ctx context.Context, c client.Client, namespace string, buildSecrets []buildv1alpha1.BuildSecret, buildRunSecrets []buildv1alpha1.BuildSecret) (bool, string, string, error) {
+	missingSecrets := []string{}
+	for _, secretMount := range resources.OverrideSecretMounts(buildSecrets, buildRunSecrets) {
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretMount.SecretName}, &corev1.Secret{}); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return false, "", "", err
+			}
+
+			missingSecrets = append(missingSecrets, secretMount.SecretName)
+		}
+	}
+
+	if len(missingSecrets) > 0 {
+		return false, buildv1alpha1.BuildRunStateSecretMountSecretNotFound, fmt.Sprintf("The following secrets of secret mounts do not exist: %s", strings.Join(missingSecrets, ", ")), nil
+	}
+
+	return true, "", "", nil
+}
+
+func validateSecretMounts(strategySecrets []buildv1alpha1.BuildStrategySecret, secrets []buildv1alpha1.BuildSecret, ignoreMissingSecretMounts bool) (bool, buildv1alpha1.BuildReason, string) {
+	strategySecretsMap := make(map[string]buildv1alpha1.BuildStrategySecret, len(strategySecrets))
+	mountPaths := make(map[string]string, len(strategySecrets))
+	for _, strategySecret := range strategySecrets {
+		if err := validateSecretMountPath(strategySecret.MountPath); err != nil {
+			return false, buildv1alpha1.SecretMountPathInvalid, fmt.Sprintf("The mount path of the secret mount %s of the build strategy is invalid: %v", strategySecret.Name, err)
+		}
+
+		// a POD cannot mount two volumes at the same path
+		mountPath := path.Clean(strategySecret.MountPath)
+		if other, ok := mountPaths[mountPath]; ok {
+			return false, buildv1alpha1.SecretMountDuplicate, fmt.Sprintf("The secret mounts %s and %s of the build strategy use the same mount path %s", other, strategySecret.Name, mountPath)
+		}
+		mountPaths[mountPath] = strategySecret.Name
+
+		strategySecretsMap[strategySecret.Name] = strategySecret
+	}
+
+	providedSecrets := make(map[string]struct{}, len(secrets))
+	undefinedSecretMounts := []string{}
+	for _, secret := range secrets {
+		if _, ok := strategySecretsMap[secret.Name]; !ok {
+			undefinedSecretMounts = append(undefinedSecretMounts, secret.Name)
+		}
+		providedSecrets[secret.Name] = struct{}{}
+	}
+
+	if len(undefinedSecretMounts) > 0 {
+		return false, buildv1alpha1.UndefinedSecretMount, fmt.Sprintf("The following secret mounts are not defined in the build strategy: %s", strings.Join(undefinedSecretMounts, ", "))
+	}
+
+	if ignoreMissingSecretMounts {
+		return true, "", ""
+	}
+
+	missingSecretMounts := []string{}
+	for _, strategySecret := range strategySecrets {
+		if strategySecret.Optional != nil && *strategySecret.Optional {
+			continue
+		}
+		if _, ok := providedSecrets[strategySecret.Name]; !ok {
+			missingSecretMounts = append(missingSecretMounts, strategySecret.Name)
+		}
+	}
+
+	if len(missingSecretMounts) > 0 {
+		return false, buildv1alpha1.MissingSecretMount, fmt.Sprintf("The following secret mounts of the build strategy require a secret but none was provided: %s", strings.Join(missingSecretMounts, ", "))
+	}
+
+	return true, "", ""
+}
+
+// validateUniqueSecretMounts validates that every list of secrets provides a secret mount only once, the
+// secret mounts of a list would otherwise result in volumes with the same name
+func validateUniqueSecretMounts(secretLists ...[]buildv1alpha1.BuildSecret) (bool, buildv1alpha1.BuildReason, string) {
+	for _, secrets := range secretLists {
+		names := make(map[string]struct{}, len(secrets))
+		duplicateSecretMounts := []string{}
+		for _, secret := range secrets {
+			if _, ok := names[secret.Name]; ok {
+				duplicateSecretMounts = append(duplicateSecretMounts, secret.Name)
+			}
+			names[secret.Name] = struct{}{}
+		}
+
+		if len(duplicateSecretMounts) > 0 {
+			return false, buildv1alpha1.SecretMountDuplicate, fmt.Sprintf("The following secret mounts are provided more than once: %s", strings.Join(duplicateSecretMounts, ", "))
+		}
+	}
+
+	return true, "", ""
+}
+
+// validateSecretMountPath validates that the mount path is absolute and does not overlap with the reserved paths
+func validateSecretMountPath(mountPath string) error {
+	if !path.IsAbs(mountPath) {
+		return fmt.Errorf("%q is not an absolute path", mountPath)
+	}
+
+	mountPath = path.Clean(mountPath)
+	for _, reservedPath := range reservedMountPaths {
+		if mountPath == "/" || mountPath == reservedPath || strings.HasPrefix(mountPath, reservedPath+"/") || strings.HasPrefix(reservedPath, mountPath+"/") {
+			return fmt.Errorf("%q overlaps with %s", mountPath, reservedPath)
+		}
+	}
+
+	return nil
+}
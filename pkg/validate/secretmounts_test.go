@@ -0,0 +1,177 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/controller/fakes"
+	"github.com/shipwright-io/build/pkg/validate"
+)
+
+var _ = Describe("SecretMounts", func() {
+
+	strategySecrets := []buildv1alpha1.BuildStrategySecret{
+		{
+			Name:      "npmrc",
+			MountPath: "/secrets/npm",
+		},
+		{
+			Name:      "pip-conf",
+			MountPath: "/secrets/pip",
+			Optional:  pointer.Bool(true),
+		},
+	}
+
+	Context("for a Build", func() {
+
+		It("accepts secret mounts that the strategy defines", func() {
+			valid, _, _ := validate.BuildSecretMounts(strategySecrets, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+			})
+			Expect(valid).To(BeTrue())
+		})
+
+		It("accepts missing secret mounts because a BuildRun can provide them", func() {
+			valid, _, _ := validate.BuildSecretMounts(strategySecrets, nil)
+			Expect(valid).To(BeTrue())
+		})
+
+		It("rejects secret mounts that the strategy does not define", func() {
+			valid, reason, message := validate.BuildSecretMounts(strategySecrets, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+				{Name: "maven-settings", SecretName: "my-settings"},
+			})
+			Expect(valid).To(BeFalse())
+			Expect(reason).To(Equal(buildv1alpha1.UndefinedSecretMount))
+			Expect(message).To(Equal("The following secret mounts are not defined in the build strategy: maven-settings"))
+		})
+	})
+
+	Context("for a BuildRun", func() {
+
+		It("accepts required secret mounts that the BuildRun provides", func() {
+			valid, _, _ := validate.BuildRunSecretMounts(strategySecrets, nil, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+			})
+			Expect(valid).To(BeTrue())
+		})
+
+		It("rejects required secret mounts that neither the Build nor the BuildRun provide", func() {
+			valid, reason, message := validate.BuildRunSecretMounts(strategySecrets, nil, []buildv1alpha1.BuildSecret{
+				{Name: "pip-conf", SecretName: "my-pip-conf"},
+			})
+			Expect(valid).To(BeFalse())
+			Expect(reason).To(Equal("MissingSecretMount"))
+			Expect(message).To(Equal("The following secret mounts of the build strategy require a secret but none was provided: npmrc"))
+		})
+	})
+
+	Context("with duplicates", func() {
+
+		It("rejects a Build that provides a secret mount twice", func() {
+			valid, reason, message := validate.BuildSecretMounts(strategySecrets, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+				{Name: "npmrc", SecretName: "other-npmrc"},
+			})
+			Expect(valid).To(BeFalse())
+			Expect(reason).To(Equal(buildv1alpha1.SecretMountDuplicate))
+			Expect(message).To(Equal("The following secret mounts are provided more than once: npmrc"))
+		})
+
+		It("rejects a BuildRun that provides a secret mount twice", func() {
+			valid, reason, _ := validate.BuildRunSecretMounts(strategySecrets, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+			}, []buildv1alpha1.BuildSecret{
+				{Name: "pip-conf", SecretName: "my-pip-conf"},
+				{Name: "pip-conf", SecretName: "other-pip-conf"},
+			})
+			Expect(valid).To(BeFalse())
+			Expect(reason).To(Equal("SecretMountDuplicate"))
+		})
+
+		It("accepts a BuildRun that overrides a secret mount of the Build", func() {
+			valid, _, _ := validate.BuildRunSecretMounts(strategySecrets, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+			}, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "other-npmrc"},
+			})
+			Expect(valid).To(BeTrue())
+		})
+
+		It("rejects secret mounts of the strategy with the same mount path", func() {
+			valid, reason, message := validate.BuildSecretMounts([]buildv1alpha1.BuildStrategySecret{
+				{Name: "npmrc", MountPath: "/secrets/npm"},
+				{Name: "yarnrc", MountPath: "/secrets/npm/"},
+			}, nil)
+			Expect(valid).To(BeFalse())
+			Expect(reason).To(Equal(buildv1alpha1.SecretMountDuplicate))
+			Expect(message).To(Equal("The secret mounts npmrc and yarnrc of the build strategy use the same mount path /secrets/npm"))
+		})
+	})
+
+	DescribeTable("mount paths of the strategy",
+		func(mountPath string, valid bool) {
+			result, reason, _ := validate.BuildSecretMounts([]buildv1alpha1.BuildStrategySecret{{Name: "npmrc", MountPath: mountPath}}, nil)
+			Expect(result).To(Equal(valid))
+			if !valid {
+				Expect(reason).To(Equal(buildv1alpha1.SecretMountPathInvalid))
+			}
+		},
+		Entry("accepts an absolute path", "/secrets/npm", true),
+		Entry("accepts a path with a reserved path as prefix of its name", "/workspaces/npm", true),
+		Entry("rejects a relative path", "secrets/npm", false),
+		Entry("rejects the root directory", "/", false),
+		Entry("rejects the workspace", "/workspace", false),
+		Entry("rejects a path inside the workspace", "/workspace/source/.npmrc", false),
+		Entry("rejects a path inside the Tekton directory", "/tekton/results", false),
+		Entry("rejects a path that resolves into the workspace", "/secrets/../workspace", false),
+	)
+
+	Context("for the secrets of a BuildRun", func() {
+		var client *fakes.FakeClient
+
+		BeforeEach(func() {
+			client = &fakes.FakeClient{}
+			client.GetCalls(func(_ context.Context, key types.NamespacedName, _ crc.Object, _ ...crc.GetOption) error {
+				if key.Name == "my-npmrc" {
+					return nil
+				}
+
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, key.Name)
+			})
+		})
+
+		It("accepts existing secrets", func() {
+			valid, _, _, err := validate.BuildRunSecretMountsExist(context.TODO(), client, "default", nil, []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+		})
+
+		It("rejects missing secrets of the BuildRun", func() {
+			valid, reason, message, err := validate.BuildRunSecretMountsExist(context.TODO(), client, "default", []buildv1alpha1.BuildSecret{
+				{Name: "npmrc", SecretName: "my-npmrc"},
+			}, []buildv1alpha1.BuildSecret{
+				{Name: "pip-conf", SecretName: "my-pip-conf"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+			Expect(reason).To(Equal(buildv1alpha1.BuildRunStateSecretMountSecretNotFound))
+			Expect(message).To(Equal("The following secrets of secret mounts do not exist: my-pip-conf"))
+		})
+	})
+})
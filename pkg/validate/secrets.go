@@ -67,8 +67,21 @@ func (s Credentials) buildCredentialserences() map[string]build.BuildReason {
 	if s.Build.Spec.Source.Credentials != nil && s.Build.Spec.Source.Credentials.Name != "" {
 		secretRefMap[s.Build.Spec.Source.Credentials.Name] = build.SpecSourceSecretRefNotFound
 	}
+	if s.Build.Spec.Source.SignatureVerification != nil && s.Build.Spec.Source.SignatureVerification.SecretRef != nil && s.Build.Spec.Source.SignatureVerification.SecretRef.Name != "" {
+		secretRefMap[s.Build.Spec.Source.SignatureVerification.SecretRef.Name] = build.SpecSourceSignatureKeysSecretRefNotFound
+	}
 	if s.Build.Spec.Builder != nil && s.Build.Spec.Builder.Credentials != nil && s.Build.Spec.Builder.Credentials.Name != "" {
 		secretRefMap[s.Build.Spec.Builder.Credentials.Name] = build.SpecBuilderSecretRefNotFound
 	}
+	for _, source := range s.Build.Spec.Sources {
+		if source.Credentials != nil && source.Credentials.Name != "" {
+			secretRefMap[source.Credentials.Name] = build.SpecSourceSecretRefNotFound
+		}
+	}
+	for _, secret := range s.Build.Spec.Secrets {
+		if secret.SecretName != "" {
+			secretRefMap[secret.SecretName] = build.SpecSecretMountSecretRefNotFound
+		}
+	}
 	return secretRefMap
 }
@@ -8,10 +8,22 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/pointer"
 
 	build "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 )
 
+// defaultSourceName is the name of the `build.spec.source`, which named sources cannot use
+const defaultSourceName = "default"
+
+// checksumFormat is the format of the checksum of the file of a HTTP source
+var checksumFormat = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
 // SourcesRef implements RuntimeRef interface to add validations for `build.spec.sources` slice.
 type SourcesRef struct {
 	Build *build.Build // build instance for analysis
@@ -20,19 +32,34 @@ type SourcesRef struct {
 // ValidatePath executes the validation routine, inspecting the `build.spec.sources` path, which
 // contains a slice of BuildSource.
 func (s *SourcesRef) ValidatePath(_ context.Context) error {
+	names := map[string]bool{}
 	for _, source := range s.Build.Spec.Sources {
-		if err := s.validateSourceEntry(source); err != nil {
+		if err := s.validateSourceEntry(source, names); err != nil {
+			s.Build.Status.Reason = build.BuildReasonPtr(build.SpecSourcesInvalid)
+			s.Build.Status.Message = pointer.String(err.Error())
 			return err
 		}
 	}
+
+	if err := validateTargetDirectories(s.Build.Spec.Sources); err != nil {
+		s.Build.Status.Reason = build.BuildReasonPtr(build.SpecSourcesInvalid)
+		s.Build.Status.Message = pointer.String(err.Error())
+		return err
+	}
 	return nil
 }
 
 // validateSourceEntry inspect informed entry, probes all required attributes.
-func (s *SourcesRef) validateSourceEntry(source build.BuildSource) error {
+func (s *SourcesRef) validateSourceEntry(source build.BuildSource, names map[string]bool) error {
 	if source.Name == "" {
 		return fmt.Errorf("name must be informed")
 	}
+
+	switch source.Type {
+	case build.HTTP, build.Git, build.Bundle:
+		return s.validateNamedSource(source, names)
+	}
+
 	if source.URL == "" {
 		return fmt.Errorf("URL must be informed")
 	}
@@ -42,6 +69,143 @@ func (s *SourcesRef) validateSourceEntry(source build.BuildSource) error {
 	return nil
 }
 
+// validateNamedSource inspects a HTTP, Git or Bundle entry, which gets its own step and results.
+// The name is part of the names of the step and the results, therefore HTTP entries, which
+// shared a single step before, must use a DNS-1123 label as well.
+func (s *SourcesRef) validateNamedSource(source build.BuildSource, names map[string]bool) error {
+	if errs := validation.IsDNS1123Label(source.Name); len(errs) > 0 {
+		return fmt.Errorf("name %q of the source is invalid: %s", source.Name, strings.Join(errs, ", "))
+	}
+
+	if source.Name == defaultSourceName {
+		return fmt.Errorf("name %q of the source is reserved for spec.source", source.Name)
+	}
+
+	if names[source.Name] {
+		return fmt.Errorf("name %q of the source is not unique", source.Name)
+	}
+	names[source.Name] = true
+
+	if source.Type != build.HTTP && (source.Checksum != nil || source.FileName != nil || source.Extract != nil) {
+		return fmt.Errorf("checksum, fileName and extract are only supported for HTTP sources, not for the source %q", source.Name)
+	}
+
+	if source.Timeout != nil {
+		return fmt.Errorf("timeout is only supported for LocalCopy sources, not for the source %q", source.Name)
+	}
+
+	switch source.Type {
+	case build.HTTP:
+		if err := validateHTTPSource(source); err != nil {
+			return err
+		}
+
+	case build.Git:
+		if source.URL == "" {
+			return fmt.Errorf("URL of the Git source %q must be informed", source.Name)
+		}
+		if source.BundleContainer != nil {
+			return fmt.Errorf("bundleContainer is not supported for the Git source %q", source.Name)
+		}
+
+	case build.Bundle:
+		if source.BundleContainer == nil || source.BundleContainer.Image == "" {
+			return fmt.Errorf("bundleContainer.image of the Bundle source %q must be informed", source.Name)
+		}
+		if source.URL != "" || source.Revision != nil {
+			return fmt.Errorf("url and revision are not supported for the Bundle source %q", source.Name)
+		}
+	}
+
+	if source.TargetDir != nil {
+		targetDir := path.Clean(*source.TargetDir)
+		if path.IsAbs(targetDir) || targetDir == "." || targetDir == ".." || strings.HasPrefix(targetDir, "../") {
+			return fmt.Errorf("targetDir %q of the source %q must be a subdirectory of the source root", *source.TargetDir, source.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateTargetDirectories rejects named sources that are written into the directory of another
+// source. Git and Bundle sources need a directory of their own, which defaults to their name, while
+// HTTP sources only add files, and therefore can share a directory with each other.
+func validateTargetDirectories(sources []build.BuildSource) error {
+	type targetDirectory struct {
+		source    string
+		dir       string
+		exclusive bool
+	}
+
+	// within returns true if the directory is the parent directory or inside of it
+	within := func(dir string, parent string) bool {
+		return dir == parent || strings.HasPrefix(dir, parent+"/")
+	}
+
+	var targetDirs []targetDirectory
+	for _, source := range sources {
+		current := targetDirectory{source: source.Name, dir: source.Name, exclusive: source.Type != build.HTTP}
+
+		switch {
+		case source.Type != build.HTTP && source.Type != build.Git && source.Type != build.Bundle:
+			continue
+
+		case source.TargetDir != nil:
+			current.dir = path.Clean(*source.TargetDir)
+
+		case source.Type == build.HTTP:
+			// without a target directory, the files are written to the source root
+			continue
+		}
+
+		for _, other := range targetDirs {
+			if (other.exclusive && within(current.dir, other.dir)) || (current.exclusive && within(other.dir, current.dir)) {
+				return fmt.Errorf("target directory %q of the source %q overlaps with the target directory %q of the source %q", current.dir, current.source, other.dir, other.source)
+			}
+		}
+
+		targetDirs = append(targetDirs, current)
+	}
+
+	return nil
+}
+
+// validateHTTPSource inspects the URL, the checksum and the file name of a HTTP entry
+func validateHTTPSource(source build.BuildSource) error {
+	if source.URL == "" {
+		return fmt.Errorf("URL of the HTTP source %q must be informed", source.Name)
+	}
+
+	sourceURL, err := url.ParseRequestURI(source.URL)
+	if err != nil {
+		return err
+	}
+
+	if sourceURL.Scheme != "http" && sourceURL.Scheme != "https" {
+		return fmt.Errorf("URL of the HTTP source %q must use the http:// or https:// scheme", source.Name)
+	}
+
+	if source.BundleContainer != nil || source.Revision != nil {
+		return fmt.Errorf("bundleContainer and revision are not supported for the HTTP source %q", source.Name)
+	}
+
+	if source.Checksum != nil && !checksumFormat.MatchString(*source.Checksum) {
+		return fmt.Errorf("checksum %q of the HTTP source %q is not in the format sha256:<hex>", *source.Checksum, source.Name)
+	}
+
+	if source.FileName != nil {
+		if source.Extract != nil && *source.Extract {
+			return fmt.Errorf("fileName cannot be used together with extract for the HTTP source %q", source.Name)
+		}
+
+		if fileName := *source.FileName; fileName == "" || fileName == "." || fileName == ".." || strings.ContainsAny(fileName, `/\`) {
+			return fmt.Errorf("fileName %q of the HTTP source %q must be a file name without a path", fileName, source.Name)
+		}
+	}
+
+	return nil
+}
+
 // NewSourcesRef instantiate a new SourcesRef passing the build object pointer along.
 func NewSourcesRef(b *build.Build) *SourcesRef {
 	return &SourcesRef{Build: b}
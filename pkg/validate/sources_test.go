@@ -6,9 +6,13 @@ package validate_test
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	build "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	"github.com/shipwright-io/build/pkg/validate"
@@ -69,5 +73,133 @@ var _ = Describe("SourcesRef", func() {
 
 			Expect(srcRef.ValidatePath(context.TODO())).To(HaveOccurred())
 		})
+
+		It("should successfully validate named Git and Bundle sources", func() {
+			srcRef := validate.NewSourcesRef(&build.Build{
+				Spec: build.BuildSpec{
+					Sources: []build.BuildSource{
+						{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", Revision: pointer.String("v1")},
+						{Name: "assets", Type: build.Bundle, BundleContainer: &build.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets"}, TargetDir: pointer.String("static/assets")},
+					},
+				},
+			})
+
+			Expect(srcRef.ValidatePath(context.TODO())).To(BeNil())
+		})
+
+		It("should successfully validate HTTP sources that share a directory next to a Git source", func() {
+			srcRef := validate.NewSourcesRef(&build.Build{
+				Spec: build.BuildSpec{
+					Sources: []build.BuildSource{
+						{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", TargetDir: pointer.String("deploy/config")},
+						{Name: "logo", Type: build.HTTP, URL: "https://shipwright.io/icons/logo.svg", TargetDir: pointer.String("deploy/static")},
+						{Name: "icon", Type: build.HTTP, URL: "https://shipwright.io/icons/icon.svg", TargetDir: pointer.String("deploy/static/")},
+						{Name: "readme", Type: build.HTTP, URL: "https://shipwright.io/README.md"},
+					},
+				},
+			})
+
+			Expect(srcRef.ValidatePath(context.TODO())).To(BeNil())
+		})
+
+		It("should successfully validate a HTTP source with a checksum and a file name", func() {
+			srcRef := validate.NewSourcesRef(&build.Build{
+				Spec: build.BuildSpec{
+					Sources: []build.BuildSource{
+						{Name: "logo", Type: build.HTTP, URL: "https://shipwright.io/icons/logo.svg", Checksum: pointer.String("sha256:" + strings.Repeat("0", 64)), FileName: pointer.String("shipwright.svg")},
+					},
+				},
+			})
+
+			Expect(srcRef.ValidatePath(context.TODO())).To(BeNil())
+		})
+
+		DescribeTable("should fail to validate an invalid named source",
+			func(sources []build.BuildSource, message string) {
+				b := &build.Build{Spec: build.BuildSpec{Sources: sources}}
+
+				Expect(validate.NewSourcesRef(b).ValidatePath(context.TODO())).To(HaveOccurred())
+				Expect(*b.Status.Reason).To(Equal(build.SpecSourcesInvalid))
+				Expect(*b.Status.Message).To(ContainSubstring(message))
+			},
+			Entry("invalid name",
+				[]build.BuildSource{{Name: "Config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config"}},
+				`name "Config" of the source is invalid`),
+			Entry("reserved name",
+				[]build.BuildSource{{Name: "default", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config"}},
+				`name "default" of the source is reserved`),
+			Entry("duplicate name",
+				[]build.BuildSource{
+					{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config"},
+					{Name: "config", Type: build.Bundle, BundleContainer: &build.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets"}},
+				},
+				`name "config" of the source is not unique`),
+			Entry("Git source without URL",
+				[]build.BuildSource{{Name: "config", Type: build.Git}},
+				`URL of the Git source "config" must be informed`),
+			Entry("Bundle source without image",
+				[]build.BuildSource{{Name: "assets", Type: build.Bundle}},
+				`bundleContainer.image of the Bundle source "assets" must be informed`),
+			Entry("Bundle source with revision",
+				[]build.BuildSource{{Name: "assets", Type: build.Bundle, BundleContainer: &build.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets"}, Revision: pointer.String("main")}},
+				`url and revision are not supported for the Bundle source "assets"`),
+			Entry("absolute targetDir",
+				[]build.BuildSource{{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", TargetDir: pointer.String("/etc")}},
+				`targetDir "/etc" of the source "config" must be a subdirectory`),
+			Entry("HTTP source with an unsupported scheme",
+				[]build.BuildSource{{Name: "logo", Type: build.HTTP, URL: "ftp://shipwright.io/icons/logo.svg"}},
+				`URL of the HTTP source "logo" must use the http:// or https:// scheme`),
+			Entry("HTTP source with an invalid checksum",
+				[]build.BuildSource{{Name: "logo", Type: build.HTTP, URL: "https://shipwright.io/icons/logo.svg", Checksum: pointer.String("md5:abc")}},
+				`checksum "md5:abc" of the HTTP source "logo" is not in the format sha256:<hex>`),
+			Entry("HTTP source with a file name that contains a path",
+				[]build.BuildSource{{Name: "logo", Type: build.HTTP, URL: "https://shipwright.io/icons/logo.svg", FileName: pointer.String("../logo.svg")}},
+				`fileName "../logo.svg" of the HTTP source "logo" must be a file name without a path`),
+			Entry("HTTP source with a file name to extract",
+				[]build.BuildSource{{Name: "assets", Type: build.HTTP, URL: "https://shipwright.io/assets.tar.gz", FileName: pointer.String("assets.tar.gz"), Extract: pointer.Bool(true)}},
+				`fileName cannot be used together with extract for the HTTP source "assets"`),
+			Entry("Git source with a checksum",
+				[]build.BuildSource{{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", Checksum: pointer.String("sha256:" + strings.Repeat("0", 64))}},
+				`checksum, fileName and extract are only supported for HTTP sources, not for the source "config"`),
+			Entry("Git source with a timeout",
+				[]build.BuildSource{{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", Timeout: &metav1.Duration{Duration: time.Minute}}},
+				`timeout is only supported for LocalCopy sources, not for the source "config"`),
+			Entry("HTTP source with an invalid name",
+				[]build.BuildSource{{Name: "project_logo", Type: build.HTTP, URL: "https://shipwright.io/icons/logo.svg"}},
+				`name "project_logo" of the source is invalid`),
+			Entry("targetDir escaping the source root",
+				[]build.BuildSource{{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", TargetDir: pointer.String("a/../../b")}},
+				`targetDir "a/../../b" of the source "config" must be a subdirectory`),
+			Entry("Git source with the default target directory of another source",
+				[]build.BuildSource{
+					{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config"},
+					{Name: "assets", Type: build.Bundle, BundleContainer: &build.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets"}, TargetDir: pointer.String("./config")},
+				},
+				`target directory "config" of the source "assets" overlaps with the target directory "config" of the source "config"`),
+			Entry("Git source inside of the target directory of another source",
+				[]build.BuildSource{
+					{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", TargetDir: pointer.String("deploy")},
+					{Name: "assets", Type: build.Bundle, BundleContainer: &build.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets"}, TargetDir: pointer.String("deploy/assets")},
+				},
+				`target directory "deploy/assets" of the source "assets" overlaps with the target directory "deploy" of the source "config"`),
+			Entry("Git source containing the target directory of another source",
+				[]build.BuildSource{
+					{Name: "assets", Type: build.Bundle, BundleContainer: &build.BundleContainer{Image: "ghcr.io/shipwright-io/sample-assets"}, TargetDir: pointer.String("deploy/assets")},
+					{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config", TargetDir: pointer.String("deploy")},
+				},
+				`target directory "deploy" of the source "config" overlaps with the target directory "deploy/assets" of the source "assets"`),
+			Entry("HTTP source inside of the target directory of a Git source",
+				[]build.BuildSource{
+					{Name: "config", Type: build.Git, URL: "https://github.com/shipwright-io/sample-config"},
+					{Name: "logo", Type: build.HTTP, URL: "https://shipwright.io/icons/logo.svg", TargetDir: pointer.String("config/static")},
+				},
+				`target directory "config/static" of the source "logo" overlaps with the target directory "config" of the source "config"`),
+			Entry("Git source with the target directory of a HTTP source",
+				[]build.BuildSource{
+					{Name: "logo", Type: build.HTTP, URL: "https://shipwright.io/icons/logo.svg", TargetDir: pointer.String("static")},
+					{Name: "static", Type: build.Git, URL: "https://github.com/shipwright-io/sample-static"},
+				},
+				`target directory "static" of the source "static" overlaps with the target directory "static" of the source "logo"`),
+		)
 	})
 })
@@ -65,6 +65,7 @@ func (s Strategy) ValidatePath(ctx context.Context) error {
 	if strategyExists {
 		s.validateBuildParams(builderStrategy.GetParameters())
 		s.validateBuildVolumes(builderStrategy.GetVolumes())
+		s.validateBuildSecretMounts(builderStrategy.GetSecrets())
 	}
 
 	return nil
@@ -109,3 +110,12 @@ func (s Strategy) validateBuildVolumes(strategyVolumes []build.BuildStrategyVolu
 		s.Build.Status.Message = pointer.String(message)
 	}
 }
+
+func (s Strategy) validateBuildSecretMounts(strategySecrets []build.BuildStrategySecret) {
+	valid, reason, message := BuildSecretMounts(strategySecrets, s.Build.Spec.Secrets)
+
+	if !valid {
+		s.Build.Status.Reason = build.BuildReasonPtr(reason)
+		s.Build.Status.Message = pointer.String(message)
+	}
+}
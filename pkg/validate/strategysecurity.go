@@ -0,0 +1,89 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/config"
+)
+
+// BuildRunStrategySecurity validates that a BuildRun in the given namespace is allowed to use the build strategy in case
+// the strategy contains steps that require elevated privileges, and such strategies are restricted in the configuration
+func BuildRunStrategySecurity(options config.StrategySecurityOptions, strategy buildv1alpha1.BuilderStrategy, namespace string) (bool, string, string) {
+	if !options.RestrictPrivileged {
+		return true, "", ""
+	}
+
+	if containsNamespace(options.PrivilegedNamespaces, namespace) {
+		return true, "", ""
+	}
+
+	// only cluster build strategies are managed by the cluster administrator and can therefore grant access themselves
+	if _, isClusterBuildStrategy := strategy.(*buildv1alpha1.ClusterBuildStrategy); isClusterBuildStrategy {
+		if privilegedNamespaces, ok := strategy.GetAnnotations()[buildv1alpha1.AnnotationPrivilegedNamespaces]; ok && containsNamespace(strings.Split(privilegedNamespaces, ","), namespace) {
+			return true, "", ""
+		}
+	}
+
+	for _, step := range strategy.GetBuildSteps() {
+		if privileges := elevatedPrivileges(step.SecurityContext); len(privileges) > 0 {
+			return false, buildv1alpha1.BuildRunStatePrivilegedStrategyNotAllowed, fmt.Sprintf("step %q of the build strategy %q requires elevated privileges (%s) which are not allowed in namespace %q",
+				step.Name,
+				strategy.GetName(),
+				strings.Join(privileges, ", "),
+				namespace,
+			)
+		}
+	}
+
+	return true, "", ""
+}
+
+// elevatedPrivileges lists the privileges beyond those of a regular user that a security context requests. A step
+// that neither sets runAsNonRoot nor a non-root runAsUser runs as the default user of its image, which often is root.
+func elevatedPrivileges(securityContext *corev1.SecurityContext) []string {
+	if securityContext == nil {
+		return []string{"may run as root"}
+	}
+
+	var privileges []string
+
+	if securityContext.Privileged != nil && *securityContext.Privileged {
+		privileges = append(privileges, "privileged")
+	}
+
+	if securityContext.Capabilities != nil && len(securityContext.Capabilities.Add) > 0 {
+		capabilities := make([]string, len(securityContext.Capabilities.Add))
+		for i, capability := range securityContext.Capabilities.Add {
+			capabilities[i] = string(capability)
+		}
+		privileges = append(privileges, fmt.Sprintf("added capabilities %s", strings.Join(capabilities, ", ")))
+	}
+
+	switch {
+	case securityContext.RunAsUser != nil && *securityContext.RunAsUser == 0:
+		privileges = append(privileges, "runs as root")
+
+	case securityContext.RunAsUser == nil && (securityContext.RunAsNonRoot == nil || !*securityContext.RunAsNonRoot):
+		privileges = append(privileges, "may run as root")
+	}
+
+	return privileges
+}
+
+func containsNamespace(namespaces []string, namespace string) bool {
+	for _, candidate := range namespaces {
+		if candidate = strings.TrimSpace(candidate); candidate == namespace || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright The Shipwright Contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	buildv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/shipwright-io/build/pkg/config"
+	"github.com/shipwright-io/build/pkg/validate"
+)
+
+var _ = Describe("BuildRunStrategySecurity", func() {
+
+	restricted := config.StrategySecurityOptions{
+		RestrictPrivileged:   true,
+		PrivilegedNamespaces: []string{"trusted"},
+	}
+
+	privilegedSteps := []buildv1alpha1.BuildStep{
+		{
+			Container: corev1.Container{
+				Name: "build-and-push",
+				SecurityContext: &corev1.SecurityContext{
+					Privileged: pointer.Bool(true),
+				},
+			},
+		},
+	}
+
+	unprivilegedSteps := []buildv1alpha1.BuildStep{
+		{
+			Container: corev1.Container{
+				Name: "build-and-push",
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser: pointer.Int64(1000),
+				},
+			},
+		},
+	}
+
+	buildStrategy := func(steps []buildv1alpha1.BuildStep) *buildv1alpha1.BuildStrategy {
+		return &buildv1alpha1.BuildStrategy{
+			ObjectMeta: metav1.ObjectMeta{Name: "buildah"},
+			Spec:       buildv1alpha1.BuildStrategySpec{BuildSteps: steps},
+		}
+	}
+
+	clusterBuildStrategy := func(steps []buildv1alpha1.BuildStep, annotations map[string]string) *buildv1alpha1.ClusterBuildStrategy {
+		return &buildv1alpha1.ClusterBuildStrategy{
+			ObjectMeta: metav1.ObjectMeta{Name: "buildah", Annotations: annotations},
+			Spec:       buildv1alpha1.BuildStrategySpec{BuildSteps: steps},
+		}
+	}
+
+	It("allows privileged strategies if no restriction is configured", func() {
+		valid, _, _ := validate.BuildRunStrategySecurity(config.StrategySecurityOptions{}, buildStrategy(privilegedSteps), "tenant")
+		Expect(valid).To(BeTrue())
+	})
+
+	It("allows unprivileged strategies in any namespace", func() {
+		valid, _, _ := validate.BuildRunStrategySecurity(restricted, clusterBuildStrategy(unprivilegedSteps, nil), "tenant")
+		Expect(valid).To(BeTrue())
+	})
+
+	It("allows privileged strategies in an allowed namespace", func() {
+		valid, _, _ := validate.BuildRunStrategySecurity(restricted, clusterBuildStrategy(privilegedSteps, nil), "trusted")
+		Expect(valid).To(BeTrue())
+	})
+
+	It("rejects privileged strategies in other namespaces", func() {
+		valid, reason, message := validate.BuildRunStrategySecurity(restricted, clusterBuildStrategy(privilegedSteps, nil), "tenant")
+		Expect(valid).To(BeFalse())
+		Expect(reason).To(Equal("PrivilegedStrategyNotAllowed"))
+		Expect(message).To(Equal(`step "build-and-push" of the build strategy "buildah" requires elevated privileges (privileged, may run as root) which are not allowed in namespace "tenant"`))
+	})
+
+	It("rejects strategies with added capabilities or running as root", func() {
+		steps := []buildv1alpha1.BuildStep{
+			{
+				Container: corev1.Container{
+					Name: "build",
+					SecurityContext: &corev1.SecurityContext{
+						RunAsUser: pointer.Int64(0),
+						Capabilities: &corev1.Capabilities{
+							Add: []corev1.Capability{"SETUID", "SETGID"},
+						},
+					},
+				},
+			},
+		}
+
+		valid, _, message := validate.BuildRunStrategySecurity(restricted, buildStrategy(steps), "tenant")
+		Expect(valid).To(BeFalse())
+		Expect(message).To(ContainSubstring("added capabilities SETUID, SETGID, runs as root"))
+	})
+
+	It("rejects strategies with steps that may run as the root user of their image", func() {
+		steps := []buildv1alpha1.BuildStep{
+			{
+				Container: corev1.Container{
+					Name: "build",
+				},
+			},
+		}
+
+		valid, reason, message := validate.BuildRunStrategySecurity(restricted, buildStrategy(steps), "tenant")
+		Expect(valid).To(BeFalse())
+		Expect(reason).To(Equal(buildv1alpha1.BuildRunStatePrivilegedStrategyNotAllowed))
+		Expect(message).To(ContainSubstring("(may run as root)"))
+	})
+
+	It("allows strategies with steps that must run as non-root user", func() {
+		steps := []buildv1alpha1.BuildStep{
+			{
+				Container: corev1.Container{
+					Name: "build",
+					SecurityContext: &corev1.SecurityContext{
+						RunAsNonRoot: pointer.Bool(true),
+					},
+				},
+			},
+		}
+
+		valid, _, _ := validate.BuildRunStrategySecurity(restricted, buildStrategy(steps), "tenant")
+		Expect(valid).To(BeTrue())
+	})
+
+	It("allows privileged cluster build strategies in namespaces listed in the strategy annotation", func() {
+		strategy := clusterBuildStrategy(privilegedSteps, map[string]string{
+			buildv1alpha1.AnnotationPrivilegedNamespaces: "team-a, tenant",
+		})
+
+		valid, _, _ := validate.BuildRunStrategySecurity(restricted, strategy, "tenant")
+		Expect(valid).To(BeTrue())
+	})
+
+	It("ignores the annotation on namespaced build strategies", func() {
+		strategy := buildStrategy(privilegedSteps)
+		strategy.Annotations = map[string]string{
+			buildv1alpha1.AnnotationPrivilegedNamespaces: "tenant",
+		}
+
+		valid, _, _ := validate.BuildRunStrategySecurity(restricted, strategy, "tenant")
+		Expect(valid).To(BeFalse())
+	})
+})
@@ -22,6 +22,8 @@ const (
 	Strategies = "strategy"
 	// SourceURL for validating the source URL in Build objects
 	SourceURL = "sourceurl"
+	// SourceArchive for validating the source archive in Build objects
+	SourceArchive = "sourcearchive"
 	// Sources for validating `spec.sources` entries
 	Sources = "sources"
 	// BuildName for validating `metadata.name` entry
@@ -61,6 +63,8 @@ func NewValidation(
 		return &Strategy{Build: build, Client: client}, nil
 	case SourceURL:
 		return &SourceURLRef{Build: build, Client: client}, nil
+	case SourceArchive:
+		return &SourceArchiveRef{Build: build}, nil
 	case OwnerReferences:
 		return &OwnerRef{Build: build, Client: client, Scheme: scheme}, nil
 	case Sources: